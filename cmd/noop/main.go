@@ -0,0 +1,34 @@
+package main
+
+import (
+	goat "github.com/mridang/dprint-plugin-go"
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+	"github.com/mridang/dprint-plugin-go/internal/dprint/plugin"
+)
+
+// config is the configuration of the noop plugin. It has no options of its
+// own; the files it claims are set with the shared fileExtensions and
+// fileNames options.
+type config struct{}
+
+// The noop plugin is registered with the shared runtime before the host
+// calls any export. It claims the configured files and leaves them as they
+// are, so that no other plugin formats them.
+var _ = plugin.Register(plugin.Definition[config]{
+	Manifest:      dprint.NoopManifest,
+	Version:       goat.Version(),
+	License:       goat.License(),
+	DefaultConfig: defaultConfig,
+	Formatter: dprint.PathIndependent(func(src []byte, _ config) ([]byte, error) {
+		return src, nil
+	}),
+})
+
+func defaultConfig() config {
+	return config{}
+}
+
+// The main is the entry point for the WASM module.
+func main() {
+	plugin.Main()
+}
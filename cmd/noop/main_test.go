@@ -0,0 +1,17 @@
+//goland:noinspection DuplicatedCode
+package main
+
+import (
+	"testing"
+
+	goat "github.com/mridang/dprint-plugin-go"
+	"github.com/mridang/dprint-plugin-go/internal/dprint/plugin"
+)
+
+// TestPlugin_Reports_Release_Version verifies that the plugin reports the
+// version of the release it is built from.
+func TestPlugin_Reports_Release_Version(t *testing.T) {
+	if got, want := plugin.Info().Version, goat.Version(); got != want {
+		t.Fatalf("plugin version = %q; want %q", got, want)
+	}
+}
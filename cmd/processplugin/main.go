@@ -0,0 +1,121 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	goat "github.com/mridang/dprint-plugin-go"
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+)
+
+const expectedArgCount = 3
+
+// This tool packages a plugin as a dprint process plugin for release. It
+// builds the plugin under cmd/<command> for every platform, zips each
+// executable into <command>-<platform>.zip and writes the
+// <command>.plugin.json that dprint loads the archives through, with their
+// checksums. The checksum of the plugin.json itself, which dprint
+// configurations reference it with, goes to <command>.plugin.json.sha256.
+//
+//	go run ./cmd/processplugin gofmt build
+func main() {
+	if len(os.Args) != expectedArgCount {
+		log.Fatalf("Usage: %s <command> <output-dir>", os.Args[0])
+	}
+	command, outDir := os.Args[1], os.Args[2]
+
+	var manifest dprint.Manifest
+	for _, m := range dprint.Manifests() {
+		if m.Command == command {
+			manifest = m
+		}
+	}
+	if manifest.Name == "" {
+		log.Fatalf("no plugin with command %q", command)
+	}
+
+	workDir, err := os.MkdirTemp("", "processplugin")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(workDir) }()
+
+	checksums := map[string]string{}
+	for _, platform := range dprint.ProcessPlatforms() {
+		executable := filepath.Join(workDir, platform.Name, manifest.ProcessExecutable(platform))
+		if err = build(command, platform, executable); err != nil {
+			log.Fatal(err)
+		}
+		archive := manifest.ProcessArchive(platform)
+		data, err := zipExecutable(executable)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err = os.WriteFile(filepath.Join(outDir, archive), data, 0o600); err != nil {
+			log.Fatal(err)
+		}
+		checksums[archive] = checksum(data)
+	}
+
+	pluginFile, err := manifest.ProcessPluginFile(goat.Version(), checksums)
+	if err != nil {
+		log.Fatal(err)
+	}
+	name := command + ".plugin.json"
+	if err = os.WriteFile(filepath.Join(outDir, name), pluginFile, 0o600); err != nil {
+		log.Fatal(err)
+	}
+	sum := fmt.Sprintf("%s  %s\n", checksum(pluginFile), name)
+	if err = os.WriteFile(filepath.Join(outDir, name+".sha256"), []byte(sum), 0o600); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// build compiles the plugin under cmd/<command> for platform to output,
+// without cgo so that the Linux binaries also run on musl.
+func build(command string, platform dprint.ProcessPlatform, output string) error {
+	cmd := exec.Command("go", "build", "-trimpath", "-ldflags=-s -w", "-o", output, "./cmd/"+command) //nolint:gosec // the command names a plugin manifest
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS="+platform.GOOS, "GOARCH="+platform.GOARCH)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("build %s for %s: %w", command, platform.Name, err)
+	}
+	return nil
+}
+
+// zipExecutable returns a zip archive holding the file at path, marked
+// executable.
+func zipExecutable(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	header := &zip.FileHeader{Name: filepath.Base(path), Method: zip.Deflate}
+	header.SetMode(0o755)
+	w, err := archive.CreateHeader(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// checksum returns the hex-encoded SHA-256 checksum of data.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -1,3 +1,5 @@
+//go:build integration
+
 package main
 
 import (
@@ -9,6 +11,8 @@ import (
 	"slices"
 	"testing"
 	"time"
+
+	"github.com/mridang/dprint-plugin-go/pkg/formatters"
 )
 
 // TestDprint_Formats_Sh_File verifies end-to-end formatting using dprint
@@ -50,7 +54,7 @@ func TestDprint_Formats_Sh_File(t *testing.T) {
 		t.Fatalf("write source: %v", err)
 	}
 
-	want, err := formatShell(bad, defaultConfig())
+	want, err := formatters.FormatShell("test.sh", bad, defaultConfig())
 	if err != nil {
 		t.Fatalf("shfmt failed on input: %v", err)
 	}
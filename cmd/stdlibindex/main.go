@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+const expectedArgCount = 2
+
+// This tool writes the index of standard library packages that the Go
+// formatter embeds to add missing imports without access to GOROOT, which
+// the WASM builds do not have. It reads the API files of the Go release it
+// runs with and writes one line per importable package: its path followed
+// by its exported package-level names.
+//
+//	go run ./cmd/stdlibindex pkg/formatters/gostdlib.txt
+func main() {
+	if len(os.Args) != expectedArgCount {
+		log.Fatalf("Usage: %s <output.txt>", os.Args[0])
+	}
+
+	files, err := filepath.Glob(filepath.Join(runtime.GOROOT(), "api", "go1*.txt"))
+	if err != nil || len(files) == 0 {
+		log.Fatalf("no API files found in %s", filepath.Join(runtime.GOROOT(), "api"))
+	}
+	index := map[string]map[string]bool{}
+	for _, file := range files {
+		if err = readAPI(file, index); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var out strings.Builder
+	paths := make([]string, 0, len(index))
+	for path := range index {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		names := make([]string, 0, len(index[path]))
+		for name := range index[path] {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		fmt.Fprintf(&out, "%s %s\n", path, strings.Join(names, " "))
+	}
+	if err = os.WriteFile(os.Args[1], []byte(out.String()), 0600); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readAPI adds the package-level names declared by the lines of an API
+// file, such as "pkg bufio, func NewReader(io.Reader) *Reader", to index.
+// Methods are skipped and fields and interface methods count as their type.
+func readAPI(file string, index map[string]map[string]bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		rest, ok := strings.CutPrefix(line, "pkg ")
+		if !ok {
+			continue
+		}
+		path, decl, ok := strings.Cut(rest, ", ")
+		if !ok {
+			continue
+		}
+		// Platform-specific lines name the platform: "pkg syscall (linux-386)".
+		path, _, _ = strings.Cut(path, " ")
+		if !importable(path) {
+			continue
+		}
+		kind, decl, _ := strings.Cut(decl, " ")
+		if kind != "const" && kind != "func" && kind != "type" && kind != "var" {
+			continue
+		}
+		name := decl
+		if i := strings.IndexAny(name, " ([,"); i >= 0 {
+			name = name[:i]
+		}
+		if index[path] == nil {
+			index[path] = map[string]bool{}
+		}
+		index[path][name] = true
+	}
+	return nil
+}
+
+// importable reports whether code outside the standard library may import
+// the package at path.
+func importable(path string) bool {
+	for _, elem := range strings.Split(path, "/") {
+		if elem == "internal" || elem == "vendor" {
+			return false
+		}
+	}
+	return !strings.HasPrefix(path, "cmd/")
+}
@@ -1,3 +1,5 @@
+//go:build integration
+
 package main
 
 import (
@@ -9,6 +11,8 @@ import (
 	"slices"
 	"testing"
 	"time"
+
+	"github.com/mridang/dprint-plugin-go/pkg/formatters"
 )
 
 // TestDprint_Formats_Tf_File verifies end-to-end formatting using dprint
@@ -60,7 +64,7 @@ func TestDprint_Formats_Tf_File(t *testing.T) {
 		t.Fatalf("write source: %v", err)
 	}
 
-	want, err := formatHCL(bad, defaultConfig())
+	want, err := formatters.FormatHCL("main.tf", bad, defaultConfig())
 	if err != nil {
 		t.Fatalf("formatHCL failed on input: %v", err)
 	}
@@ -0,0 +1,47 @@
+package dprint
+
+import (
+	"context"
+	"time"
+)
+
+// pollingContext is a context whose cancellation is discovered by polling.
+// The WASM runtime has no goroutines that could watch the host, so Err asks
+// the host every time it is called and closes Done once it reports that the
+// request was cancelled.
+type pollingContext struct {
+	poll func() bool
+	done chan struct{}
+	err  error
+}
+
+// PollingContext returns a context that becomes cancelled the first time
+// poll reports true. Cancellation is only observed through Err; code that
+// waits on Done alone will not see it until Err has been called.
+func PollingContext(poll func() bool) context.Context {
+	return &pollingContext{poll: poll, done: make(chan struct{})}
+}
+
+// Deadline reports that the context has no deadline.
+func (c *pollingContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Done returns a channel that is closed once cancellation has been seen.
+func (c *pollingContext) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err polls for cancellation and returns context.Canceled once it happened.
+func (c *pollingContext) Err() error {
+	if c.err == nil && c.poll() {
+		c.err = context.Canceled
+		close(c.done)
+	}
+	return c.err
+}
+
+// Value returns nil; the context carries no values.
+func (c *pollingContext) Value(any) any {
+	return nil
+}
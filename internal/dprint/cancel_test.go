@@ -0,0 +1,32 @@
+package dprint
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestPollingContext_Cancels_Once_Polled_True verifies that Err reports
+// cancellation from the first poll that returns true and closes Done.
+func TestPollingContext_Cancels_Once_Polled_True(t *testing.T) {
+	calls := 0
+	ctx := PollingContext(func() bool {
+		calls++
+		return calls > 1
+	})
+
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("first Err = %v; want nil", err)
+	}
+	if err := ctx.Err(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("second Err = %v; want %v", err, context.Canceled)
+	}
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatal("Done is not closed after cancellation")
+	}
+	if err := ctx.Err(); !errors.Is(err, context.Canceled) || calls != 2 {
+		t.Fatalf("Err after cancellation = %v with %d polls", err, calls)
+	}
+}
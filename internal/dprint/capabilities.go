@@ -0,0 +1,25 @@
+package dprint
+
+// Capabilities advertises the optional features a plugin supports, so
+// that hosts and tooling can adapt to each plugin. It is returned by the
+// get_capabilities export and by the capabilities command of native builds.
+type Capabilities struct {
+	// RangeFormatting is set when format_range formats only the part of a
+	// file around the range instead of the whole file.
+	RangeFormatting bool `json:"rangeFormatting"`
+
+	// Cancellation is set when the plugin polls the host for cancellation
+	// while it formats.
+	Cancellation bool `json:"cancellation"`
+
+	// OverrideConfig is set when set_override_config is honoured.
+	OverrideConfig bool `json:"overrideConfig"`
+
+	// IgnoreComments is set when dprint-ignore-file and
+	// dprint-ignore-start/end comments are honoured.
+	IgnoreComments bool `json:"ignoreComments"`
+
+	// FormatDiff is set when format_diff returns a unified diff of the
+	// changes format would make.
+	FormatDiff bool `json:"formatDiff"`
+}
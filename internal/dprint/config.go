@@ -0,0 +1,284 @@
+package dprint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RawFormatConfig is the payload the host places in the shared buffer before
+// calling register_config. The plugin section holds the user's options for
+// this plugin; the global section holds dprint's global configuration.
+type RawFormatConfig struct {
+	Plugin map[string]json.RawMessage `json:"plugin"`
+	Global GlobalConfig               `json:"global"`
+}
+
+// GlobalConfig mirrors the global section of a dprint configuration. Unset
+// values are nil or empty so that plugins can tell them apart from values
+// the user chose explicitly.
+type GlobalConfig struct {
+	LineWidth   *uint32 `json:"lineWidth,omitempty"`
+	IndentWidth *uint8  `json:"indentWidth,omitempty"`
+	UseTabs     *bool   `json:"useTabs,omitempty"`
+	NewLineKind string  `json:"newLineKind,omitempty"`
+}
+
+// ConfigDiagnosticer is implemented by validation errors that belong to a
+// specific configuration property.
+type ConfigDiagnosticer interface {
+	ConfigDiagnostic() (string, string)
+}
+
+// DecodeConfig decodes the plugin section of a register_config payload onto
+// targets, each of which must be a pointer to a struct holding defaults. A
+// property is decoded into every target that declares it. Every property is
+// decoded on its own so that one bad value does not discard the others.
+//
+// Malformed JSON, values rejected by a target's Validate method and
+// properties whose field carries a deprecated struct tag are returned as
+// diags; the tag text suggests what to use instead. Properties no target
+// declares and values of the wrong type are returned separately as
+// structural diagnostics; such values are skipped, and callers report them
+// only in strict mode.
+//
+// The payload is normally the {"plugin": ..., "global": ...} envelope sent
+// by the host; a bare object of plugin options is accepted as well.
+func DecodeConfig(raw []byte, targets ...any) (diags, structural []ConfigDiagnostic) {
+	diags, structural = []ConfigDiagnostic{}, []ConfigDiagnostic{}
+	if len(raw) == 0 {
+		return diags, structural
+	}
+
+	section, err := pluginSection(raw)
+	if err != nil {
+		diags = append(diags, ConfigDiagnostic{
+			PropertyName: "",
+			Message:      "invalid configuration JSON: " + err.Error(),
+		})
+		return diags, structural
+	}
+
+	return DecodeOptions(section, targets...)
+}
+
+// kebabPattern matches kebab-case property names such as binary-next-line.
+var kebabPattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)+$`) //nolint:gochecknoglobals // read-only lookup
+
+// DecodeOptions decodes a map of plugin options onto targets and reports
+// diagnostics like DecodeConfig does. Properties may be written in
+// kebab-case, such as binary-next-line for binaryNextLine; a property
+// written both ways keeps the camelCase value and is reported.
+func DecodeOptions(section map[string]json.RawMessage, targets ...any) (diags, structural []ConfigDiagnostic) {
+	section, written, diags := resolveAliases(section)
+	structural = []ConfigDiagnostic{}
+	keys := make([]string, 0, len(section))
+	for key := range section {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	values := make([]reflect.Value, len(targets))
+	fields := make([]map[string]int, len(targets))
+	for i, target := range targets {
+		values[i] = reflect.ValueOf(target).Elem()
+		fields[i] = jsonFields(values[i].Type())
+	}
+
+	for _, key := range keys {
+		property := written[key]
+		known := false
+		for i := range targets {
+			index, ok := fields[i][key]
+			if !ok {
+				continue
+			}
+			known = true
+			if diag, failed := decodeField(values[i].Field(index), property, section[key]); failed {
+				structural = append(structural, diag)
+				break
+			}
+			if replacement, deprecated := values[i].Type().Field(index).Tag.Lookup("deprecated"); deprecated {
+				diags = append(diags, ConfigDiagnostic{PropertyName: property, Message: "deprecated; " + replacement})
+			}
+		}
+		if !known {
+			structural = append(structural, ConfigDiagnostic{PropertyName: property, Message: "unknown property"})
+		}
+	}
+
+	for _, target := range targets {
+		diags = append(diags, ValidateConfig(target)...)
+	}
+	SortDiagnostics(diags)
+	SortDiagnostics(structural)
+	return diags, structural
+}
+
+// resolveAliases renames kebab-case properties to camelCase. It returns the
+// renamed options, the name each property was written with for use in
+// diagnostics, and a diagnostic for every property written both ways.
+func resolveAliases(
+	section map[string]json.RawMessage,
+) (map[string]json.RawMessage, map[string]string, []ConfigDiagnostic) {
+	resolved := make(map[string]json.RawMessage, len(section))
+	written := make(map[string]string, len(section))
+	diags := []ConfigDiagnostic{}
+	for key, value := range section {
+		name := camelCase(key)
+		if _, both := section[name]; both && name != key {
+			diags = append(diags, ConfigDiagnostic{
+				PropertyName: key,
+				Message:      "same as " + name + ", which is also set; remove one",
+			})
+			continue
+		}
+		resolved[name] = value
+		written[name] = key
+	}
+	return resolved, written, diags
+}
+
+// camelCase converts a kebab-case property name to camelCase and returns
+// any other name unchanged.
+func camelCase(key string) string {
+	if !kebabPattern.MatchString(key) {
+		return key
+	}
+	words := strings.Split(key, "-")
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// DecodeGlobalConfig extracts the global section of a register_config
+// payload. Payloads without one, or with one the host would not send,
+// yield an empty GlobalConfig.
+func DecodeGlobalConfig(raw []byte) GlobalConfig {
+	var envelope RawFormatConfig
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return GlobalConfig{}
+	}
+	return envelope.Global
+}
+
+// decodeField decodes a single property value into field, leaving the field
+// untouched and returning a diagnostic if the value has the wrong type.
+func decodeField(field reflect.Value, key string, data json.RawMessage) (ConfigDiagnostic, bool) {
+	value := reflect.New(field.Type())
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return ConfigDiagnostic{PropertyName: key, Message: typeMismatchMessage(field.Type(), err)}, true
+	}
+	field.Set(value.Elem())
+	return ConfigDiagnostic{}, false
+}
+
+// ValidateConfig runs the Validate method of cfg, if it has one, and turns
+// the returned errors into diagnostics. Errors joined with errors.Join are
+// reported individually.
+func ValidateConfig(cfg any) []ConfigDiagnostic {
+	validator, ok := cfg.(interface{ Validate() error })
+	if !ok {
+		return nil
+	}
+	err := validator.Validate()
+	if err == nil {
+		return nil
+	}
+
+	errs := []error{err}
+	if joined, isJoined := err.(interface{ Unwrap() []error }); isJoined { //nolint:errorlint // only the top level is split
+		errs = joined.Unwrap()
+	}
+
+	diags := make([]ConfigDiagnostic, 0, len(errs))
+	for _, e := range errs {
+		var withProperty ConfigDiagnosticer
+		if errors.As(e, &withProperty) {
+			property, message := withProperty.ConfigDiagnostic()
+			diags = append(diags, ConfigDiagnostic{PropertyName: property, Message: message})
+			continue
+		}
+		diags = append(diags, ConfigDiagnostic{PropertyName: "", Message: e.Error()})
+	}
+	return diags
+}
+
+// pluginSection extracts the plugin options from a register_config payload.
+func pluginSection(raw []byte) (map[string]json.RawMessage, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &top); err != nil {
+		return nil, err
+	}
+	_, hasPlugin := top["plugin"]
+	_, hasGlobal := top["global"]
+	if !hasPlugin && !hasGlobal {
+		return top, nil
+	}
+
+	var envelope struct {
+		Plugin map[string]json.RawMessage `json:"plugin"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Plugin == nil {
+		return map[string]json.RawMessage{}, nil
+	}
+	return envelope.Plugin, nil
+}
+
+// jsonFields maps the JSON property names of a struct type to field indexes.
+func jsonFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// typeMismatchMessage describes why a property value could not be decoded.
+func typeMismatchMessage(t reflect.Type, err error) string {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("expected %s but got %s", jsonTypeName(t), typeErr.Value)
+	}
+	return fmt.Sprintf("expected %s: %v", jsonTypeName(t), err)
+}
+
+// jsonTypeName names the JSON type that decodes into t.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() { //nolint:exhaustive // remaining kinds never appear in configs
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,130 @@
+package dprint
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// sampleConfig is a plugin configuration used to exercise DecodeConfig.
+type sampleConfig struct {
+	Indent  int    `json:"indent"`
+	Tabs    bool   `json:"useTabs"`
+	Dialect string `json:"dialect"`
+	Pad     bool   `json:"pad"     deprecated:"use indent instead"`
+}
+
+// sampleError is a validation error tied to a property.
+type sampleError struct{ property string }
+
+func (e *sampleError) Error() string { return e.property + " is invalid" }
+
+func (e *sampleError) ConfigDiagnostic() (string, string) { return e.property, "is invalid" }
+
+// Validate rejects negative indents.
+func (c sampleConfig) Validate() error {
+	if c.Indent < 0 {
+		return errors.Join(&sampleError{property: "indent"}, errors.New("general problem"))
+	}
+	return nil
+}
+
+// TestDecodeConfig_Reports_Diagnostics verifies decoding of the
+// register_config envelope and every kind of diagnostic it produces.
+func TestDecodeConfig_Reports_Diagnostics(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		want       sampleConfig
+		diags      []ConfigDiagnostic
+		structural []ConfigDiagnostic
+	}{
+		{
+			name:  "envelope",
+			raw:   `{"plugin":{"indent":4,"useTabs":true},"global":{"lineWidth":80}}`,
+			want:  sampleConfig{Indent: 4, Tabs: true, Dialect: "x"},
+			diags: []ConfigDiagnostic{},
+		},
+		{
+			name:  "bare object",
+			raw:   `{"dialect":"y"}`,
+			want:  sampleConfig{Indent: 2, Dialect: "y"},
+			diags: []ConfigDiagnostic{},
+		},
+		{
+			name:  "unknown key and wrong type",
+			raw:   `{"plugin":{"indnet":4,"useTabs":"yes","indent":8}}`,
+			want:  sampleConfig{Indent: 8, Dialect: "x"},
+			diags: []ConfigDiagnostic{},
+			structural: []ConfigDiagnostic{
+				{PropertyName: "indnet", Message: "unknown property"},
+				{PropertyName: "useTabs", Message: "expected boolean but got string"},
+			},
+		},
+		{
+			name:  "kebab-case",
+			raw:   `{"plugin":{"use-tabs":true,"indent":4}}`,
+			want:  sampleConfig{Indent: 4, Tabs: true, Dialect: "x"},
+			diags: []ConfigDiagnostic{},
+		},
+		{
+			name: "kebab-case and camelCase",
+			raw:  `{"plugin":{"use-tabs":false,"useTabs":true}}`,
+			want: sampleConfig{Indent: 2, Tabs: true, Dialect: "x"},
+			diags: []ConfigDiagnostic{
+				{PropertyName: "use-tabs", Message: "same as useTabs, which is also set; remove one"},
+			},
+		},
+		{
+			name:  "kebab-case wrong type",
+			raw:   `{"plugin":{"use-tabs":1,"no-such-key":1}}`,
+			want:  sampleConfig{Indent: 2, Dialect: "x"},
+			diags: []ConfigDiagnostic{},
+			structural: []ConfigDiagnostic{
+				{PropertyName: "no-such-key", Message: "unknown property"},
+				{PropertyName: "use-tabs", Message: "expected boolean but got number"},
+			},
+		},
+		{
+			name: "deprecated",
+			raw:  `{"plugin":{"pad":true}}`,
+			want: sampleConfig{Indent: 2, Dialect: "x", Pad: true},
+			diags: []ConfigDiagnostic{
+				{PropertyName: "pad", Message: "deprecated; use indent instead"},
+			},
+		},
+		{
+			name: "validation",
+			raw:  `{"plugin":{"indent":-1}}`,
+			want: sampleConfig{Indent: -1, Dialect: "x"},
+			diags: []ConfigDiagnostic{
+				{PropertyName: "", Message: "general problem"},
+				{PropertyName: "indent", Message: "is invalid"},
+			},
+		},
+		{
+			name: "malformed",
+			raw:  `{`,
+			want: sampleConfig{Indent: 2, Dialect: "x"},
+			diags: []ConfigDiagnostic{
+				{PropertyName: "", Message: "invalid configuration JSON: unexpected end of JSON input"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := sampleConfig{Indent: 2, Dialect: "x"}
+			diags, structural := DecodeConfig([]byte(tt.raw), &cfg)
+			if cfg != tt.want {
+				t.Fatalf("config = %+v; want %+v", cfg, tt.want)
+			}
+			if !reflect.DeepEqual(diags, tt.diags) {
+				t.Fatalf("diagnostics = %+v; want %+v", diags, tt.diags)
+			}
+			if want := append([]ConfigDiagnostic{}, tt.structural...); !reflect.DeepEqual(structural, want) {
+				t.Fatalf("structural diagnostics = %+v; want %+v", structural, want)
+			}
+		})
+	}
+}
@@ -7,7 +7,8 @@ const (
 	// PluginSchemaVersion Schema version supported by this plugin.
 	PluginSchemaVersion = 4
 
-	// SharedBufferSize Shared buffer size (1MB) for communication between host and plugin.
+	// SharedBufferSize Initial shared buffer size (1MB) for communication between
+	// host and plugin. The buffer grows when the host or a result needs more.
 	SharedBufferSize = 1 << 20
 
 	// FormatResultNoChange Format return values as defined by dprint WASM ABI.
@@ -0,0 +1,186 @@
+package dprint
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// UnifiedDiff returns the changes from original to formatted as a unified
+// diff of path, the format produced by diff -u and git diff. Identical
+// inputs produce an empty diff.
+func UnifiedDiff(path string, original, formatted []byte) []byte {
+	a, b := splitLines(original), splitLines(formatted)
+	edits := diffLines(a, b)
+
+	var out bytes.Buffer
+	for _, h := range hunks(edits) {
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", path, path)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(h.aStart, h.aCount), hunkRange(h.bStart, h.bCount))
+		for _, e := range edits[h.from:h.to] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			if e.line == "" || e.line[len(e.line)-1] != '\n' {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.Bytes()
+}
+
+// edit is one line of a diff: kept (' '), removed ('-') or added ('+').
+type edit struct {
+	op   byte
+	line string
+}
+
+// hunk is a run of edits shown together, with the first line and number of
+// lines it covers in the original and the formatted text.
+type hunk struct {
+	from, to       int
+	aStart, aCount int
+	bStart, bCount int
+}
+
+// splitLines splits s after every LF, keeping the line endings so that a
+// missing final newline shows up as a change.
+func splitLines(s []byte) []string {
+	var lines []string
+	for len(s) > 0 {
+		i := bytes.IndexByte(s, '\n') + 1
+		if i == 0 {
+			i = len(s)
+		}
+		lines = append(lines, string(s[:i]))
+		s = s[i:]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script turning a into b, computed
+// with Myers' algorithm.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		if done {
+			break
+		}
+	}
+
+	// Walk the trace backwards from the end of both inputs.
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			edits = append(edits, edit{op: ' ', line: a[x]})
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{op: '+', line: b[y]})
+		} else {
+			x--
+			edits = append(edits, edit{op: '-', line: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		edits = append(edits, edit{op: ' ', line: a[x]})
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// hunks groups the changes of edits, with up to diffContext unchanged lines
+// around each, merging changes whose context would overlap.
+func hunks(edits []edit) []hunk {
+	var result []hunk
+	aLine, bLine := 0, 0
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			aLine, bLine, i = aLine+1, bLine+1, i+1
+			continue
+		}
+		from := max(0, i-diffContext)
+		for j := i; j > from; j-- {
+			if edits[j-1].op != ' ' {
+				break
+			}
+			aLine, bLine = aLine-1, bLine-1
+		}
+		h := hunk{from: from, aStart: aLine, bStart: bLine}
+		end, unchanged := i, 0
+		for end < len(edits) && unchanged <= 2*diffContext {
+			if edits[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			end++
+		}
+		h.to = min(end-unchanged+diffContext, len(edits))
+		for _, e := range edits[h.from:h.to] {
+			if e.op != '+' {
+				h.aCount++
+			}
+			if e.op != '-' {
+				h.bCount++
+			}
+		}
+		aLine, bLine = h.aStart+h.aCount, h.bStart+h.bCount
+		result = append(result, h)
+		i = h.to
+	}
+	return result
+}
+
+// hunkRange formats the line range of a hunk header. Empty ranges name the
+// line before them, as diff -u does.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
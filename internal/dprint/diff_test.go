@@ -0,0 +1,56 @@
+package dprint
+
+import "testing"
+
+// TestUnifiedDiff_Matches_Diff_U verifies the hunks, context and headers
+// against the output of diff -u.
+func TestUnifiedDiff_Matches_Diff_U(t *testing.T) {
+	long := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	tests := []struct {
+		name      string
+		original  string
+		formatted string
+		want      string
+	}{
+		{
+			name:      "unchanged",
+			original:  "a\nb\n",
+			formatted: "a\nb\n",
+			want:      "",
+		},
+		{
+			name:      "one line",
+			original:  "a\nb\nc\n",
+			formatted: "a\nB\nc\n",
+			want:      "--- a/f.go\n+++ b/f.go\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:      "separate hunks",
+			original:  long,
+			formatted: "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: "--- a/f.go\n+++ b/f.go\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name:      "into empty",
+			original:  "",
+			formatted: "a\n",
+			want:      "--- a/f.go\n+++ b/f.go\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:      "final newline",
+			original:  "a\nb",
+			formatted: "a\nb\n",
+			want:      "--- a/f.go\n+++ b/f.go\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(UnifiedDiff("f.go", []byte(tt.original), []byte(tt.formatted))); got != tt.want {
+				t.Fatalf("UnifiedDiff = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package dprint
+
+import "context"
+
+// Formatter formats a single file. It receives the path the host is
+// formatting, the file contents and the plugin configuration resolved for
+// that file. Implementations only deal with formatting; the WASM ABI is
+// served by the plugin runtime they are registered with.
+//
+// The context is cancelled when the host cancels the request. Formatters
+// that work in several passes should check it between them. The source may
+// live in memory shared with the host, so formatters must not modify it or
+// keep it after returning; returning it unchanged is fine.
+type Formatter[C any] interface {
+	Format(ctx context.Context, path string, src []byte, cfg C) ([]byte, error)
+}
+
+// FormatterFunc adapts an ordinary function to the Formatter interface.
+type FormatterFunc[C any] func(ctx context.Context, path string, src []byte, cfg C) ([]byte, error)
+
+// Format calls f(ctx, path, src, cfg).
+func (f FormatterFunc[C]) Format(ctx context.Context, path string, src []byte, cfg C) ([]byte, error) {
+	return f(ctx, path, src, cfg)
+}
+
+// PathIndependent adapts a formatting function that needs neither the file
+// path nor the context, such as FormatGo in pkg/formatters, to the
+// Formatter interface.
+func PathIndependent[C any](fn func(src []byte, cfg C) ([]byte, error)) Formatter[C] {
+	return FormatterFunc[C](func(_ context.Context, _ string, src []byte, cfg C) ([]byte, error) {
+		return fn(src, cfg)
+	})
+}
+
+// RangeFormatter formats the part of a file around the byte range
+// [start, end). Implementations return formatters.ErrRangeUnsupported when
+// they cannot honour the range; the runtime then formats the whole file.
+type RangeFormatter[C any] interface {
+	FormatRange(ctx context.Context, path string, src []byte, start, end int, cfg C) ([]byte, error)
+}
+
+// RangeFormatterFunc adapts an ordinary function to the RangeFormatter
+// interface.
+type RangeFormatterFunc[C any] func(ctx context.Context, path string, src []byte, start, end int, cfg C) ([]byte, error)
+
+// FormatRange calls f(ctx, path, src, start, end, cfg).
+func (f RangeFormatterFunc[C]) FormatRange(
+	ctx context.Context,
+	path string,
+	src []byte,
+	start, end int,
+	cfg C,
+) ([]byte, error) {
+	return f(ctx, path, src, start, end, cfg)
+}
@@ -0,0 +1,122 @@
+package dprint
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// Comment directives that exclude code from formatting.
+const (
+	// IgnoreFileDirective excludes a file from formatting when it appears
+	// in the file's leading comments.
+	IgnoreFileDirective = "dprint-ignore-file"
+
+	// IgnoreStartDirective and IgnoreEndDirective enclose lines that are
+	// kept exactly as written.
+	IgnoreStartDirective = "dprint-ignore-start"
+	IgnoreEndDirective   = "dprint-ignore-end"
+)
+
+// HasIgnoreFileDirective reports whether the leading comments of src
+// contain directive as a comment of its own. Leading comments are the line
+// comments, started by one of prefixes, and blank lines before the first
+// line of code; a shebang line counts as a comment.
+func HasIgnoreFileDirective(src []byte, prefixes []string, directive string) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(nil, len(src)+1)
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		isShebang := first && strings.HasPrefix(line, "#!")
+		first = false
+		if line == "" || isShebang {
+			continue
+		}
+		text, ok := commentText(line, prefixes)
+		if !ok {
+			return false
+		}
+		if text == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// commentText returns the text of a line comment without its prefix.
+func commentText(line string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if text, ok := strings.CutPrefix(line, prefix); ok {
+			return strings.TrimSpace(text), true
+		}
+	}
+	return "", false
+}
+
+// RestoreIgnoredRegions copies the lines between each dprint-ignore-start
+// and dprint-ignore-end comment of original over the matching region of
+// formatted, undoing whatever the formatter did to them. The marker
+// comments themselves are formatted like any other comment.
+func RestoreIgnoredRegions(original, formatted []byte, prefixes []string) ([]byte, error) {
+	originalRegions, err := ignoredRegions(original, prefixes)
+	if err != nil || len(originalRegions) == 0 {
+		return formatted, err
+	}
+	formattedRegions, err := ignoredRegions(formatted, prefixes)
+	if err != nil {
+		return nil, err
+	}
+	if len(formattedRegions) != len(originalRegions) {
+		return nil, errors.New("formatting changed the dprint-ignore-start and dprint-ignore-end comments")
+	}
+
+	out := make([]byte, 0, len(formatted))
+	last := 0
+	for i, region := range formattedRegions {
+		out = append(out, formatted[last:region.start]...)
+		out = append(out, original[originalRegions[i].start:originalRegions[i].end]...)
+		last = region.end
+	}
+	return append(out, formatted[last:]...), nil
+}
+
+// region is a half-open byte range of a file.
+type region struct {
+	start, end int
+}
+
+// ignoredRegions returns the lines between each pair of ignore markers,
+// from the start of the line after dprint-ignore-start to the start of the
+// dprint-ignore-end line.
+func ignoredRegions(src []byte, prefixes []string) ([]region, error) {
+	var regions []region
+	open := -1
+	for offset := 0; offset < len(src); {
+		end := bytes.IndexByte(src[offset:], '\n') + 1
+		if end == 0 {
+			end = len(src) - offset
+		}
+		text, isComment := commentText(strings.TrimSpace(string(src[offset:offset+end])), prefixes)
+		switch {
+		case !isComment:
+		case text == IgnoreStartDirective:
+			if open >= 0 {
+				return nil, errors.New("dprint-ignore-start inside another ignored region")
+			}
+			open = offset + end
+		case text == IgnoreEndDirective:
+			if open < 0 {
+				return nil, errors.New("dprint-ignore-end without dprint-ignore-start")
+			}
+			regions = append(regions, region{start: open, end: offset})
+			open = -1
+		}
+		offset += end
+	}
+	if open >= 0 {
+		return nil, errors.New("dprint-ignore-start without dprint-ignore-end")
+	}
+	return regions, nil
+}
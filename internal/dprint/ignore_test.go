@@ -0,0 +1,54 @@
+package dprint
+
+import "testing"
+
+// TestHasIgnoreFileDirective_Only_Checks_Leading_Comments covers where the
+// directive is and is not honoured.
+func TestHasIgnoreFileDirective_Only_Checks_Leading_Comments(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		prefixes []string
+		want     bool
+	}{
+		{"first line", "# dprint-ignore-file\necho hi\n", []string{"#"}, true},
+		{"after shebang", "#!/bin/sh\n\n# dprint-ignore-file\necho hi\n", []string{"#"}, true},
+		{"after header", "// Copyright\n//dprint-ignore-file\npackage main\n", []string{"//"}, true},
+		{"second prefix", "// dprint-ignore-file\na = 1\n", []string{"#", "//"}, true},
+		{"after code", "echo hi\n# dprint-ignore-file\n", []string{"#"}, false},
+		{"other prefix", "// dprint-ignore-file\necho hi\n", []string{"#"}, false},
+		{"longer comment", "# dprint-ignore-file please\necho hi\n", []string{"#"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasIgnoreFileDirective([]byte(tt.src), tt.prefixes, IgnoreFileDirective); got != tt.want {
+				t.Fatalf("HasIgnoreFileDirective = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRestoreIgnoredRegions_Keeps_Original_Lines verifies that the lines
+// between the markers survive formatting while the rest is formatted.
+func TestRestoreIgnoredRegions_Keeps_Original_Lines(t *testing.T) {
+	original := "a  =  1\n# dprint-ignore-start\nb  =  2\nc  =  3\n  # dprint-ignore-end\nd  =  4\n"
+	formatted := "a = 1\n# dprint-ignore-start\nb = 2\nc = 3\n# dprint-ignore-end\nd = 4\n"
+
+	got, err := RestoreIgnoredRegions([]byte(original), []byte(formatted), []string{"#"})
+	if err != nil {
+		t.Fatalf("RestoreIgnoredRegions: %v", err)
+	}
+	want := "a = 1\n# dprint-ignore-start\nb  =  2\nc  =  3\n# dprint-ignore-end\nd = 4\n"
+	if string(got) != want {
+		t.Fatalf("RestoreIgnoredRegions = %q; want %q", got, want)
+	}
+}
+
+// TestRestoreIgnoredRegions_Rejects_Unbalanced_Markers verifies that a
+// missing end marker is reported instead of ignoring the rest of the file.
+func TestRestoreIgnoredRegions_Rejects_Unbalanced_Markers(t *testing.T) {
+	src := []byte("# dprint-ignore-start\na = 1\n")
+	if _, err := RestoreIgnoredRegions(src, src, []string{"#"}); err == nil {
+		t.Fatal("RestoreIgnoredRegions accepted an unterminated region")
+	}
+}
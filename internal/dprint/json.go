@@ -0,0 +1,65 @@
+package dprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MarshalCanonical serializes v as canonical JSON: object keys are sorted at
+// every level, numbers keep their original representation and HTML
+// characters are left unescaped. Responses produced this way are byte-for-byte
+// stable across runs and builds, regardless of struct field order or map
+// iteration order.
+func MarshalCanonical(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var tree any
+	if err = dec.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err = enc.Encode(tree); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// SortDiagnostics orders diagnostics by property name and then message so
+// that the reported list does not depend on validation order.
+func SortDiagnostics(diags []ConfigDiagnostic) {
+	slices.SortFunc(diags, func(a, b ConfigDiagnostic) int {
+		if c := strings.Compare(a.PropertyName, b.PropertyName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Message, b.Message)
+	})
+}
+
+// MergeObjects serializes each value as a JSON object and merges their
+// properties into one object. Later values win when a property repeats.
+func MergeObjects(values ...any) (map[string]json.RawMessage, error) {
+	merged := map[string]json.RawMessage{}
+	for _, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		var object map[string]json.RawMessage
+		if err = json.Unmarshal(raw, &object); err != nil {
+			return nil, err
+		}
+		maps.Copy(merged, object)
+	}
+	return merged, nil
+}
@@ -0,0 +1,59 @@
+package dprint
+
+import (
+	"testing"
+)
+
+// TestMarshalCanonical_Sorts_Keys verifies that struct fields and map keys
+// are emitted in sorted order at every nesting level.
+func TestMarshalCanonical_Sorts_Keys(t *testing.T) {
+	info := PluginInfo{
+		Name:           "dprint-plugin-test",
+		Version:        "1.0.0",
+		ConfigKey:      "test",
+		FileExtensions: []string{"b", "a"},
+		FileNames:      []string{},
+	}
+
+	got, err := MarshalCanonical(info)
+	if err != nil {
+		t.Fatalf("MarshalCanonical: %v", err)
+	}
+	want := `{"configKey":"test","configSchemaUrl":"","fileExtensions":["b","a"],` +
+		`"fileNames":[],"helpUrl":"","name":"dprint-plugin-test","version":"1.0.0"}`
+	if string(got) != want {
+		t.Fatalf("MarshalCanonical = %s; want %s", got, want)
+	}
+
+	nested := map[string]any{"z": map[string]int{"y": 2, "x": 1}, "a": "<&>", "n": 1.50}
+	got, err = MarshalCanonical(nested)
+	if err != nil {
+		t.Fatalf("MarshalCanonical: %v", err)
+	}
+	want = `{"a":"<&>","n":1.5,"z":{"x":1,"y":2}}`
+	if string(got) != want {
+		t.Fatalf("MarshalCanonical = %s; want %s", got, want)
+	}
+}
+
+// TestSortDiagnostics_Orders_By_Property verifies the diagnostic ordering
+// used before serialization.
+func TestSortDiagnostics_Orders_By_Property(t *testing.T) {
+	diags := []ConfigDiagnostic{
+		{PropertyName: "indent", Message: "b"},
+		{PropertyName: "", Message: "z"},
+		{PropertyName: "indent", Message: "a"},
+	}
+	SortDiagnostics(diags)
+
+	want := []ConfigDiagnostic{
+		{PropertyName: "", Message: "z"},
+		{PropertyName: "indent", Message: "a"},
+		{PropertyName: "indent", Message: "b"},
+	}
+	for i := range want {
+		if diags[i] != want[i] {
+			t.Fatalf("diags[%d] = %+v; want %+v", i, diags[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package dprint
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Locations derived from a plugin's manifest.
+const (
+	// HelpURLPrefix is followed by the plugin's command name, which is also
+	// the README section documenting its options.
+	HelpURLPrefix = "https://github.com/mridang/dprint-goat#"
+
+	// ReleaseURLPrefix is where the assets of the latest release are
+	// published, including every plugin's <command>.schema.json.
+	ReleaseURLPrefix = "https://github.com/mridang/dprint-goat/releases/latest/download/"
+)
+
+// identifierPattern matches plugin names, command names and config keys.
+var identifierPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`) //nolint:gochecknoglobals // read-only lookup
+
+// Manifest describes a plugin once. The plugin info reported through
+// get_plugin_info, the file matching reported through
+// get_config_file_matching and the help and schema URLs are all derived
+// from it, so they cannot disagree.
+type Manifest struct {
+	// Name is the plugin name reported to the host, such as
+	// "dprint-plugin-gofmt".
+	Name string
+
+	// Command is the name of the plugin's directory under cmd, of its
+	// build artifacts and of its README section, such as "gofmt".
+	Command string
+
+	// ConfigKey is the key of the plugin's block in a dprint configuration.
+	ConfigKey string
+
+	// FileExtensions lists the extensions, without the dot, of the files
+	// the plugin formats.
+	FileExtensions []string
+
+	// FileNames lists file names the plugin formats regardless of extension.
+	FileNames []string
+
+	// Passthrough marks a plugin that claims files without formatting them,
+	// so that no other plugin touches them. It matches no files unless the
+	// shared fileExtensions and fileNames options name some, and the runtime
+	// returns every file unchanged.
+	Passthrough bool
+}
+
+// Validate reports manifests whose names, config key or file matching are
+// malformed.
+func (m Manifest) Validate() error {
+	var errs []error
+	if !strings.HasPrefix(m.Name, "dprint-plugin-") || !identifierPattern.MatchString(m.Name) {
+		errs = append(errs, fmt.Errorf("name %q must start with dprint-plugin-", m.Name))
+	}
+	if !identifierPattern.MatchString(m.Command) {
+		errs = append(errs, fmt.Errorf("command %q must be lower case letters, digits and dashes", m.Command))
+	}
+	if !identifierPattern.MatchString(m.ConfigKey) {
+		errs = append(errs, fmt.Errorf("config key %q must be lower case letters, digits and dashes", m.ConfigKey))
+	}
+	if len(m.FileExtensions) == 0 && len(m.FileNames) == 0 && !m.Passthrough {
+		errs = append(errs, errors.New("no file extensions or file names"))
+	}
+	for _, problem := range extensionProblems(m.FileExtensions) {
+		errs = append(errs, errors.New("file extension "+problem))
+	}
+	for _, problem := range fileNameProblems(m.FileNames) {
+		errs = append(errs, errors.New("file name "+problem))
+	}
+	return errors.Join(errs...)
+}
+
+// PluginInfo returns the plugin info for the given version, which is
+// trimmed so that it can come straight from an embedded VERSION file.
+func (m Manifest) PluginInfo(version string) PluginInfo {
+	return PluginInfo{
+		Name:            m.Name,
+		Version:         strings.TrimSpace(version),
+		ConfigKey:       m.ConfigKey,
+		FileExtensions:  nonNil(m.FileExtensions),
+		FileNames:       nonNil(m.FileNames),
+		HelpURL:         HelpURLPrefix + m.Command,
+		ConfigSchemaURL: ReleaseURLPrefix + m.Command + ".schema.json",
+	}
+}
+
+// FileMatching returns the file matching info reported to the host. The
+// shared fileExtensions and fileNames options, when set, replace the
+// manifest's lists, and extraFileNames adds to the names.
+func (m Manifest) FileMatching(shared SharedConfig) FileMatchingInfo {
+	info := FileMatchingInfo{FileExtensions: nonNil(m.FileExtensions), FileNames: nonNil(m.FileNames)}
+	if shared.FileExtensions != nil {
+		info.FileExtensions = shared.FileExtensions
+	}
+	if shared.FileNames != nil {
+		info.FileNames = shared.FileNames
+	}
+	for _, name := range shared.ExtraFileNames {
+		if !slices.Contains(info.FileNames, name) {
+			info.FileNames = append(slices.Clip(info.FileNames), name)
+		}
+	}
+	return info
+}
+
+// extensionProblems describes the malformed and repeated entries of a list
+// of file extensions.
+func extensionProblems(extensions []string) []string {
+	var problems []string
+	for i, ext := range extensions {
+		if ext == "" || strings.HasPrefix(ext, ".") || ext != strings.ToLower(ext) {
+			problems = append(problems, fmt.Sprintf("%q must be lower case without a leading dot", ext))
+		}
+		if slices.Contains(extensions[:i], ext) {
+			problems = append(problems, fmt.Sprintf("%q is listed twice", ext))
+		}
+	}
+	return problems
+}
+
+// fileNameProblems describes the malformed and repeated entries of a list
+// of file names.
+func fileNameProblems(names []string) []string {
+	var problems []string
+	for i, name := range names {
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			problems = append(problems, fmt.Sprintf("%q must be a plain file name", name))
+		}
+		if slices.Contains(names[:i], name) {
+			problems = append(problems, fmt.Sprintf("%q is listed twice", name))
+		}
+	}
+	return problems
+}
+
+// nonNil returns s, or an empty slice if s is nil, so that it serializes
+// as an empty JSON array.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// The manifests of the plugins built from this repository.
+var (
+	GofmtManifest = Manifest{ //nolint:gochecknoglobals // read-only manifest
+		Name:           "dprint-plugin-gofmt",
+		Command:        "gofmt",
+		ConfigKey:      "go-gofmt",
+		FileExtensions: []string{"go"},
+	}
+	ShfmtManifest = Manifest{ //nolint:gochecknoglobals // read-only manifest
+		Name:           "dprint-plugin-shfmt",
+		Command:        "shfmt",
+		ConfigKey:      "go-shfmt",
+		FileExtensions: []string{"sh", "bash", "zsh", "ksh"},
+		FileNames: []string{
+			".bashrc", ".bash_profile", ".bash_login", ".bash_logout", ".profile",
+			".zshrc", ".zshenv", ".zprofile", ".zlogin", ".zlogout",
+			".kshrc", ".mkshrc", "PKGBUILD", "APKBUILD",
+		},
+	}
+	TffmtManifest = Manifest{ //nolint:gochecknoglobals // read-only manifest
+		Name:      "dprint-plugin-gohcl",
+		Command:   "tffmt",
+		ConfigKey: "go-hcl",
+		FileExtensions: []string{
+			"tf", "tfvars", "tftest.hcl", "tfmock.hcl", "hcl", "tf.json", "tfvars.json", "pkr.hcl", "pkrvars.hcl",
+			"nomad", "nomad.hcl", "sentinel",
+		},
+		FileNames: []string{"terragrunt.hcl", "terragrunt.hcl.json", ".terraform.lock.hcl"},
+	}
+	NoopManifest = Manifest{ //nolint:gochecknoglobals // read-only manifest
+		Name:        "dprint-plugin-gonoop",
+		Command:     "noop",
+		ConfigKey:   "go-noop",
+		Passthrough: true,
+	}
+)
+
+// Manifests returns the manifests of every plugin built from this
+// repository.
+func Manifests() []Manifest {
+	return []Manifest{GofmtManifest, ShfmtManifest, TffmtManifest, NoopManifest}
+}
@@ -0,0 +1,78 @@
+package dprint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestManifests_Agree verifies that every plugin manifest is valid, that
+// no two plugins share a name, command, config key or file extension, and
+// that each command has a directory under cmd.
+func TestManifests_Agree(t *testing.T) {
+	seen := map[string]string{}
+	claim := func(kind, value, owner string) {
+		key := kind + " " + value
+		if other, ok := seen[key]; ok {
+			t.Fatalf("%s %q is used by %s and %s", kind, value, other, owner)
+		}
+		seen[key] = owner
+	}
+
+	for _, m := range Manifests() {
+		if err := m.Validate(); err != nil {
+			t.Fatalf("manifest %s: %v", m.Name, err)
+		}
+		claim("name", m.Name, m.Command)
+		claim("command", m.Command, m.Command)
+		claim("config key", m.ConfigKey, m.Command)
+		for _, ext := range m.FileExtensions {
+			claim("file extension", ext, m.Command)
+		}
+		if _, err := os.Stat(filepath.Join("..", "..", "cmd", m.Command, "main.go")); err != nil {
+			t.Fatalf("manifest %s: %v", m.Name, err)
+		}
+	}
+}
+
+// TestManifest_Validate_Reports_Problems checks the problems Validate
+// reports for a malformed manifest.
+func TestManifest_Validate_Reports_Problems(t *testing.T) {
+	m := Manifest{
+		Name:           "gofmt",
+		Command:        "Go",
+		ConfigKey:      "go-gofmt",
+		FileExtensions: []string{".go", "go", "go"},
+		FileNames:      []string{"dir/file"},
+	}
+	err := m.Validate()
+	if err == nil {
+		t.Fatal("Validate = nil; want an error")
+	}
+	want := []string{
+		`name "gofmt" must start with dprint-plugin-`,
+		`command "Go" must be lower case letters, digits and dashes`,
+		`file extension ".go" must be lower case without a leading dot`,
+		`file extension "go" is listed twice`,
+		`file name "dir/file" must be a plain file name`,
+	}
+	if got := strings.Split(err.Error(), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Validate =\n%v\nwant\n%s", err, strings.Join(want, "\n"))
+	}
+}
+
+// TestManifest_PluginInfo_Derives_URLs checks the plugin info derived from
+// a manifest.
+func TestManifest_PluginInfo_Derives_URLs(t *testing.T) {
+	info := TffmtManifest.PluginInfo("1.0.0\n")
+	if info.Version != "1.0.0" || info.Name != "dprint-plugin-gohcl" || info.ConfigKey != "go-hcl" {
+		t.Fatalf("PluginInfo = %+v", info)
+	}
+	if info.HelpURL != HelpURLPrefix+"tffmt" || info.ConfigSchemaURL != ReleaseURLPrefix+"tffmt.schema.json" {
+		t.Fatalf("PluginInfo URLs = %q, %q", info.HelpURL, info.ConfigSchemaURL)
+	}
+	if info.FileNames == nil {
+		t.Fatal("PluginInfo file names are nil; want an empty list")
+	}
+}
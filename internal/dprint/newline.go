@@ -0,0 +1,75 @@
+package dprint
+
+import (
+	"bytes"
+	"runtime"
+)
+
+// Newline kinds accepted by the newLineKind option. They match the values
+// dprint uses for its global configuration.
+const (
+	NewLineKindAuto   = "auto"
+	NewLineKindLF     = "lf"
+	NewLineKindCRLF   = "crlf"
+	NewLineKindSystem = "system"
+)
+
+// NormalizeNewlines rewrites every CRLF sequence in src to LF. The
+// underlying formatters treat a carriage return as content, so they only
+// ever see LF line endings.
+func NormalizeNewlines(src []byte) []byte {
+	if !bytes.Contains(src, []byte("\r\n")) {
+		return src
+	}
+	return bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+}
+
+// NormalizedOffset translates a byte offset into src to the matching offset
+// in NormalizeNewlines(src).
+func NormalizedOffset(src []byte, offset int) int {
+	offset = min(offset, len(src))
+	return offset - bytes.Count(src[:offset], []byte("\r\n"))
+}
+
+// ApplyNewlineKind rewrites the LF line endings in formatted according to
+// kind. The auto kind keeps the line ending used by the first line of
+// original, falling back to LF when original has no line breaks.
+func ApplyNewlineKind(formatted, original []byte, kind string) []byte {
+	if resolveNewlineKind(kind, original) != NewLineKindCRLF {
+		return formatted
+	}
+	return bytes.ReplaceAll(NormalizeNewlines(formatted), []byte("\n"), []byte("\r\n"))
+}
+
+// ApplyFinalNewline makes LF-only formatted text end in exactly one newline
+// when insert is true and in none when it is false. Empty text stays empty.
+func ApplyFinalNewline(formatted []byte, insert bool) []byte {
+	trimmed := bytes.TrimRight(formatted, "\n")
+	if !insert || len(trimmed) == 0 {
+		return trimmed
+	}
+	if len(trimmed)+1 == len(formatted) {
+		return formatted
+	}
+	return append(trimmed[:len(trimmed):len(trimmed)], '\n')
+}
+
+// resolveNewlineKind reduces kind to either lf or crlf.
+func resolveNewlineKind(kind string, original []byte) string {
+	switch kind {
+	case NewLineKindCRLF:
+		return NewLineKindCRLF
+	case NewLineKindAuto:
+		if i := bytes.IndexByte(original, '\n'); i > 0 && original[i-1] == '\r' {
+			return NewLineKindCRLF
+		}
+		return NewLineKindLF
+	case NewLineKindSystem:
+		if runtime.GOOS == "windows" {
+			return NewLineKindCRLF
+		}
+		return NewLineKindLF
+	default:
+		return NewLineKindLF
+	}
+}
@@ -0,0 +1,58 @@
+package dprint
+
+import "testing"
+
+// TestApplyNewlineKind_Converts_Line_Endings checks every newline kind
+// against LF-only formatter output.
+func TestApplyNewlineKind_Converts_Line_Endings(t *testing.T) {
+	tests := []struct {
+		kind     string
+		original string
+		want     string
+	}{
+		{NewLineKindLF, "a\r\nb\r\n", "a\nb\n"},
+		{NewLineKindCRLF, "a\nb\n", "a\r\nb\r\n"},
+		{NewLineKindAuto, "a\r\nb\n", "a\r\nb\r\n"},
+		{NewLineKindAuto, "a\nb\r\n", "a\nb\n"},
+		{NewLineKindAuto, "ab", "a\nb\n"},
+	}
+	for _, tt := range tests {
+		got := string(ApplyNewlineKind([]byte("a\nb\n"), []byte(tt.original), tt.kind))
+		if got != tt.want {
+			t.Fatalf("ApplyNewlineKind(%q, %q) = %q; want %q", tt.kind, tt.original, got, tt.want)
+		}
+	}
+}
+
+// TestNormalizeNewlines_Keeps_Lone_Carriage_Returns verifies that only
+// CRLF pairs are rewritten.
+func TestNormalizeNewlines_Keeps_Lone_Carriage_Returns(t *testing.T) {
+	got := string(NormalizeNewlines([]byte("a\r\nb\rc\n")))
+	if want := "a\nb\rc\n"; got != want {
+		t.Fatalf("NormalizeNewlines = %q; want %q", got, want)
+	}
+}
+
+// TestApplyFinalNewline_Inserts_And_Strips covers both settings of the
+// insertFinalNewline option.
+func TestApplyFinalNewline_Inserts_And_Strips(t *testing.T) {
+	tests := []struct {
+		input  string
+		insert bool
+		want   string
+	}{
+		{"a", true, "a\n"},
+		{"a\n", true, "a\n"},
+		{"a\n\n\n", true, "a\n"},
+		{"", true, ""},
+		{"\n", true, ""},
+		{"a\n\n", false, "a"},
+		{"a", false, "a"},
+	}
+	for _, tt := range tests {
+		got := string(ApplyFinalNewline([]byte(tt.input), tt.insert))
+		if got != tt.want {
+			t.Fatalf("ApplyFinalNewline(%q, %v) = %q; want %q", tt.input, tt.insert, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package dprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mridang/dprint-plugin-go/pkg/formatters"
+)
+
+// OverridesProperty is the name of the option holding per-file overrides.
+const OverridesProperty = "overrides"
+
+// Override is one entry of the overrides option: plugin options that apply
+// only to files matching one of the glob patterns in Files. In JSON the
+// patterns and the options share one object, as in
+// {"files": ["*.tfvars"], "indent": 4}.
+type Override struct {
+	Files   []string
+	Options map[string]json.RawMessage
+}
+
+// UnmarshalJSON splits the files property from the options.
+func (o *Override) UnmarshalJSON(data []byte) error {
+	var options map[string]json.RawMessage
+	if err := json.Unmarshal(data, &options); err != nil {
+		return err
+	}
+	var files []string
+	if raw, ok := options["files"]; ok {
+		if err := json.Unmarshal(raw, &files); err != nil {
+			return err
+		}
+		delete(options, "files")
+	}
+	*o = Override{Files: files, Options: options}
+	return nil
+}
+
+// MarshalJSON renders the override in the form it is configured in.
+func (o Override) MarshalJSON() ([]byte, error) {
+	object := make(map[string]any, len(o.Options)+1)
+	for key, value := range o.Options {
+		object[key] = value
+	}
+	object["files"] = o.Files
+	return json.Marshal(object)
+}
+
+// Matches reports whether path matches one of the override's patterns. A
+// pattern is matched against as many trailing path segments as it has, so
+// "*.tfvars" matches by file name and "env/*.tfvars" also by directory.
+func (o Override) Matches(filePath string) bool {
+	segments := pathSegments(filePath)
+	return slices.ContainsFunc(o.Files, func(pattern string) bool {
+		return matchTrailing(pattern, segments)
+	})
+}
+
+// pathSegments splits a file path into its slash-separated segments.
+func pathSegments(filePath string) []string {
+	return strings.Split(filepath.ToSlash(filePath), "/")
+}
+
+// matchTrailing reports whether pattern matches as many trailing segments
+// as it has.
+func matchTrailing(pattern string, segments []string) bool {
+	n := strings.Count(pattern, "/") + 1
+	if n > len(segments) {
+		return false
+	}
+	ok, _ := path.Match(pattern, strings.Join(segments[len(segments)-n:], "/"))
+	return ok
+}
+
+// Validate reports overrides without patterns and malformed patterns. The
+// index is used to name the offending property.
+func (o Override) Validate(index int) error {
+	property := fmt.Sprintf("%s[%d].files", OverridesProperty, index)
+	if len(o.Files) == 0 {
+		return &formatters.ConfigError{Property: property, Message: "must list at least one pattern"}
+	}
+	for _, pattern := range o.Files {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return &formatters.ConfigError{Property: property, Message: fmt.Sprintf("invalid pattern %q", pattern)}
+		}
+	}
+	return nil
+}
+
+// DecodeOverride decodes the options of the override at index onto targets,
+// which normally hold the configuration the override applies to. Only
+// diagnostics about properties the override sets are returned, named after
+// their position in the overrides list. Overrides cannot be nested.
+func DecodeOverride(index int, o Override, targets ...any) (diags, structural []ConfigDiagnostic) {
+	options := maps.Clone(o.Options)
+	prefix := fmt.Sprintf("%s[%d].", OverridesProperty, index)
+
+	var nested []ConfigDiagnostic
+	if _, ok := options[OverridesProperty]; ok {
+		delete(options, OverridesProperty)
+		nested = append(nested, ConfigDiagnostic{PropertyName: OverridesProperty, Message: "overrides cannot be nested"})
+	}
+
+	diags, structural = DecodeOptions(options, targets...)
+	diags = append(diags, nested...)
+	relevant := func(diag ConfigDiagnostic) bool {
+		_, set := o.Options[diag.PropertyName]
+		return !set
+	}
+	diags = slices.DeleteFunc(diags, relevant)
+	structural = slices.DeleteFunc(structural, relevant)
+	for i := range diags {
+		diags[i].PropertyName = prefix + diags[i].PropertyName
+	}
+	for i := range structural {
+		structural[i].PropertyName = prefix + structural[i].PropertyName
+	}
+	SortDiagnostics(diags)
+	return diags, structural
+}
@@ -0,0 +1,60 @@
+package dprint
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestOverride_Matches_Trailing_Segments checks patterns against file names
+// and against trailing directories.
+func TestOverride_Matches_Trailing_Segments(t *testing.T) {
+	o := Override{Files: []string{"*.tfvars", "env/*.tf"}}
+	tests := map[string]bool{
+		"/repo/prod.tfvars":      true,
+		"/repo/env/main.tf":      true,
+		"/repo/main.tf":          false,
+		"/repo/env/sub/main.tf":  false,
+		"prod.tfvars":            true,
+		"/repo/prod.tfvars.bak":  false,
+		"/repo/env/prod.tfvars":  true,
+		"/repo/other/prod.tfvar": false,
+	}
+	for path, want := range tests {
+		if got := o.Matches(path); got != want {
+			t.Fatalf("Matches(%q) = %v; want %v", path, got, want)
+		}
+	}
+}
+
+// TestDecodeOverride_Reports_Only_Its_Properties verifies that an override
+// is decoded onto the given targets and that diagnostics name the override
+// and leave out problems it did not introduce.
+func TestDecodeOverride_Reports_Only_Its_Properties(t *testing.T) {
+	var o Override
+	if err := json.Unmarshal([]byte(`{"files":["*.x"],"indent":-1,"useTabs":"no","overrides":[]}`), &o); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(o.Files, []string{"*.x"}) {
+		t.Fatalf("files = %v", o.Files)
+	}
+
+	cfg := sampleConfig{Dialect: "x"}
+	diags, structural := DecodeOverride(2, o, &cfg)
+	if cfg.Indent != -1 {
+		t.Fatalf("indent = %d; want -1", cfg.Indent)
+	}
+	wantDiags := []ConfigDiagnostic{
+		{PropertyName: "overrides[2].indent", Message: "is invalid"},
+		{PropertyName: "overrides[2].overrides", Message: "overrides cannot be nested"},
+	}
+	if !reflect.DeepEqual(diags, wantDiags) {
+		t.Fatalf("diagnostics = %+v; want %+v", diags, wantDiags)
+	}
+	wantStructural := []ConfigDiagnostic{
+		{PropertyName: "overrides[2].useTabs", Message: "expected boolean but got string"},
+	}
+	if !reflect.DeepEqual(structural, wantStructural) {
+		t.Fatalf("structural diagnostics = %+v; want %+v", structural, wantStructural)
+	}
+}
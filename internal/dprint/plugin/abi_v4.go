@@ -0,0 +1,235 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+)
+
+// This file is the adapter for dprint WASM plugin schema v4. Each export
+// translates the v4 calling convention onto the runtime in exports.go and
+// plugin.go; supporting another schema version means adding a file like
+// this one next to it rather than changing the plugins.
+
+// get_shared_bytes_ptr returns a pointer to the shared Wasm memory buffer.
+// This is called by the dprint CLI to access the shared buffer.
+// See: https://dprint.dev/plugins/wasm/#get_shared_bytes_ptr
+//
+//go:wasmexport get_shared_bytes_ptr
+//go:noinline
+//goland:noinspection GoUnusedFunction, GoSnakeCaseUsage
+func get_shared_bytes_ptr() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return state.pointer()
+}
+
+// clear_shared_bytes clears the shared byte array and returns a pointer to it.
+// The dprint CLI calls this to prepare the buffer for writing file content.
+// The buffer is reallocated when the host needs more room than it has.
+// See: https://dprint.dev/plugins/wasm/#clear_shared_bytes
+//
+//go:wasmexport clear_shared_bytes
+//go:noinline
+//goland:noinspection GoUnusedFunction, GoSnakeCaseUsage
+func clear_shared_bytes(size uint32) uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return state.clear(size)
+}
+
+// dprint_plugin_version_4 returns the schema version supported by this plugin.
+// The CLI checks for this export to determine plugin compatibility.
+// See: https://dprint.dev/plugins/wasm/#dprint_plugin_version_4
+//
+//go:wasmexport dprint_plugin_version_4
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func dprint_plugin_version_4() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return dprint.PluginSchemaVersion
+}
+
+// get_plugin_info serializes and returns the plugin information as JSON.
+// This includes the plugin name, version, configuration key, and supported
+// file extensions. See: https://dprint.dev/plugins/wasm/#get_plugin_info
+//
+//go:wasmexport get_plugin_info
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func get_plugin_info() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return putJSON(active.pluginInfo(), "{}")
+}
+
+// get_license_text returns the license text for this plugin.
+// The license is embedded at compile time by the plugin's main package.
+// See: https://dprint.dev/plugins/wasm/#get_license_text
+//
+//go:wasmexport get_license_text
+//go:noinline
+//goland:noinspection GoUnusedFunction, GoSnakeCaseUsage
+func get_license_text() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return putShared([]byte(active.licenseText()))
+}
+
+// get_config_file_matching returns the file matching configuration as JSON.
+// This tells dprint which files this plugin formats for the configuration,
+// which can replace the plugin's own lists.
+// See: https://dprint.dev/plugins/wasm/#get_config_file_matching
+//
+//go:wasmexport get_config_file_matching
+//go:noinline
+//goland:noinspection GoUnusedFunction, GoUnusedParameter, GoSnakeCaseUsage
+func get_config_file_matching(configID uint32) uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	_gE ^= 1
+	return putJSON(active.fileMatching(configID), dprint.SupportedFiles)
+}
+
+// register_config is called when the plugin and global configuration are complete.
+// The configuration is decoded straight out of the shared buffer; decoding
+// copies every value it keeps.
+// See: https://dprint.dev/plugins/wasm/#register_config
+//
+//go:wasmexport register_config
+//go:noinline
+//goland:noinspection GoUnusedFunction, GoUnusedParameter, GoSnakeCaseUsage
+func register_config(configID uint32) { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	_gA ^= 1
+	raw, _ := state.takeInput()
+	active.registerConfig(configID, raw)
+}
+
+// release_config releases the configuration from memory when no longer needed.
+// See: https://dprint.dev/plugins/wasm/#release_config
+//
+//go:wasmexport release_config
+//go:noinline
+//goland:noinspection GoUnusedFunction, GoUnusedParameter, GoSnakeCaseUsage
+func release_config(configID uint32) { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	_gB ^= 1
+	active.releaseConfig(configID)
+}
+
+// get_config_diagnostics returns configuration validation diagnostics as JSON.
+// This should return an array of diagnostic messages for invalid config.
+// See: https://dprint.dev/plugins/wasm/#get_config_diagnostics
+//
+//go:wasmexport get_config_diagnostics
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoUnusedParameter,GoSnakeCaseUsage
+func get_config_diagnostics(configID uint32) uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	_gC ^= 1
+	return putJSON(configDiagnostics(configID), "[]")
+}
+
+// get_resolved_config returns the resolved configuration as JSON for display
+// in the CLI. This shows the final configuration after all processing; it
+// is serialized once per registered configuration.
+// See: https://dprint.dev/plugins/wasm/#get_resolved_config
+//
+//go:wasmexport get_resolved_config
+//go:noinline
+//goland:noinspection GoUnusedFunction, GoUnusedParameter, GoSnakeCaseUsage
+func get_resolved_config(configID uint32) uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	_gD ^= 1
+	resolved, ok := active.resolvedConfig(configID)
+	if !ok {
+		return putShared([]byte("{}"))
+	}
+	return putShared(resolved)
+}
+
+// set_file_path is called by the CLI to set the file path in the shared buffer.
+// The path is kept and handed to the formatter on the next format call.
+// See: https://dprint.dev/plugins/wasm/#set_file_path
+//
+//go:wasmexport set_file_path
+//go:noinline
+//goland:noinspection GoUnusedFunction, GoSnakeCaseUsage
+func set_file_path() { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	_gF ^= 1
+	path, _ := state.takeInput()
+	state.filePath = string(path)
+}
+
+// set_override_config is called by the CLI to set override configuration,
+// a JSON object of plugin options such as {"language": "posix"} that
+// applies to the next format call only. The options are copied out of the
+// shared buffer, which the file text overwrites.
+// See: https://dprint.dev/plugins/wasm/#set_override_config
+//
+//go:wasmexport set_override_config
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func set_override_config() { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	_gG ^= 1
+	raw, _ := state.takeInput()
+	state.overrideConfig = bytes.Clone(raw)
+}
+
+// format performs the actual code formatting using the registered plugin.
+// Returns formatResultNoChange (0) for no changes, formatResultChanged (1)
+// for successful formatting, or formatResultError (2) for errors. The host
+// is polled for cancellation before and after formatting and by formatters
+// between their passes; a cancelled request reports no change.
+// See: https://dprint.dev/plugins/wasm/#format
+//
+//go:wasmexport format
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoUnusedParameter,GoSnakeCaseUsage
+func format(configID uint32) uint32 { //nolint:unused // because it is exported
+	ensureInit()
+	path, override := state.takeFilePath(), state.takeOverrideConfig()
+	return formatShared(func(ctx context.Context, input []byte) ([]byte, error) {
+		return active.format(ctx, configID, path, override, input)
+	})
+}
+
+// format_range formats the part of the file around the byte range
+// [rangeStart, rangeEnd). Plugins without range support, or ranges they
+// cannot honour, format the whole file. Results are reported like format.
+//
+//go:wasmexport format_range
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func format_range(configID, rangeStart, rangeEnd uint32) uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	path, override := state.takeFilePath(), state.takeOverrideConfig()
+	return formatShared(func(ctx context.Context, input []byte) ([]byte, error) {
+		return active.formatRange(ctx, configID, path, override, input, int(rangeStart), int(rangeEnd))
+	})
+}
+
+// get_formatted_text returns the size of the formatted text in the shared
+// buffer. Called after format() returns formatResultChanged; at any other
+// time it returns zero.
+// See: https://dprint.dev/plugins/wasm/#get_formatted_text
+//
+//go:wasmexport get_formatted_text
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func get_formatted_text() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return state.result(phaseFormatted)
+}
+
+// get_error_text returns the size of the error text in the shared buffer.
+// Called after format() returns formatResultError; at any other time it
+// returns zero.
+// See: https://dprint.dev/plugins/wasm/#get_error_text
+//
+//go:wasmexport get_error_text
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func get_error_text() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return state.result(phaseError)
+}
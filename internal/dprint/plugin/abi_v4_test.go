@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// TestABIv4_Exports_Remain_Intact parses the v4 adapter and checks that
+// every export the dprint CLI calls is still there with its signature, so
+// that restructuring the runtime for a newer schema cannot drop one.
+func TestABIv4_Exports_Remain_Intact(t *testing.T) {
+	want := map[string]string{
+		"get_shared_bytes_ptr":     "() uint32",
+		"clear_shared_bytes":       "(uint32) uint32",
+		"dprint_plugin_version_4":  "() uint32",
+		"get_plugin_info":          "() uint32",
+		"get_license_text":         "() uint32",
+		"get_config_file_matching": "(uint32) uint32",
+		"register_config":          "(uint32)",
+		"release_config":           "(uint32)",
+		"get_config_diagnostics":   "(uint32) uint32",
+		"get_resolved_config":      "(uint32) uint32",
+		"set_file_path":            "()",
+		"set_override_config":      "()",
+		"format":                   "(uint32) uint32",
+		"format_range":             "(uint32, uint32, uint32) uint32",
+		"get_formatted_text":       "() uint32",
+		"get_error_text":           "() uint32",
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "abi_v4.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse abi_v4.go: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Doc == nil {
+			continue
+		}
+		for _, c := range fn.Doc.List {
+			if name, ok := strings.CutPrefix(c.Text, "//go:wasmexport "); ok {
+				got[name] = signature(fn.Type)
+			}
+		}
+	}
+
+	for name, sig := range want {
+		if got[name] != sig {
+			t.Errorf("export %s has signature %q; want %q", name, got[name], sig)
+		}
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			t.Errorf("unexpected v4 export %s", name)
+		}
+	}
+}
+
+// signature renders the parameter and result types of a function.
+func signature(fn *ast.FuncType) string {
+	var params []string
+	for _, field := range fn.Params.List {
+		for range max(len(field.Names), 1) {
+			params = append(params, typeName(field.Type))
+		}
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+	if fn.Results != nil {
+		for _, field := range fn.Results.List {
+			sig += " " + typeName(field.Type)
+		}
+	}
+	return sig
+}
+
+// typeName renders a parameter type, which is always an identifier here.
+func typeName(expr ast.Expr) string {
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return "?"
+}
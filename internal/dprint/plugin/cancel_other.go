@@ -0,0 +1,9 @@
+//go:build !tinygo
+
+package plugin
+
+// hostHasCancelled reports whether the host cancelled the current request.
+// Outside of a WASM host nothing can cancel a request.
+func hostHasCancelled() bool {
+	return false
+}
@@ -0,0 +1,8 @@
+//go:build tinygo
+
+package plugin
+
+// hostHasCancelled reports whether the host cancelled the current request.
+func hostHasCancelled() bool {
+	return host_has_cancelled() == 1
+}
@@ -0,0 +1,209 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+)
+
+// putShared places data in the shared buffer as the response of the
+// current call and returns its size.
+func putShared(b []byte) uint32 {
+	ensureInit()
+	return state.respond(b, phaseResponse)
+}
+
+// putJSON serializes v as canonical JSON into the shared buffer, falling
+// back to the given literal if serialization fails.
+func putJSON(v any, fallback string) uint32 {
+	data, err := dprint.MarshalCanonical(v)
+	if err != nil {
+		return putShared([]byte(fallback))
+	}
+	return putShared(data)
+}
+
+// get_config_schema returns the JSON Schema of the plugin's configuration
+// block. It is not part of the dprint ABI; it lets tooling read the schema
+// published at ConfigSchemaURL straight from the plugin.
+//
+//go:wasmexport get_config_schema
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func get_config_schema() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return putShared(active.configSchema())
+}
+
+// get_capabilities returns the optional features the plugin supports as
+// JSON. It is not part of the dprint ABI.
+//
+//go:wasmexport get_capabilities
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func get_capabilities() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return putJSON(active.capabilities(), "{}")
+}
+
+// get_debug_log returns the trace messages recorded since the last call,
+// one per line, up to the latest maxDebugLog. Messages other than warnings
+// are only recorded for configurations that set the shared debug option.
+// It is not part of the dprint ABI.
+//
+//go:wasmexport get_debug_log
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func get_debug_log() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return putShared([]byte(strings.Join(active.drainDebugLog(), "\n")))
+}
+
+// get_format_stats returns the counts of changed, unchanged, failed and
+// cancelled files, the bytes received and the time spent formatting since
+// the plugin was loaded, as JSON. It is not part of the dprint ABI.
+//
+//go:wasmexport get_format_stats
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func get_format_stats() uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	return putJSON(active.formatStats(), "{}")
+}
+
+// format_diff is like format but leaves a unified diff of the changes
+// behind for get_formatted_text instead of the formatted text, for tooling
+// that annotates pull requests with what would change. It is not part of
+// the dprint ABI, so dprint fmt never writes a diff into a file.
+//
+//go:wasmexport format_diff
+//go:noinline
+//goland:noinspection GoUnusedFunction,GoSnakeCaseUsage
+func format_diff(configID uint32) uint32 { //nolint:revive,unused,staticcheck // because it is exported
+	ensureInit()
+	path, override := state.takeFilePath(), state.takeOverrideConfig()
+	return formatShared(func(ctx context.Context, input []byte) ([]byte, error) {
+		formatted, err := active.format(ctx, configID, path, override, input)
+		if err != nil || bytes.Equal(formatted, input) {
+			return formatted, err
+		}
+		return dprint.UnifiedDiff(path, input, formatted), nil
+	})
+}
+
+// configDiagnostics returns the sorted diagnostics of a configuration,
+// reporting configurations the host never registered.
+func configDiagnostics(configID uint32) []dprint.ConfigDiagnostic {
+	diags := []dprint.ConfigDiagnostic{}
+	if !active.registered(configID) {
+		err := dprint.UnregisteredConfigError("get_config_diagnostics", configID)
+		diags = append(diags, dprint.ConfigDiagnostic{PropertyName: "", Message: err.Error()})
+	} else {
+		diags = append(diags, active.diagnostics(configID)...)
+	}
+	dprint.SortDiagnostics(diags)
+	return diags
+}
+
+// formatShared runs fn on the file the host wrote to the shared buffer and
+// leaves the formatted text or the error message behind for the host to
+// read. The formatter reads the file straight out of the shared buffer,
+// which is only replaced once formatting is done. A format call without a
+// freshly written file is reported as an error instead of formatting
+// whatever the buffer held before.
+func formatShared(fn formatFunc) uint32 {
+	input, ok := state.takeInput()
+	if !ok {
+		return writeError(&dprint.SchemaMismatchError{Detail: "host called format without writing the file first"})
+	}
+	if len(input) == 0 {
+		return dprint.FormatResultNoChange
+	}
+
+	formatted, changed, err := runFormat(dprint.PollingContext(state.hasCancelled), input, fn)
+	if err != nil {
+		return writeError(err)
+	}
+	if !changed {
+		return dprint.FormatResultNoChange
+	}
+
+	state.respond(formatted, phaseFormatted)
+	return dprint.FormatResultChanged
+}
+
+// formatFunc formats one file for a host request.
+type formatFunc func(ctx context.Context, input []byte) ([]byte, error)
+
+// runFormat runs fn on input and reports whether the file changed. A
+// request that is cancelled before or while it is formatted reports no
+// change. Every request is counted in the plugin's format stats.
+func runFormat(ctx context.Context, input []byte, fn formatFunc) ([]byte, bool, error) {
+	start := time.Now()
+	formatted, changed, err := checkFormat(ctx, input, fn)
+	active.recordFormat(len(input), changed, ctx.Err() != nil, err, time.Since(start))
+	return formatted, changed, err
+}
+
+// checkFormat runs fn on input unless the request is cancelled and
+// compares the result with the input.
+func checkFormat(ctx context.Context, input []byte, fn formatFunc) ([]byte, bool, error) {
+	if ctx.Err() != nil {
+		return nil, false, nil
+	}
+	formatted, err := recoverFormat(ctx, input, fn)
+	if ctx.Err() != nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// unchanged fast path
+	if len(formatted) == len(input) && bytes.Equal(formatted, input) {
+		return nil, false, nil
+	}
+	return formatted, true, nil
+}
+
+// recoverFormat calls fn and turns a panic inside the formatter libraries
+// into an error, so that one bad file is reported as a formatting error
+// instead of trapping the instance and failing the whole dprint run.
+func recoverFormat(ctx context.Context, input []byte, fn formatFunc) (formatted []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			formatted, err = nil, fmt.Errorf("formatter panicked: %v", r)
+		}
+	}()
+	return fn(ctx, input)
+}
+
+// Dummy globals to prevent Identical Code Folding optimization from
+// merging these placeholder functions.
+var (
+	_gA uint8 //nolint:unused, gochecknoglobals, var-naming // CGO global variable
+	_gB uint8 //nolint:unused, gochecknoglobals, var-naming // CGO global variable
+	_gC uint8 //nolint:unused, gochecknoglobals, var-naming // CGO global variable
+	_gD uint8 //nolint:unused, gochecknoglobals, var-naming // CGO global variable
+	_gE uint8 //nolint:unused, gochecknoglobals, var-naming // CGO global variable
+	_gF uint8 //nolint:unused, gochecknoglobals, var-naming // CGO global variable
+	_gG uint8 //nolint:unused, gochecknoglobals, var-naming // CGO global variable
+)
+
+// writeError places the error message in the shared buffer and returns the
+// error result code expected by the host.
+func writeError(err error) uint32 {
+	state.respond([]byte(err.Error()), phaseError)
+	return dprint.FormatResultError
+}
+
+// toUint32 converts an int to uint32, suppressing the G115 overflow warning.
+func toUint32(val int) uint32 {
+	// This cast from int (64-bit) to uint32 (32-bit) could
+	// overflow, so we suppress the gosec linter.
+	return uint32(val) //nolint:gosec // since dprint really needs uint32
+}
@@ -0,0 +1,51 @@
+//go:build !tinygo
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mridang/dprint-plugin-go/pkg/formatters"
+)
+
+// errHostFormatUnsupported is returned by hostFormat in process plugins.
+var errHostFormatUnsupported = errors.New("process plugins cannot have the host format embedded code")
+
+// hostFormat reports that the host cannot format code for process plugins,
+// which would have to ask dprint for it over the process protocol while a
+// format request is being served.
+func hostFormat(string, []byte) ([]byte, error) {
+	return nil, errHostFormatUnsupported
+}
+
+// withPackageFiles lets the formatters read the other Go files in the
+// directory of the file being formatted, as goimports does.
+func withPackageFiles(ctx context.Context) context.Context {
+	return formatters.WithPackageFiles(ctx, packageFiles)
+}
+
+// packageFiles returns the contents of the .go files in the directory of
+// the file at path, by name.
+func packageFiles(path string) (map[string][]byte, error) {
+	dir := filepath.Dir(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || entry.Name() == filepath.Base(path) {
+			continue
+		}
+		src, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = src
+	}
+	return files, nil
+}
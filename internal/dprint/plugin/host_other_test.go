@@ -0,0 +1,41 @@
+//go:build !tinygo
+
+package plugin
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestPackageFiles_Reads_Go_Files_Next_To_The_File verifies that
+// packageFiles returns the other .go files in the directory of a file,
+// leaving out the file itself, other files and directories.
+func TestPackageFiles_Reads_Go_Files_Next_To_The_File(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"main.go":   "package main\n",
+		"log.go":    "package main\n\nvar log = 1\n",
+		"README.md": "# main\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub.go"), 0o700); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	files, err := packageFiles(filepath.Join(dir, "main.go"))
+	if err != nil {
+		t.Fatalf("packageFiles: %v", err)
+	}
+	if got := slices.Sorted(maps.Keys(files)); !slices.Equal(got, []string{"log.go"}) {
+		t.Fatalf("packageFiles = %q; want [log.go]", got)
+	}
+	if got, want := string(files["log.go"]), "package main\n\nvar log = 1\n"; got != want {
+		t.Fatalf("packageFiles[log.go] = %q; want %q", got, want)
+	}
+}
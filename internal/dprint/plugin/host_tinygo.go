@@ -0,0 +1,52 @@
+//go:build tinygo
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"unsafe"
+
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+)
+
+// hostFormat asks the host to format src as the file at path, with the
+// plugin it formats such files with, and returns the result.
+func hostFormat(path string, src []byte) ([]byte, error) {
+	name := []byte(path)
+	switch host_format(bytesPointer(name), toUint32(len(name)), 0, toUint32(len(src)), 0, 0,
+		bytesPointer(src), toUint32(len(src))) {
+	case dprint.FormatResultNoChange:
+		return src, nil
+	case dprint.FormatResultChanged:
+		return hostText(host_get_formatted_text()), nil
+	default:
+		return nil, errors.New(string(hostText(host_get_error_text())))
+	}
+}
+
+// withPackageFiles returns ctx as it is, since a Wasm plugin cannot read
+// the other files of a Go package.
+func withPackageFiles(ctx context.Context) context.Context {
+	return ctx
+}
+
+// hostText has the host write the size bytes of text it holds, the result
+// of host_get_formatted_text or host_get_error_text, to a new slice. The
+// shared buffer is left alone, since it may hold the file being formatted.
+func hostText(size uint32) []byte {
+	text := make([]byte, size)
+	if size > 0 {
+		host_write_buffer(bytesPointer(text))
+	}
+	return text
+}
+
+// bytesPointer returns the address of the first byte of b, or zero when b
+// is empty.
+func bytesPointer(b []byte) uint32 {
+	if len(b) == 0 {
+		return 0
+	}
+	return uint32(uintptr(unsafe.Pointer(&b[0])))
+}
@@ -0,0 +1,126 @@
+package plugin
+
+import (
+	"unsafe"
+
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+)
+
+// phase records what the shared buffer holds between two ABI calls. Every
+// export that reads the buffer checks the phase, so a host that calls the
+// exports in an unexpected order reads nothing instead of a stale size or
+// the data of another call.
+type phase int
+
+const (
+	// phaseEmpty means the buffer holds nothing anyone should read.
+	phaseEmpty phase = iota
+	// phaseInput means the host wrote data for the next call to consume.
+	phaseInput
+	// phaseResponse means the buffer holds the response of an export.
+	phaseResponse
+	// phaseFormatted means the buffer holds the text of a changed file.
+	phaseFormatted
+	// phaseError means the buffer holds the message of a failed format.
+	phaseError
+)
+
+// instance is the mutable state of a plugin instance: the buffer shared
+// with the host, what it currently holds and the file path and override
+// configuration for the next format call.
+type instance struct {
+	buffer         []byte
+	size           uint32
+	phase          phase
+	filePath       string
+	overrideConfig []byte
+	hasCancelled   func() bool
+}
+
+// state is the instance served by the exports. A WASM module is one
+// instance, so there is exactly one.
+var state = newInstance() //nolint:gochecknoglobals // CGO global variable
+
+// newInstance returns an instance with an empty buffer of the initial size.
+func newInstance() *instance {
+	return &instance{
+		buffer:       make([]byte, dprint.SharedBufferSize),
+		phase:        phaseEmpty,
+		hasCancelled: hostHasCancelled,
+	}
+}
+
+// ensureInit initializes the plugin if not already initialized.
+// This must be called before any other plugin operations.
+func ensureInit() {
+	_ = uintptr(unsafe.Pointer(&state.buffer[0]))
+}
+
+// pointer returns the address of the shared buffer.
+func (s *instance) pointer() uint32 {
+	return uint32(uintptr(unsafe.Pointer(&s.buffer[0])))
+}
+
+// clear prepares the buffer for the host to write size bytes, growing it if
+// needed. A larger buffer is a new allocation, so the host must fetch the
+// pointer again, which it does before every read and write.
+func (s *instance) clear(size uint32) uint32 {
+	if int(size) > len(s.buffer) {
+		s.buffer = make([]byte, size)
+	}
+	s.size = size
+	s.phase = phaseInput
+	return s.pointer()
+}
+
+// takeInput returns the data the host wrote for this call and marks it
+// consumed. It reports false if the host wrote nothing since the last call
+// that consumed or replaced the buffer. The input's capacity is capped so
+// that appending to it allocates instead of overwriting the buffer.
+func (s *instance) takeInput() ([]byte, bool) {
+	if s.phase != phaseInput {
+		return nil, false
+	}
+	s.phase = phaseEmpty
+	return s.buffer[:s.size:s.size], true
+}
+
+// respond places b in the buffer as the result of the current call and
+// returns its size. Data that already starts at the front of the buffer is
+// left where it is.
+func (s *instance) respond(b []byte, p phase) uint32 {
+	if len(b) == 0 || &b[0] != &s.buffer[0] {
+		if len(b) > len(s.buffer) {
+			s.buffer = make([]byte, len(b))
+		}
+		copy(s.buffer, b)
+	}
+	s.size = toUint32(len(b))
+	s.phase = p
+	return s.size
+}
+
+// result returns the size of the buffer's contents if it holds a result of
+// the given phase, and zero otherwise.
+func (s *instance) result(p phase) uint32 {
+	if s.phase != p {
+		return 0
+	}
+	return s.size
+}
+
+// takeFilePath returns the path set for the next format call and forgets
+// it, so that a later call without set_file_path does not reuse it.
+func (s *instance) takeFilePath() string {
+	path := s.filePath
+	s.filePath = ""
+	return path
+}
+
+// takeOverrideConfig returns the override configuration set for the next
+// format call and forgets it, like takeFilePath.
+func (s *instance) takeOverrideConfig() []byte {
+	override := s.overrideConfig
+	s.overrideConfig = nil
+	return override
+}
@@ -0,0 +1,68 @@
+//go:build !tinygo
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+)
+
+// Main is the body of a plugin's main function. Native builds of a plugin
+// run as dprint process plugins: dprint starts them with --parent-pid and
+// talks to them over stdin and stdout, and they exit once that process is
+// gone. Run with the schema argument they
+// print the configuration schema, which the release build publishes, with
+// the capabilities argument the optional features they support and with
+// the version argument the plugin version.
+func Main() {
+	ensureInit()
+	switch {
+	case len(os.Args) == 2 && os.Args[1] == "schema":
+		fmt.Println(string(active.configSchema()))
+	case len(os.Args) == 2 && os.Args[1] == "capabilities":
+		printJSON(active.capabilities())
+	case len(os.Args) == 2 && os.Args[1] == "version":
+		fmt.Println(Info().Version)
+	case slices.Contains(os.Args[1:], "--parent-pid"):
+		pid, err := parentPID(os.Args[1:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if err = Serve(os.Stdin, os.Stdout, os.Stderr, pid); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "usage: %s schema | capabilities | version | --parent-pid <pid>\n", os.Args[0])
+		os.Exit(2)
+	}
+}
+
+// parentPID returns the process ID that follows --parent-pid in args.
+func parentPID(args []string) (int, error) {
+	i := slices.Index(args, "--parent-pid")
+	if i < 0 || i+1 >= len(args) {
+		return 0, errors.New("--parent-pid needs the process ID of dprint")
+	}
+	pid, err := strconv.Atoi(args[i+1])
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("--parent-pid %q is not a process ID", args[i+1])
+	}
+	return pid, nil
+}
+
+// printJSON writes v to stdout as canonical JSON.
+func printJSON(v any) {
+	data, err := dprint.MarshalCanonical(v)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,8 @@
+//go:build tinygo
+
+package plugin
+
+// Main is the body of a plugin's main function.
+func Main() {
+	ensureInit()
+}
@@ -0,0 +1,16 @@
+//go:build !tinygo && !windows
+
+package plugin
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning reports whether the process pid is running, by sending it
+// the null signal. A process that exists but belongs to another user
+// refuses the signal and is running all the same.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,23 @@
+//go:build !tinygo && windows
+
+package plugin
+
+import "syscall"
+
+// stillActive is the exit code Windows reports for a running process.
+const stillActive = 259
+
+// processRunning reports whether the process pid is running, by asking
+// Windows for its exit code.
+func processRunning(pid int) bool {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid)) //nolint:gosec // process IDs fit in a DWORD
+	if err != nil {
+		return false
+	}
+	defer func() { _ = syscall.CloseHandle(handle) }()
+	var code uint32
+	if err = syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
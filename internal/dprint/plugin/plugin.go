@@ -0,0 +1,436 @@
+// Package plugin implements the dprint WASM ABI once for every formatter in
+// this repository. A plugin binary only describes itself through a
+// Definition and calls Register; the exported ABI functions live here.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+	"github.com/mridang/dprint-plugin-go/pkg/formatters"
+)
+
+// Definition describes a formatter plugin served by the runtime.
+type Definition[C any] struct {
+	// Manifest names the plugin and the files it formats. It drives
+	// get_plugin_info and get_config_file_matching.
+	Manifest dprint.Manifest
+
+	// Version is the plugin version reported by get_plugin_info. Surrounding
+	// whitespace is ignored.
+	Version string
+
+	// License is the license text returned from get_license_text.
+	License string
+
+	// DefaultConfig returns the configuration used before the host
+	// registers one and as the base that registered JSON is decoded onto.
+	DefaultConfig func() C
+
+	// Inherit, if set, adjusts the default configuration to dprint's global
+	// configuration before the plugin's own options are decoded onto it, so
+	// that options the user sets for the plugin win over global ones.
+	Inherit func(cfg C, global dprint.GlobalConfig) C
+
+	// Formatter formats each file with the resolved configuration.
+	Formatter dprint.Formatter[C]
+
+	// RangeFormatter, if set, serves format_range requests. Without it, or
+	// when it cannot honour a range, the whole file is formatted.
+	RangeFormatter dprint.RangeFormatter[C]
+
+	// LineComments lists the prefixes that start a line comment in the
+	// files this plugin formats. Files whose leading comments contain a
+	// dprint-ignore-file comment are left untouched, and lines between
+	// dprint-ignore-start and dprint-ignore-end comments are kept as written.
+	LineComments []string
+}
+
+// handler is the type-erased view of a Definition used by the exports.
+// Configurations are keyed by the config id the host passes to
+// register_config; every other config-aware export receives the same id.
+type handler interface {
+	pluginInfo() dprint.PluginInfo
+	fileMatching(configID uint32) dprint.FileMatchingInfo
+	licenseText() string
+	registerConfig(configID uint32, raw []byte)
+	releaseConfig(configID uint32)
+	registered(configID uint32) bool
+	diagnostics(configID uint32) []dprint.ConfigDiagnostic
+	resolvedConfig(configID uint32) ([]byte, bool)
+	format(ctx context.Context, configID uint32, path string, override, src []byte) ([]byte, error)
+	formatRange(ctx context.Context, configID uint32, path string, override, src []byte, start, end int) ([]byte, error)
+	configSchema() []byte
+	capabilities() dprint.Capabilities
+	drainDebugLog() []string
+	recordFormat(size int, changed, cancelled bool, err error, elapsed time.Duration)
+	formatStats() dprint.Stats
+}
+
+// active is the plugin registered by the binary's main package.
+var active handler //nolint:gochecknoglobals // CGO global variable
+
+// Register installs def as the plugin served by the exported ABI functions.
+// It returns true so that it can be called from a package-level variable
+// initializer, which runs before the host invokes any export. An invalid
+// manifest panics, failing every test and every start of the binary. The
+// configuration schema is built once here and embedded in the handler.
+func Register[C any](def Definition[C]) bool {
+	if err := def.Manifest.Validate(); err != nil {
+		panic(fmt.Sprintf("invalid manifest for plugin %q: %v", def.Manifest.Name, err))
+	}
+	info := def.Manifest.PluginInfo(def.Version)
+	shared := dprint.DefaultSharedConfig(dprint.GlobalConfig{})
+	schema, err := dprint.MarshalCanonical(dprint.ConfigSchema(info.Name, def.DefaultConfig(), shared))
+	if err != nil {
+		panic(fmt.Sprintf("invalid config schema for plugin %q: %v", def.Manifest.Name, err))
+	}
+	active = &definedHandler[C]{
+		def:     def,
+		info:    info,
+		schema:  schema,
+		configs: map[uint32]*registration[C]{},
+	}
+	return true
+}
+
+// Info returns the plugin info of the registered plugin, as reported to
+// the host.
+func Info() dprint.PluginInfo {
+	return active.pluginInfo()
+}
+
+// registration is a registered configuration, the shared options decoded
+// next to it and the diagnostics that were produced while decoding both.
+// Both hold the effective settings, including those inherited from the
+// global configuration, and are what get_resolved_config reports.
+type registration[C any] struct {
+	config C
+	shared dprint.SharedConfig
+	diags  []dprint.ConfigDiagnostic
+
+	// resolved caches the serialized effective configuration. Registering
+	// the config id again replaces the registration and with it the cache.
+	resolved []byte
+}
+
+// resolve returns the configuration and shared options for path, with the
+// options of every matching override applied in order and then the
+// override configuration the host set for the request, if any, so that a
+// plugin delegating to this one through host_format has the last word.
+// Problems in the override configuration are returned as diagnostics; the
+// options that decode are applied all the same.
+func (r *registration[C]) resolve(path string, override []byte) (C, dprint.SharedConfig, []dprint.ConfigDiagnostic) {
+	cfg, shared := r.config, r.shared
+	for i, o := range r.shared.Overrides {
+		if o.Matches(path) {
+			dprint.DecodeOverride(i, o, &cfg, &shared)
+		}
+	}
+	if len(override) == 0 {
+		return cfg, shared, nil
+	}
+	diags, structural := dprint.DecodeConfig(override, &cfg, &shared)
+	return cfg, shared, append(diags, structural...)
+}
+
+// definedHandler adapts a Definition to the handler interface.
+type definedHandler[C any] struct {
+	def      Definition[C]
+	info     dprint.PluginInfo
+	schema   []byte
+	configs  map[uint32]*registration[C]
+	debugLog []string
+	stats    dprint.Stats
+}
+
+func (h *definedHandler[C]) pluginInfo() dprint.PluginInfo {
+	return h.info
+}
+
+// fileMatching returns the files the plugin formats for a configuration,
+// falling back to the manifest's lists for unknown ids.
+func (h *definedHandler[C]) fileMatching(configID uint32) dprint.FileMatchingInfo {
+	var shared dprint.SharedConfig
+	if rc, ok := h.configs[configID]; ok {
+		shared = rc.shared
+	}
+	return h.def.Manifest.FileMatching(shared)
+}
+
+func (h *definedHandler[C]) licenseText() string {
+	return h.def.License
+}
+
+func (h *definedHandler[C]) configSchema() []byte {
+	return h.schema
+}
+
+// capabilities reports the optional features the definition enables. The
+// runtime always polls for cancellation, honours set_override_config and
+// exports format_diff.
+func (h *definedHandler[C]) capabilities() dprint.Capabilities {
+	return dprint.Capabilities{
+		RangeFormatting: h.def.RangeFormatter != nil,
+		Cancellation:    true,
+		OverrideConfig:  true,
+		IgnoreComments:  len(h.def.LineComments) > 0,
+		FormatDiff:      true,
+	}
+}
+
+func (h *definedHandler[C]) registerConfig(configID uint32, raw []byte) {
+	global := dprint.DecodeGlobalConfig(raw)
+	cfg := h.def.DefaultConfig()
+	if h.def.Inherit != nil {
+		cfg = h.def.Inherit(cfg, global)
+	}
+	shared := dprint.DefaultSharedConfig(global)
+	diags, structural := dprint.DecodeConfig(raw, &cfg, &shared)
+	schemaDiags, schemaStructural := dprint.ValidateSchema(h.schema, raw)
+	diags = dprint.MergeDiagnostics(diags, schemaDiags)
+	structural = dprint.MergeDiagnostics(schemaStructural, structural)
+	for i, o := range shared.Overrides {
+		c, s := cfg, shared
+		d, st := dprint.DecodeOverride(i, o, &c, &s)
+		diags, structural = append(diags, d...), append(structural, st...)
+	}
+	if shared.Strict {
+		diags = append(diags, structural...)
+	}
+	dprint.SortDiagnostics(diags)
+	h.configs[configID] = &registration[C]{config: cfg, shared: shared, diags: diags}
+	if shared.Debug {
+		resolved, _ := h.resolvedConfig(configID)
+		h.debugf("config %d resolved to %s with %d diagnostics", configID, resolved, len(diags))
+	}
+}
+
+func (h *definedHandler[C]) releaseConfig(configID uint32) {
+	delete(h.configs, configID)
+}
+
+func (h *definedHandler[C]) registered(configID uint32) bool {
+	_, ok := h.configs[configID]
+	return ok
+}
+
+func (h *definedHandler[C]) diagnostics(configID uint32) []dprint.ConfigDiagnostic {
+	if rc, ok := h.configs[configID]; ok {
+		return rc.diags
+	}
+	return nil
+}
+
+// resolvedConfig returns the effective configuration of a config id as
+// canonical JSON, serializing it only on the first request.
+func (h *definedHandler[C]) resolvedConfig(configID uint32) ([]byte, bool) {
+	rc, ok := h.configs[configID]
+	if !ok {
+		return nil, false
+	}
+	if rc.resolved == nil {
+		rc.resolved = rc.serialize()
+	}
+	return rc.resolved, true
+}
+
+// serialize renders the configuration merged with the shared options.
+func (r *registration[C]) serialize() []byte {
+	var resolved any = r.config
+	if merged, err := dprint.MergeObjects(r.config, r.shared); err == nil {
+		resolved = merged
+	}
+	data, err := dprint.MarshalCanonical(resolved)
+	if err != nil {
+		return []byte("{}")
+	}
+	return data
+}
+
+func (h *definedHandler[C]) format(
+	ctx context.Context,
+	configID uint32,
+	path string,
+	override, src []byte,
+) ([]byte, error) {
+	rc, ok := h.configs[configID]
+	if !ok {
+		return nil, dprint.UnregisteredConfigError("format", configID)
+	}
+	if h.def.Manifest.Passthrough {
+		return src, nil
+	}
+	if h.ignored(src) {
+		h.debugIf(rc, "%s: skipped by %s", path, dprint.IgnoreFileDirective)
+		return src, nil
+	}
+	ctx = h.traceContext(ctx, rc, path)
+	cfg, shared := h.resolve(rc, path, override)
+	if shared.Excludes(path) {
+		h.debugIf(rc, "%s: skipped by exclude", path)
+		return src, nil
+	}
+	if skip, err := h.oversized(rc, shared, path, src); skip || err != nil {
+		return src, err
+	}
+	formatted, err := h.timed(rc, path, func() ([]byte, error) {
+		return shared.Apply(src, func(src []byte) ([]byte, error) {
+			formatted, err := h.def.Formatter.Format(ctx, path, src, cfg)
+			return h.restoreIgnored(src, formatted, err)
+		})
+	})
+	return formatted, err
+}
+
+func (h *definedHandler[C]) formatRange(
+	ctx context.Context,
+	configID uint32,
+	path string,
+	override, src []byte,
+	start, end int,
+) ([]byte, error) {
+	rc, ok := h.configs[configID]
+	if !ok {
+		return nil, dprint.UnregisteredConfigError("format_range", configID)
+	}
+	if h.def.Manifest.Passthrough {
+		return src, nil
+	}
+	if h.ignored(src) {
+		h.debugIf(rc, "%s: skipped by %s", path, dprint.IgnoreFileDirective)
+		return src, nil
+	}
+	ctx = h.traceContext(ctx, rc, path)
+	h.debugIf(rc, "%s: formatting range %d-%d", path, start, end)
+	cfg, shared := h.resolve(rc, path, override)
+	if shared.Excludes(path) {
+		h.debugIf(rc, "%s: skipped by exclude", path)
+		return src, nil
+	}
+	if skip, err := h.oversized(rc, shared, path, src); skip || err != nil {
+		return src, err
+	}
+	formatted, err := h.timed(rc, path, func() ([]byte, error) {
+		return shared.Apply(src, func(normalized []byte) ([]byte, error) {
+			if h.def.RangeFormatter != nil {
+				start, end := dprint.NormalizedOffset(src, start), dprint.NormalizedOffset(src, end)
+				formatted, err := h.def.RangeFormatter.FormatRange(ctx, path, normalized, start, end, cfg)
+				if !errors.Is(err, formatters.ErrRangeUnsupported) {
+					return h.restoreIgnored(normalized, formatted, err)
+				}
+				h.debugIf(rc, "%s: range cannot be formatted on its own, formatting the whole file", path)
+			}
+			formatted, err := h.def.Formatter.Format(ctx, path, normalized, cfg)
+			return h.restoreIgnored(normalized, formatted, err)
+		})
+	})
+	return formatted, err
+}
+
+// resolve returns the configuration for path and the override
+// configuration of the request, tracing problems in the latter, which has
+// no configuration diagnostics of its own to be reported through.
+func (h *definedHandler[C]) resolve(rc *registration[C], path string, override []byte) (C, dprint.SharedConfig) {
+	cfg, shared, diags := rc.resolve(path, override)
+	for _, d := range diags {
+		h.debugIf(rc, "%s: override config: %s: %s", path, d.PropertyName, d.Message)
+	}
+	return cfg, shared
+}
+
+// ignored reports whether src opts out of formatting with a
+// dprint-ignore-file comment.
+func (h *definedHandler[C]) ignored(src []byte) bool {
+	return dprint.HasIgnoreFileDirective(src, h.def.LineComments, dprint.IgnoreFileDirective)
+}
+
+// oversized reports whether src is over the configured maxFileSize and is
+// to be skipped, or the error to report for it.
+func (h *definedHandler[C]) oversized(
+	rc *registration[C],
+	shared dprint.SharedConfig,
+	path string,
+	src []byte,
+) (bool, error) {
+	skip, err := shared.CheckSize(path, len(src))
+	if skip {
+		h.debugIf(rc, "%s: skipped, %d bytes is over maxFileSize", path, len(src))
+	}
+	return skip, err
+}
+
+// restoreIgnored puts the dprint-ignore-start/end regions of src back into
+// the formatter's result.
+func (h *definedHandler[C]) restoreIgnored(src, formatted []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return dprint.RestoreIgnoredRegions(src, formatted, h.def.LineComments)
+}
+
+// maxDebugLog is the number of trace messages kept for the host. WASM
+// hosts that never call get_debug_log would otherwise let the warnings,
+// which are recorded without the debug option, pile up for the life of
+// the plugin.
+const maxDebugLog = 1000
+
+// debugf records a trace message for the host, dropping the oldest once
+// maxDebugLog messages are waiting.
+func (h *definedHandler[C]) debugf(format string, args ...any) {
+	if len(h.debugLog) >= maxDebugLog {
+		h.debugLog = slices.Delete(h.debugLog, 0, len(h.debugLog)-maxDebugLog+1)
+	}
+	h.debugLog = append(h.debugLog, fmt.Sprintf(format, args...))
+}
+
+// debugIf records a trace message if the configuration enables debugging.
+func (h *definedHandler[C]) debugIf(rc *registration[C], format string, args ...any) {
+	if rc.shared.Debug {
+		h.debugf(format, args...)
+	}
+}
+
+// traceContext hands the host formatter, the package file reader where
+// files can be read and the warning logger to the formatters, and the debug
+// logger too when the configuration enables debugging. Warnings are
+// recorded with the trace messages whether debugging is enabled or not.
+func (h *definedHandler[C]) traceContext(ctx context.Context, rc *registration[C], path string) context.Context {
+	ctx = withPackageFiles(formatters.WithHostFormat(ctx, hostFormat))
+	ctx = formatters.WithWarningLog(ctx, func(format string, args ...any) {
+		h.debugf("%s: warning: %s", path, fmt.Sprintf(format, args...))
+	})
+	if !rc.shared.Debug {
+		return ctx
+	}
+	return formatters.WithDebugLog(ctx, func(format string, args ...any) {
+		h.debugf("%s: %s", path, fmt.Sprintf(format, args...))
+	})
+}
+
+// timed runs format and records how long it took if the configuration
+// enables debugging.
+func (h *definedHandler[C]) timed(rc *registration[C], path string, format func() ([]byte, error)) ([]byte, error) {
+	start := time.Now()
+	formatted, err := format()
+	h.debugIf(rc, "%s: formatted in %s", path, time.Since(start))
+	return formatted, err
+}
+
+func (h *definedHandler[C]) drainDebugLog() []string {
+	log := h.debugLog
+	h.debugLog = nil
+	return log
+}
+
+func (h *definedHandler[C]) recordFormat(size int, changed, cancelled bool, err error, elapsed time.Duration) {
+	h.stats.Record(size, changed, cancelled, err, elapsed)
+}
+
+func (h *definedHandler[C]) formatStats() dprint.Stats {
+	return h.stats
+}
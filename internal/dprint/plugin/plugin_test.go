@@ -0,0 +1,897 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+	"github.com/mridang/dprint-plugin-go/pkg/formatters"
+)
+
+// testConfig is the configuration type of the plugin used in these tests.
+type testConfig struct {
+	Suffix string `json:"suffix"`
+}
+
+// testManifest describes the plugins used in these tests.
+var testManifest = dprint.Manifest{ //nolint:gochecknoglobals // read-only fixture
+	Name:           "dprint-plugin-test",
+	Command:        "test",
+	ConfigKey:      "test",
+	FileExtensions: []string{"txt"},
+}
+
+// registerTestPlugin installs a plugin that appends the configured suffix
+// and fails on input containing "bad".
+func registerTestPlugin(t *testing.T) {
+	t.Helper()
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		Version:       "1.2.3\n",
+		License:       "license",
+		DefaultConfig: func() testConfig { return testConfig{Suffix: "!"} },
+		Formatter: dprint.PathIndependent(func(src []byte, cfg testConfig) ([]byte, error) {
+			if bytes.Contains(src, []byte("bad")) {
+				return nil, errors.New("bad input")
+			}
+			return append(bytes.TrimSuffix(src, []byte(cfg.Suffix)), cfg.Suffix...), nil
+		}),
+		LineComments: []string{"#"},
+	})
+}
+
+// hostWrite mimics the host writing data into the shared buffer.
+func hostWrite(b []byte) {
+	clear_shared_bytes(toUint32(len(b)))
+	copy(state.buffer, b)
+}
+
+// hostRead mimics the host reading n bytes out of the shared buffer.
+func hostRead(n uint32) string {
+	return string(state.buffer[:n])
+}
+
+// TestRuntime_Formats_With_Registered_Config drives the exports in the
+// order used by the dprint CLI and checks every result code.
+func TestRuntime_Formats_With_Registered_Config(t *testing.T) {
+	registerTestPlugin(t)
+
+	if got := hostRead(get_plugin_info()); got !=
+		`{"configKey":"test",`+
+			`"configSchemaUrl":"https://github.com/mridang/dprint-goat/releases/latest/download/test.schema.json",`+
+			`"fileExtensions":["txt"],"fileNames":[],"helpUrl":"https://github.com/mridang/dprint-goat#test",`+
+			`"name":"dprint-plugin-test","version":"1.2.3"}` {
+		t.Fatalf("get_plugin_info = %s", got)
+	}
+
+	hostWrite([]byte(`{"suffix":"?"}`))
+	register_config(1)
+
+	if got := hostRead(get_resolved_config(1)); got != `{"debug":false,"maxFileSize":0,"newLineKind":"lf","oversizedFiles":"error","strict":false,"suffix":"?"}` {
+		t.Fatalf("get_resolved_config = %s", got)
+	}
+	if got := hostRead(get_config_diagnostics(1)); got != `[]` {
+		t.Fatalf("get_config_diagnostics = %s", got)
+	}
+
+	hostWrite([]byte("text"))
+	if got := format(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	if got := hostRead(get_formatted_text()); got != "text?" {
+		t.Fatalf("formatted text = %q", got)
+	}
+
+	hostWrite([]byte("text?"))
+	if got := format(1); got != dprint.FormatResultNoChange {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultNoChange)
+	}
+
+	hostWrite([]byte("bad"))
+	if got := format(1); got != dprint.FormatResultError {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultError)
+	}
+	if got := hostRead(get_error_text()); got != "bad input" {
+		t.Fatalf("error text = %q", got)
+	}
+}
+
+// TestRuntime_Counts_Format_Stats verifies that get_format_stats counts
+// every kind of format result and the bytes received.
+func TestRuntime_Counts_Format_Stats(t *testing.T) {
+	registerTestPlugin(t)
+	hostWrite([]byte(`{"suffix":"?"}`))
+	register_config(1)
+
+	for _, text := range []string{"text", "more", "done?", "bad"} {
+		hostWrite([]byte(text))
+		format(1)
+	}
+	state.hasCancelled = func() bool { return true }
+	t.Cleanup(func() { state.hasCancelled = hostHasCancelled })
+	hostWrite([]byte("late"))
+	format(1)
+
+	var got dprint.Stats
+	if err := json.Unmarshal([]byte(hostRead(get_format_stats())), &got); err != nil {
+		t.Fatalf("get_format_stats: %v", err)
+	}
+	got.FormatMicros = 0
+	want := dprint.Stats{Changed: 2, Unchanged: 1, Errors: 1, Cancelled: 1, Bytes: 20}
+	if got != want {
+		t.Fatalf("get_format_stats = %+v; want %+v", got, want)
+	}
+}
+
+// TestRuntime_Rejects_Unregistered_Config verifies that formatting with an
+// unknown config id reports a schema mismatch.
+func TestRuntime_Rejects_Unregistered_Config(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte("text"))
+	if got := format(9); got != dprint.FormatResultError {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultError)
+	}
+	want := dprint.UnregisteredConfigError("format", 9).Error()
+	if got := hostRead(get_error_text()); got != want {
+		t.Fatalf("error text = %q; want %q", got, want)
+	}
+}
+
+// TestRuntime_Reports_Structural_Diagnostics_In_Strict_Mode verifies that
+// unknown properties and mistyped values are skipped silently unless the
+// configuration opts into strict mode.
+func TestRuntime_Reports_Structural_Diagnostics_In_Strict_Mode(t *testing.T) {
+	registerTestPlugin(t)
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"lenient", `{"plugin":{"bogus":true,"suffix":1},"global":{}}`, `[]`},
+		{
+			"strict",
+			`{"plugin":{"bogus":true,"suffix":1,"strict":true},"global":{}}`,
+			`[{"message":"unknown property","propertyName":"bogus"},` +
+				`{"message":"expected string but got number","propertyName":"suffix"}]`,
+		},
+		{
+			"strict array item",
+			`{"plugin":{"fileExtensions":["txt",1],"strict":true},"global":{}}`,
+			`[{"message":"expected string but got number","propertyName":"fileExtensions[1]"}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostWrite([]byte(tt.raw))
+			register_config(1)
+			if got := hostRead(get_config_diagnostics(1)); got != tt.want {
+				t.Fatalf("get_config_diagnostics = %s; want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRuntime_Resolves_Inherited_Config verifies that settings inherited
+// from the global configuration show up in the resolved configuration and
+// lose to the plugin's own options.
+func TestRuntime_Resolves_Inherited_Config(t *testing.T) {
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		DefaultConfig: func() testConfig { return testConfig{Suffix: "!"} },
+		Inherit: func(cfg testConfig, global dprint.GlobalConfig) testConfig {
+			if global.UseTabs != nil && *global.UseTabs {
+				cfg.Suffix = "\t"
+			}
+			return cfg
+		},
+		Formatter: dprint.PathIndependent(func(src []byte, _ testConfig) ([]byte, error) { return src, nil }),
+	})
+
+	hostWrite([]byte(`{"plugin":{},"global":{"useTabs":true,"newLineKind":"crlf"}}`))
+	register_config(1)
+	hostWrite([]byte(`{"plugin":{"suffix":"?"},"global":{"useTabs":true}}`))
+	register_config(2)
+
+	want := `{"debug":false,"maxFileSize":0,"newLineKind":"crlf","oversizedFiles":"error","strict":false,"suffix":"\t"}`
+	if got := hostRead(get_resolved_config(1)); got != want {
+		t.Fatalf("get_resolved_config(1) = %s; want %s", got, want)
+	}
+	want = `{"debug":false,"maxFileSize":0,"newLineKind":"lf","oversizedFiles":"error","strict":false,"suffix":"?"}`
+	if got := hostRead(get_resolved_config(2)); got != want {
+		t.Fatalf("get_resolved_config(2) = %s; want %s", got, want)
+	}
+}
+
+// TestRuntime_Reports_Configured_File_Matching verifies that the file
+// matching of a configuration follows its fileExtensions and fileNames
+// options, falls back to the manifest and adds its extraFileNames.
+func TestRuntime_Reports_Configured_File_Matching(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"plugin":{"fileExtensions":["tpl","text"],"fileNames":["Notes"]},"global":{}}`))
+	register_config(1)
+	hostWrite(nil)
+	register_config(2)
+	hostWrite([]byte(`{"plugin":{"fileExtensions":[".tpl"],"extraFileNames":["a/b"]},"global":{}}`))
+	register_config(3)
+	hostWrite([]byte(`{"plugin":{"extraFileNames":["Notes","Todo"]},"global":{}}`))
+	register_config(4)
+	hostWrite([]byte(`{"plugin":{"fileNames":["Notes"],"extraFileNames":["Notes","Todo"]},"global":{}}`))
+	register_config(5)
+
+	for id, want := range map[uint32]string{
+		1: `{"fileExtensions":["tpl","text"],"fileNames":["Notes"]}`,
+		2: `{"fileExtensions":["txt"],"fileNames":[]}`,
+		4: `{"fileExtensions":["txt"],"fileNames":["Notes","Todo"]}`,
+		5: `{"fileExtensions":["txt"],"fileNames":["Notes","Todo"]}`,
+		9: `{"fileExtensions":["txt"],"fileNames":[]}`,
+	} {
+		if got := hostRead(get_config_file_matching(id)); got != want {
+			t.Fatalf("get_config_file_matching(%d) = %s; want %s", id, got, want)
+		}
+	}
+	want := `[{"message":"\"a/b\" must be a plain file name","propertyName":"extraFileNames"},` +
+		`{"message":"\".tpl\" must be lower case without a leading dot","propertyName":"fileExtensions"}]`
+	if got := hostRead(get_config_diagnostics(3)); got != want {
+		t.Fatalf("get_config_diagnostics(3) = %s; want %s", got, want)
+	}
+}
+
+// TestRuntime_Passes_Files_Through verifies that a passthrough plugin
+// claims only the configured files and returns them unchanged, without
+// applying the shared options.
+func TestRuntime_Passes_Files_Through(t *testing.T) {
+	manifest := testManifest
+	manifest.FileExtensions, manifest.Passthrough = nil, true
+	Register(Definition[testConfig]{
+		Manifest:      manifest,
+		DefaultConfig: func() testConfig { return testConfig{Suffix: "!"} },
+		Formatter: dprint.PathIndependent(func(src []byte, cfg testConfig) ([]byte, error) {
+			return append(src, cfg.Suffix...), nil
+		}),
+	})
+
+	hostWrite(nil)
+	register_config(1)
+	hostWrite([]byte(`{"plugin":{"fileExtensions":["lock"],"newLineKind":"crlf"},"global":{}}`))
+	register_config(2)
+	for id, want := range map[uint32]string{
+		1: `{"fileExtensions":[],"fileNames":[]}`,
+		2: `{"fileExtensions":["lock"],"fileNames":[]}`,
+	} {
+		if got := hostRead(get_config_file_matching(id)); got != want {
+			t.Fatalf("get_config_file_matching(%d) = %s; want %s", id, got, want)
+		}
+	}
+	hostWrite([]byte("a\nb"))
+	if got := format(2); got != dprint.FormatResultNoChange {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultNoChange)
+	}
+}
+
+// TestRuntime_Keeps_Configs_Per_ID verifies that configurations registered
+// under different ids are resolved and released independently.
+func TestRuntime_Keeps_Configs_Per_ID(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"plugin":{"suffix":"?"},"global":{}}`))
+	register_config(1)
+	hostWrite([]byte(`{"plugin":{"suffix":"#","bogus":true,"strict":true},"global":{}}`))
+	register_config(2)
+
+	for id, want := range map[uint32]string{1: "x?", 2: "x#"} {
+		hostWrite([]byte("x"))
+		if got := format(id); got != dprint.FormatResultChanged {
+			t.Fatalf("format(%d) = %d; want %d", id, got, dprint.FormatResultChanged)
+		}
+		if got := hostRead(get_formatted_text()); got != want {
+			t.Fatalf("format(%d) text = %q; want %q", id, got, want)
+		}
+	}
+
+	if got := hostRead(get_config_diagnostics(1)); got != `[]` {
+		t.Fatalf("get_config_diagnostics(1) = %s", got)
+	}
+	if got := hostRead(get_config_diagnostics(2)); got != `[{"message":"unknown property","propertyName":"bogus"}]` {
+		t.Fatalf("get_config_diagnostics(2) = %s", got)
+	}
+
+	release_config(1)
+	if got := hostRead(get_resolved_config(1)); got != `{}` {
+		t.Fatalf("get_resolved_config after release = %s", got)
+	}
+	if got := hostRead(get_resolved_config(2)); got != `{"debug":false,"maxFileSize":0,"newLineKind":"lf","oversizedFiles":"error","strict":true,"suffix":"#"}` {
+		t.Fatalf("get_resolved_config(2) = %s", got)
+	}
+}
+
+// TestRuntime_Caches_Resolved_Config verifies that the resolved config is
+// serialized once per registration and refreshed when the id is registered
+// again.
+func TestRuntime_Caches_Resolved_Config(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"plugin":{"suffix":"?"},"global":{}}`))
+	register_config(1)
+	first := hostRead(get_resolved_config(1))
+	cached, _ := active.resolvedConfig(1)
+	if got := hostRead(get_resolved_config(1)); got != first {
+		t.Fatalf("get_resolved_config(1) again = %s; want %s", got, first)
+	}
+	if again, _ := active.resolvedConfig(1); &again[0] != &cached[0] {
+		t.Fatalf("resolvedConfig(1) serialized the config again")
+	}
+
+	hostWrite([]byte(`{"plugin":{"suffix":"#"},"global":{}}`))
+	register_config(1)
+	want := `{"debug":false,"maxFileSize":0,"newLineKind":"lf","oversizedFiles":"error","strict":false,"suffix":"#"}`
+	if got := hostRead(get_resolved_config(1)); got != want {
+		t.Fatalf("get_resolved_config(1) after register_config = %s; want %s", got, want)
+	}
+}
+
+// TestRuntime_Passes_File_Path verifies that the path sent through
+// set_file_path reaches the formatter.
+func TestRuntime_Passes_File_Path(t *testing.T) {
+	var seen string
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		DefaultConfig: func() testConfig { return testConfig{} },
+		Formatter: dprint.FormatterFunc[testConfig](
+			func(_ context.Context, path string, src []byte, _ testConfig) ([]byte, error) {
+				seen = path
+				return src, nil
+			},
+		),
+	})
+
+	hostWrite(nil)
+	register_config(1)
+	hostWrite([]byte("dir/file.txt"))
+	set_file_path()
+	hostWrite([]byte("text"))
+	if got := format(1); got != dprint.FormatResultNoChange {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultNoChange)
+	}
+	if seen != "dir/file.txt" {
+		t.Fatalf("formatter saw path %q; want %q", seen, "dir/file.txt")
+	}
+}
+
+// TestRuntime_Applies_Overrides_By_Path verifies that overrides apply to
+// the files matching their patterns, later overrides winning, and that
+// problems inside an override are reported under its position.
+func TestRuntime_Applies_Overrides_By_Path(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"plugin":{"suffix":"!","overrides":[` +
+		`{"files":["*.md"],"suffix":"?"},` +
+		`{"files":["docs/*.md"],"suffix":"#","newLineKind":"crlf"}]},"global":{}}`))
+	register_config(1)
+	if got := hostRead(get_config_diagnostics(1)); got != `[]` {
+		t.Fatalf("get_config_diagnostics = %s", got)
+	}
+
+	for path, want := range map[string]string{
+		"/src/a.txt":       "a\nb!",
+		"/src/a.md":        "a\nb?",
+		"/src/docs/a.md":   "a\r\nb#",
+		"/src/docs/a.text": "a\nb!",
+	} {
+		hostWrite([]byte(path))
+		set_file_path()
+		hostWrite([]byte("a\nb"))
+		format(1)
+		if got := hostRead(get_formatted_text()); got != want {
+			t.Fatalf("format(%s) = %q; want %q", path, got, want)
+		}
+	}
+
+	hostWrite([]byte(`{"plugin":{"overrides":[{"suffix":1},{"files":["["],"newLineKind":"cr"}]},"global":{}}`))
+	register_config(2)
+	want := `[{"message":"must list at least one pattern","propertyName":"overrides[0].files"},` +
+		`{"message":"invalid pattern \"[\"","propertyName":"overrides[1].files"},` +
+		`{"message":"must be one of auto, lf, crlf, system","propertyName":"overrides[1].newLineKind"}]`
+	if got := hostRead(get_config_diagnostics(2)); got != want {
+		t.Fatalf("get_config_diagnostics(2) = %s; want %s", got, want)
+	}
+}
+
+// TestRuntime_Applies_Override_Config verifies that the override
+// configuration set by the host wins over overrides by path and applies to
+// the next format call only.
+func TestRuntime_Applies_Override_Config(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"plugin":{"suffix":"!","overrides":[{"files":["*.md"],"suffix":"?"}]},"global":{}}`))
+	register_config(1)
+
+	for _, tt := range []struct {
+		override string
+		want     string
+	}{
+		{override: `{"suffix":"#","newLineKind":"crlf"}`, want: "a\r\nb#"},
+		{override: `{"suffix":1}`, want: "a\nb?"},
+		{override: "", want: "a\nb?"},
+	} {
+		if tt.override != "" {
+			hostWrite([]byte(tt.override))
+			set_override_config()
+		}
+		hostWrite([]byte("/src/a.md"))
+		set_file_path()
+		hostWrite([]byte("a\nb"))
+		format(1)
+		if got := hostRead(get_formatted_text()); got != tt.want {
+			t.Fatalf("format with override %s = %q; want %q", tt.override, got, tt.want)
+		}
+	}
+}
+
+// TestRuntime_Skips_Excluded_Paths verifies that files matching an exclude
+// pattern, including one set by an override, are left unformatted.
+func TestRuntime_Skips_Excluded_Paths(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"plugin":{"suffix":"!","exclude":["vendor/"],` +
+		`"overrides":[{"files":["*.gen.txt"],"exclude":["*"]}]},"global":{}}`))
+	register_config(1)
+	if got := hostRead(get_config_diagnostics(1)); got != `[]` {
+		t.Fatalf("get_config_diagnostics = %s", got)
+	}
+
+	for path, want := range map[string]uint32{
+		"/src/a.txt":          dprint.FormatResultChanged,
+		"/src/vendor/x/a.txt": dprint.FormatResultNoChange,
+		"/src/a.gen.txt":      dprint.FormatResultNoChange,
+	} {
+		hostWrite([]byte(path))
+		set_file_path()
+		hostWrite([]byte("a"))
+		if got := format(1); got != want {
+			t.Fatalf("format(%s) = %d; want %d", path, got, want)
+		}
+	}
+
+	hostWrite([]byte(`{"exclude":["[", "/"]}`))
+	register_config(2)
+	want := `[{"message":"invalid pattern \"/\"","propertyName":"exclude"},` +
+		`{"message":"invalid pattern \"[\"","propertyName":"exclude"}]`
+	if got := hostRead(get_config_diagnostics(2)); got != want {
+		t.Fatalf("get_config_diagnostics(2) = %s; want %s", got, want)
+	}
+}
+
+// TestRuntime_Limits_File_Size verifies that files over maxFileSize are
+// reported with their size and the limit, or skipped when configured so.
+func TestRuntime_Limits_File_Size(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"maxFileSize":4}`))
+	register_config(1)
+	hostWrite([]byte(`{"maxFileSize":4,"oversizedFiles":"skip"}`))
+	register_config(2)
+
+	hostWrite([]byte("text"))
+	if got := format(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format at the limit = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	hostWrite([]byte("big.txt"))
+	set_file_path()
+	hostWrite([]byte("texts"))
+	if got := format(1); got != dprint.FormatResultError {
+		t.Fatalf("format over the limit = %d; want %d", got, dprint.FormatResultError)
+	}
+	want := (&dprint.FileTooLargeError{Path: "big.txt", Size: 5, Limit: 4}).Error()
+	if got := hostRead(get_error_text()); got != want {
+		t.Fatalf("error text = %q; want %q", got, want)
+	}
+	hostWrite([]byte("texts"))
+	if got := format(2); got != dprint.FormatResultNoChange {
+		t.Fatalf("format over the limit with skip = %d; want %d", got, dprint.FormatResultNoChange)
+	}
+}
+
+// TestRuntime_Applies_Newline_Kind verifies that CRLF input reaches the
+// formatter as LF and that the configured or global newline kind decides
+// the line endings of the result.
+func TestRuntime_Applies_Newline_Kind(t *testing.T) {
+	registerTestPlugin(t)
+
+	tests := []struct {
+		name   string
+		config string
+		input  string
+		want   string
+	}{
+		{"default is lf", `{}`, "a\r\nb", "a\nb!"},
+		{"plugin crlf", `{"plugin":{"newLineKind":"crlf"},"global":{}}`, "a\nb", "a\r\nb!"},
+		{"global crlf", `{"plugin":{},"global":{"newLineKind":"crlf"}}`, "a\nb", "a\r\nb!"},
+		{"plugin wins", `{"plugin":{"newLineKind":"lf"},"global":{"newLineKind":"crlf"}}`, "a\r\nb", "a\nb!"},
+		{"auto keeps crlf", `{"newLineKind":"auto"}`, "a\r\nb", "a\r\nb!"},
+		{"auto keeps lf", `{"newLineKind":"auto"}`, "a\nb", "a\nb!"},
+		{"final newline with crlf", `{"newLineKind":"crlf","insertFinalNewline":true}`, "a\nb", "a\r\nb!\r\n"},
+		{"final newline stripped", `{"insertFinalNewline":false,"suffix":"\n"}`, "a\n\n", "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostWrite([]byte(tt.config))
+			register_config(1)
+			if got := hostRead(get_config_diagnostics(1)); got != `[]` {
+				t.Fatalf("get_config_diagnostics = %s", got)
+			}
+			hostWrite([]byte(tt.input))
+			if got := format(1); got != dprint.FormatResultChanged {
+				t.Fatalf("format = %d; want %d", got, dprint.FormatResultChanged)
+			}
+			if got := hostRead(get_formatted_text()); got != tt.want {
+				t.Fatalf("formatted text = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRuntime_Returns_Diff verifies that format_diff returns a unified diff
+// of changed files and nothing for unchanged ones, while format keeps
+// returning the formatted text.
+func TestRuntime_Returns_Diff(t *testing.T) {
+	registerTestPlugin(t)
+	hostWrite([]byte(`{"suffix":"?"}`))
+	register_config(1)
+
+	hostWrite([]byte("a.txt"))
+	set_file_path()
+	hostWrite([]byte("text"))
+	if got := format_diff(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format_diff = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	want := "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-text\n\\ No newline at end of file\n+text?\n\\ No newline at end of file\n"
+	if got := hostRead(get_formatted_text()); got != want {
+		t.Fatalf("formatted text = %q; want %q", got, want)
+	}
+
+	hostWrite([]byte("text?"))
+	if got := format_diff(1); got != dprint.FormatResultNoChange {
+		t.Fatalf("format_diff unchanged = %d; want %d", got, dprint.FormatResultNoChange)
+	}
+
+	hostWrite([]byte("a.txt"))
+	set_file_path()
+	hostWrite([]byte("text"))
+	if got := format(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	if got := hostRead(get_formatted_text()); got != "text?" {
+		t.Fatalf("formatted text = %q; want %q", got, "text?")
+	}
+}
+
+// TestRuntime_Rejects_Unknown_Newline_Kind verifies that an invalid
+// newLineKind is reported as a configuration diagnostic.
+func TestRuntime_Rejects_Unknown_Newline_Kind(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"newLineKind":"cr"}`))
+	register_config(1)
+	want := `[{"message":"must be one of auto, lf, crlf, system","propertyName":"newLineKind"}]`
+	if got := hostRead(get_config_diagnostics(1)); got != want {
+		t.Fatalf("get_config_diagnostics = %s; want %s", got, want)
+	}
+}
+
+// TestRuntime_Reports_No_Change_When_Cancelled verifies that a request the
+// host cancels while it is being formatted reports no change instead of
+// the formatted text or an error.
+func TestRuntime_Reports_No_Change_When_Cancelled(t *testing.T) {
+	cancelled := false
+	state.hasCancelled = func() bool { return cancelled }
+	t.Cleanup(func() { state.hasCancelled = hostHasCancelled })
+
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		DefaultConfig: func() testConfig { return testConfig{} },
+		Formatter: dprint.FormatterFunc[testConfig](
+			func(ctx context.Context, _ string, _ []byte, _ testConfig) ([]byte, error) {
+				cancelled = true
+				return nil, ctx.Err()
+			},
+		),
+	})
+
+	hostWrite(nil)
+	register_config(1)
+	hostWrite([]byte("text"))
+	if got := format(1); got != dprint.FormatResultNoChange {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultNoChange)
+	}
+}
+
+// TestRuntime_Grows_Shared_Buffer verifies that files larger than the
+// initial shared buffer are accepted and their results returned in full.
+func TestRuntime_Grows_Shared_Buffer(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite(nil)
+	register_config(1)
+
+	input := bytes.Repeat([]byte("x"), dprint.SharedBufferSize+10)
+	hostWrite(input)
+	if got := format(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	if got, want := hostRead(get_formatted_text()), string(input)+"!"; got != want {
+		t.Fatalf("formatted text has %d bytes; want %d", len(got), len(want))
+	}
+}
+
+// TestRuntime_Formats_Ranges verifies that format_range reaches the range
+// formatter with offsets adjusted for CRLF normalization and falls back to
+// the whole-file formatter when the range is unsupported.
+func TestRuntime_Formats_Ranges(t *testing.T) {
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		DefaultConfig: func() testConfig { return testConfig{} },
+		Formatter: dprint.PathIndependent(func(src []byte, _ testConfig) ([]byte, error) {
+			return bytes.ToUpper(src), nil
+		}),
+		RangeFormatter: dprint.RangeFormatterFunc[testConfig](
+			func(_ context.Context, _ string, src []byte, start, end int, _ testConfig) ([]byte, error) {
+				if start == end {
+					return nil, formatters.ErrRangeUnsupported
+				}
+				return slices.Concat(src[:start], bytes.ToUpper(src[start:end]), src[end:]), nil
+			},
+		),
+	})
+
+	hostWrite([]byte(`{"newLineKind":"auto"}`))
+	register_config(1)
+
+	hostWrite([]byte("ab\r\ncd\r\nef"))
+	if got := format_range(1, 4, 6); got != dprint.FormatResultChanged {
+		t.Fatalf("format_range = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	if got, want := hostRead(get_formatted_text()), "ab\r\nCD\r\nef"; got != want {
+		t.Fatalf("format_range text = %q; want %q", got, want)
+	}
+
+	hostWrite([]byte("ab\r\ncd"))
+	if got := format_range(1, 2, 2); got != dprint.FormatResultChanged {
+		t.Fatalf("format_range = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	if got, want := hostRead(get_formatted_text()), "AB\r\nCD"; got != want {
+		t.Fatalf("format_range fallback text = %q; want %q", got, want)
+	}
+}
+
+// TestRuntime_Serves_Config_Schema verifies that the schema export covers
+// the plugin options and the shared options.
+func TestRuntime_Serves_Config_Schema(t *testing.T) {
+	registerTestPlugin(t)
+
+	got := hostRead(get_config_schema())
+	for _, want := range []string{
+		`"title":"dprint-plugin-test"`,
+		`"suffix":{"default":"!","type":"string"}`,
+		`"newLineKind":`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("get_config_schema = %s; missing %s", got, want)
+		}
+	}
+}
+
+// TestRuntime_Serves_Capabilities verifies that the capabilities export
+// follows the definition.
+func TestRuntime_Serves_Capabilities(t *testing.T) {
+	registerTestPlugin(t)
+	want := `{"cancellation":true,"formatDiff":true,"ignoreComments":true,"overrideConfig":true,"rangeFormatting":false}`
+	if got := hostRead(get_capabilities()); got != want {
+		t.Fatalf("get_capabilities = %s; want %s", got, want)
+	}
+
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		DefaultConfig: func() testConfig { return testConfig{} },
+		Formatter:     dprint.PathIndependent(func(src []byte, _ testConfig) ([]byte, error) { return src, nil }),
+		RangeFormatter: dprint.RangeFormatterFunc[testConfig](
+			func(_ context.Context, _ string, src []byte, _, _ int, _ testConfig) ([]byte, error) {
+				return src, nil
+			},
+		),
+	})
+	want = `{"cancellation":true,"formatDiff":true,"ignoreComments":false,"overrideConfig":true,"rangeFormatting":true}`
+	if got := hostRead(get_capabilities()); got != want {
+		t.Fatalf("get_capabilities = %s; want %s", got, want)
+	}
+}
+
+// TestRuntime_Recovers_Formatter_Panics verifies that a panicking
+// formatter is reported as a formatting error and leaves the runtime usable.
+func TestRuntime_Recovers_Formatter_Panics(t *testing.T) {
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		DefaultConfig: func() testConfig { return testConfig{} },
+		Formatter: dprint.PathIndependent(func(src []byte, _ testConfig) ([]byte, error) {
+			if bytes.Equal(src, []byte("boom")) {
+				panic("index out of range")
+			}
+			return bytes.ToUpper(src), nil
+		}),
+	})
+
+	hostWrite(nil)
+	register_config(1)
+
+	hostWrite([]byte("boom"))
+	if got := format(1); got != dprint.FormatResultError {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultError)
+	}
+	if got, want := hostRead(get_error_text()), "formatter panicked: index out of range"; got != want {
+		t.Fatalf("error text = %q; want %q", got, want)
+	}
+
+	hostWrite([]byte("fine"))
+	if got := format(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format after panic = %d; want %d", got, dprint.FormatResultChanged)
+	}
+}
+
+// TestRuntime_Skips_Ignored_Files verifies that a dprint-ignore-file
+// comment leaves the file untouched, including its line endings.
+func TestRuntime_Skips_Ignored_Files(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite(nil)
+	register_config(1)
+
+	hostWrite([]byte("# dprint-ignore-file\r\ntext"))
+	if got := format(1); got != dprint.FormatResultNoChange {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultNoChange)
+	}
+	hostWrite([]byte("text\n# dprint-ignore-file\n"))
+	if got := format(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format with a late directive = %d; want %d", got, dprint.FormatResultChanged)
+	}
+}
+
+// TestRuntime_Records_Debug_Log verifies that trace messages are recorded
+// only for configurations with the debug option and drained when read.
+func TestRuntime_Records_Debug_Log(t *testing.T) {
+	registerTestPlugin(t)
+
+	hostWrite([]byte(`{"debug":true}`))
+	register_config(1)
+	hostWrite(nil)
+	register_config(2)
+	hostWrite([]byte("dir/a.txt"))
+	set_file_path()
+	hostWrite([]byte("text"))
+	format(1)
+	hostWrite([]byte("text"))
+	format(2)
+
+	log := hostRead(get_debug_log())
+	for _, want := range []string{"config 1 resolved to", "dir/a.txt: formatted in"} {
+		if !strings.Contains(log, want) {
+			t.Fatalf("debug log %q; missing %q", log, want)
+		}
+	}
+	if strings.Contains(log, "config 2") || strings.Count(log, "formatted in") != 1 {
+		t.Fatalf("debug log %q has messages for the config without debug", log)
+	}
+	if got := hostRead(get_debug_log()); got != "" {
+		t.Fatalf("debug log after draining = %q", got)
+	}
+}
+
+// TestRuntime_Caps_Debug_Log verifies that only the latest maxDebugLog
+// trace messages are kept for a host that never drains them.
+func TestRuntime_Caps_Debug_Log(t *testing.T) {
+	h := &definedHandler[testConfig]{}
+	for i := range maxDebugLog + 5 {
+		h.debugf("warning %d", i)
+	}
+	log := h.drainDebugLog()
+	if len(log) != maxDebugLog || log[0] != "warning 5" || log[len(log)-1] != fmt.Sprintf("warning %d", maxDebugLog+4) {
+		t.Fatalf("debug log has %d messages from %q to %q; want %d from %q", len(log), log[0], log[len(log)-1], maxDebugLog, "warning 5")
+	}
+}
+
+// TestRuntime_Rejects_Unexpected_Call_Order verifies that results are only
+// readable right after the call that produced them and that a format call
+// without a freshly written file or path does not reuse stale ones.
+func TestRuntime_Rejects_Unexpected_Call_Order(t *testing.T) {
+	var seen string
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		DefaultConfig: func() testConfig { return testConfig{Suffix: "!"} },
+		Formatter: dprint.FormatterFunc[testConfig](
+			func(_ context.Context, path string, src []byte, cfg testConfig) ([]byte, error) {
+				seen = path
+				return append(src, cfg.Suffix...), nil
+			},
+		),
+	})
+	hostWrite(nil)
+	register_config(1)
+
+	hostWrite([]byte("a.txt"))
+	set_file_path()
+	hostWrite([]byte("text"))
+	if got := format(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	if got := get_error_text(); got != 0 {
+		t.Fatalf("get_error_text after a change = %d; want 0", got)
+	}
+	if got := hostRead(get_formatted_text()); got != "text!" {
+		t.Fatalf("formatted text = %q", got)
+	}
+
+	if got := format(1); got != dprint.FormatResultError {
+		t.Fatalf("format without a new file = %d; want %d", got, dprint.FormatResultError)
+	}
+	if got := get_formatted_text(); got != 0 {
+		t.Fatalf("get_formatted_text after an error = %d; want 0", got)
+	}
+
+	hostWrite([]byte("text"))
+	format(1)
+	if seen != "" {
+		t.Fatalf("formatter saw stale path %q", seen)
+	}
+	get_plugin_info()
+	if got := get_formatted_text(); got != 0 {
+		t.Fatalf("get_formatted_text after get_plugin_info = %d; want 0", got)
+	}
+}
+
+// TestRuntime_Formats_Shared_Buffer_In_Place verifies that formatters read
+// the file straight from the shared buffer, that results aliasing it are
+// handed back as is and that appending to the input leaves it intact.
+func TestRuntime_Formats_Shared_Buffer_In_Place(t *testing.T) {
+	var seen []byte
+	Register(Definition[testConfig]{
+		Manifest:      testManifest,
+		DefaultConfig: func() testConfig { return testConfig{} },
+		Formatter: dprint.PathIndependent(func(src []byte, cfg testConfig) ([]byte, error) {
+			seen = src
+			if cfg.Suffix != "" {
+				return append(src, cfg.Suffix...), nil
+			}
+			return bytes.TrimSpace(src), nil
+		}),
+	})
+
+	hostWrite(nil)
+	register_config(1)
+	hostWrite([]byte("text  "))
+	if got := format(1); got != dprint.FormatResultChanged {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	if &seen[0] != &state.buffer[0] {
+		t.Fatal("formatter received a copy of the shared buffer")
+	}
+	if got := hostRead(get_formatted_text()); got != "text" {
+		t.Fatalf("formatted text = %q; want %q", got, "text")
+	}
+
+	hostWrite([]byte(`{"suffix":"!"}`))
+	register_config(2)
+	hostWrite([]byte("text"))
+	if got := format(2); got != dprint.FormatResultChanged {
+		t.Fatalf("format = %d; want %d", got, dprint.FormatResultChanged)
+	}
+	if got := hostRead(get_formatted_text()); got != "text!" {
+		t.Fatalf("formatted text = %q; want %q", got, "text!")
+	}
+}
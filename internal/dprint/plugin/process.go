@@ -0,0 +1,388 @@
+//go:build !tinygo
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mridang/dprint-plugin-go/internal/dprint"
+)
+
+// processSchemaVersion is the dprint process plugin schema spoken by Serve.
+const processSchemaVersion = 5
+
+// Message kinds of the process plugin schema.
+const (
+	kindSuccess              = 0
+	kindDataResponse         = 1
+	kindErrorResponse        = 2
+	kindFormatResponse       = 3
+	kindClose                = 4
+	kindIsAlive              = 5
+	kindGetPluginInfo        = 6
+	kindGetLicenseText       = 7
+	kindRegisterConfig       = 8
+	kindReleaseConfig        = 9
+	kindGetConfigDiagnostics = 10
+	kindGetFileMatchingInfo  = 11
+	kindGetResolvedConfig    = 12
+	kindCheckConfigUpdates   = 13
+	kindFormat               = 14
+	kindCancelFormat         = 15
+)
+
+// parentCheckInterval is how often Serve checks that the process that
+// started the plugin is still running.
+const parentCheckInterval = 30 * time.Second
+
+// errParentExited is returned by Serve once the process that started the
+// plugin is gone.
+var errParentExited = errors.New("parent process exited")
+
+// successBytes terminate every message of the process plugin schema.
+var successBytes = []byte{0xFF, 0xFF, 0xFF, 0xFF} //nolint:gochecknoglobals // read-only protocol constant
+
+// processConn reads host messages from r and writes responses to w.
+type processConn struct {
+	r      *bufio.Reader
+	w      *bufio.Writer
+	log    io.Writer
+	nextID uint32
+}
+
+// Serve speaks the dprint process plugin schema over r and w on behalf of
+// the registered plugin until the host sends a close message or closes r.
+// Trace messages of configurations with the debug option are written to
+// log, which dprint shows with --log-level=debug. Requests are handled one
+// at a time, so cancel messages have nothing to cancel and are ignored.
+// With a parentPID above zero, Serve returns errParentExited once that
+// process is gone, so a plugin does not outlive a dprint that was killed.
+func Serve(r io.Reader, w io.Writer, log io.Writer, parentPID int) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() { served <- serve(r, w, log) }()
+	select {
+	case err := <-served:
+		return err
+	case <-watchParent(ctx, parentPID, parentCheckInterval):
+		return errParentExited
+	}
+}
+
+// serve answers host messages until the host sends a close message or
+// closes r.
+func serve(r io.Reader, w io.Writer, log io.Writer) error {
+	conn := &processConn{r: bufio.NewReader(r), w: bufio.NewWriter(w), log: log}
+	if err := conn.handshake(); err != nil {
+		return err
+	}
+	for {
+		done, err := conn.handleMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err = conn.w.Flush(); err != nil {
+			return err
+		}
+		for _, line := range active.drainDebugLog() {
+			_, _ = fmt.Fprintln(conn.log, line)
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// watchParent returns a channel that is closed once the process pid is no
+// longer running, checked every interval until ctx is done. It is never
+// closed for a pid of zero or less.
+func watchParent(ctx context.Context, pid int, interval time.Duration) <-chan struct{} {
+	gone := make(chan struct{})
+	if pid <= 0 {
+		return gone
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !processRunning(pid) {
+					close(gone)
+					return
+				}
+			}
+		}
+	}()
+	return gone
+}
+
+// handshake answers the host's schema version request.
+func (c *processConn) handshake() error {
+	if _, err := c.readU32(); err != nil {
+		return err
+	}
+	c.writeU32(0)
+	c.writeU32(processSchemaVersion)
+	return c.w.Flush()
+}
+
+// handleMessage reads one message and writes its response. It reports
+// whether the host asked the plugin to exit.
+func (c *processConn) handleMessage() (bool, error) {
+	id, err := c.readU32()
+	if err != nil {
+		return false, err
+	}
+	kind, err := c.readU32()
+	if err != nil {
+		return false, err
+	}
+
+	switch kind {
+	case kindClose:
+		return true, c.finish(func() { c.writeSuccess(id) })
+	case kindIsAlive:
+		return false, c.finish(func() { c.writeSuccess(id) })
+	case kindGetPluginInfo:
+		return false, c.finish(func() { c.writeJSON(id, active.pluginInfo()) })
+	case kindGetLicenseText:
+		return false, c.finish(func() { c.writeData(id, []byte(active.licenseText())) })
+	case kindRegisterConfig:
+		return false, c.handleRegisterConfig(id)
+	case kindReleaseConfig:
+		configID, err := c.readU32()
+		if err != nil {
+			return false, err
+		}
+		return false, c.finish(func() {
+			active.releaseConfig(configID)
+			c.writeSuccess(id)
+		})
+	case kindGetConfigDiagnostics:
+		configID, err := c.readU32()
+		if err != nil {
+			return false, err
+		}
+		return false, c.finish(func() { c.writeJSON(id, configDiagnostics(configID)) })
+	case kindGetFileMatchingInfo:
+		configID, err := c.readU32()
+		if err != nil {
+			return false, err
+		}
+		return false, c.finish(func() { c.writeJSON(id, active.fileMatching(configID)) })
+	case kindGetResolvedConfig:
+		configID, err := c.readU32()
+		if err != nil {
+			return false, err
+		}
+		return false, c.finish(func() {
+			resolved, ok := active.resolvedConfig(configID)
+			if !ok {
+				resolved = []byte("{}")
+			}
+			c.writeData(id, resolved)
+		})
+	case kindCheckConfigUpdates:
+		if _, err := c.readBytes(); err != nil {
+			return false, err
+		}
+		return false, c.finish(func() { c.writeJSON(id, map[string]any{"changes": []any{}}) })
+	case kindFormat:
+		return false, c.handleFormat(id)
+	case kindCancelFormat:
+		if _, err := c.readU32(); err != nil {
+			return false, err
+		}
+		return false, c.readSuccessBytes()
+	default:
+		return false, fmt.Errorf("unknown process plugin message kind %d", kind)
+	}
+}
+
+// handleRegisterConfig registers the global and plugin configuration the
+// same way register_config does for the WASM build.
+func (c *processConn) handleRegisterConfig(id uint32) error {
+	configID, err := c.readU32()
+	if err != nil {
+		return err
+	}
+	global, err := c.readBytes()
+	if err != nil {
+		return err
+	}
+	pluginConfig, err := c.readBytes()
+	if err != nil {
+		return err
+	}
+	return c.finish(func() {
+		raw := fmt.Appendf(nil, `{"plugin":%s,"global":%s}`, orEmptyObject(pluginConfig), orEmptyObject(global))
+		active.registerConfig(configID, raw)
+		c.writeSuccess(id)
+	})
+}
+
+// handleFormat formats the file sent with a format message. A range that
+// covers the whole file is formatted like a full request.
+func (c *processConn) handleFormat(id uint32) error {
+	path, err := c.readBytes()
+	if err != nil {
+		return err
+	}
+	start, err := c.readU32()
+	if err != nil {
+		return err
+	}
+	end, err := c.readU32()
+	if err != nil {
+		return err
+	}
+	configID, err := c.readU32()
+	if err != nil {
+		return err
+	}
+	override, err := c.readBytes()
+	if err != nil {
+		return err
+	}
+	input, err := c.readBytes()
+	if err != nil {
+		return err
+	}
+
+	fn := func(ctx context.Context, input []byte) ([]byte, error) {
+		if start == 0 && int(end) >= len(input) {
+			return active.format(ctx, configID, string(path), override, input)
+		}
+		return active.formatRange(ctx, configID, string(path), override, input, int(start), int(end))
+	}
+	return c.finish(func() {
+		formatted, changed, err := runFormat(context.Background(), input, fn)
+		switch {
+		case err != nil:
+			c.writeError(id, err)
+		case !changed:
+			c.writeFormatResponse(id, nil, false)
+		default:
+			c.writeFormatResponse(id, formatted, true)
+		}
+	})
+}
+
+// finish reads the success bytes closing a request and then runs respond.
+func (c *processConn) finish(respond func()) error {
+	if err := c.readSuccessBytes(); err != nil {
+		return err
+	}
+	respond()
+	return nil
+}
+
+func (c *processConn) readU32() (uint32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(c.r, buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(buf[:]), nil
+}
+
+func (c *processConn) readBytes() ([]byte, error) {
+	size, err := c.readU32()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	if _, err = io.ReadFull(c.r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (c *processConn) readSuccessBytes() error {
+	var buf [4]byte
+	if _, err := io.ReadFull(c.r, buf[:]); err != nil {
+		return err
+	}
+	if buf != [4]byte(successBytes) {
+		return errors.New("process plugin message is not terminated by the success bytes")
+	}
+	return nil
+}
+
+func (c *processConn) writeU32(v uint32) {
+	_ = binary.Write(c.w, binary.BigEndian, v)
+}
+
+func (c *processConn) writeBytes(b []byte) {
+	c.writeU32(toUint32(len(b)))
+	_, _ = c.w.Write(b)
+}
+
+// writeMessage writes a response header, lets body write the payload and
+// terminates the message.
+func (c *processConn) writeMessage(kind uint32, body func()) {
+	c.nextID++
+	c.writeU32(c.nextID)
+	c.writeU32(kind)
+	body()
+	_, _ = c.w.Write(successBytes)
+}
+
+func (c *processConn) writeSuccess(id uint32) {
+	c.writeMessage(kindSuccess, func() { c.writeU32(id) })
+}
+
+func (c *processConn) writeData(id uint32, data []byte) {
+	c.writeMessage(kindDataResponse, func() {
+		c.writeU32(id)
+		c.writeBytes(data)
+	})
+}
+
+func (c *processConn) writeJSON(id uint32, v any) {
+	data, err := dprint.MarshalCanonical(v)
+	if err != nil {
+		c.writeError(id, err)
+		return
+	}
+	c.writeData(id, data)
+}
+
+func (c *processConn) writeError(id uint32, err error) {
+	c.writeMessage(kindErrorResponse, func() {
+		c.writeU32(id)
+		c.writeBytes([]byte(err.Error()))
+	})
+}
+
+func (c *processConn) writeFormatResponse(id uint32, formatted []byte, changed bool) {
+	c.writeMessage(kindFormatResponse, func() {
+		c.writeU32(id)
+		if !changed {
+			c.writeU32(0)
+			return
+		}
+		c.writeU32(1)
+		c.writeBytes(formatted)
+	})
+}
+
+// orEmptyObject substitutes an empty JSON object for a missing section.
+func orEmptyObject(raw []byte) []byte {
+	if len(raw) == 0 {
+		return []byte("{}")
+	}
+	return raw
+}
@@ -0,0 +1,171 @@
+//go:build !tinygo
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// hostMessages encodes messages the way the dprint CLI sends them to a
+// process plugin.
+type hostMessages struct {
+	bytes.Buffer
+}
+
+func (m *hostMessages) u32(v uint32) *hostMessages {
+	_ = binary.Write(&m.Buffer, binary.BigEndian, v)
+	return m
+}
+
+func (m *hostMessages) data(b string) *hostMessages {
+	m.u32(uint32(len(b)))
+	m.WriteString(b)
+	return m
+}
+
+func (m *hostMessages) end() *hostMessages {
+	m.Write(successBytes)
+	return m
+}
+
+// pluginResponse is one decoded response of the plugin.
+type pluginResponse struct {
+	kind uint32
+	id   uint32
+	body []byte
+}
+
+// readResponses decodes responses written by Serve, using wantKinds to
+// know which body layout each response has.
+func readResponses(t *testing.T, r io.Reader, wantKinds ...uint32) []pluginResponse {
+	t.Helper()
+	u32 := func() uint32 {
+		var v uint32
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			t.Fatalf("reading response: %v", err)
+		}
+		return v
+	}
+	data := func() []byte {
+		b := make([]byte, u32())
+		if _, err := io.ReadFull(r, b); err != nil {
+			t.Fatalf("reading response body: %v", err)
+		}
+		return b
+	}
+
+	if version := []uint32{u32(), u32()}; version[1] != processSchemaVersion {
+		t.Fatalf("handshake = %v; want schema %d", version, processSchemaVersion)
+	}
+	responses := make([]pluginResponse, 0, len(wantKinds))
+	for _, want := range wantKinds {
+		u32()
+		resp := pluginResponse{kind: u32()}
+		if resp.kind != want {
+			t.Fatalf("response kind = %d; want %d", resp.kind, want)
+		}
+		resp.id = u32()
+		switch resp.kind {
+		case kindDataResponse, kindErrorResponse:
+			resp.body = data()
+		case kindFormatResponse:
+			if u32() == 1 {
+				resp.body = data()
+			}
+		}
+		if end := u32(); end != binary.BigEndian.Uint32(successBytes) {
+			t.Fatalf("response not terminated by success bytes: %x", end)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// TestServe_Speaks_Process_Plugin_Schema drives a process plugin session
+// from handshake to close.
+func TestServe_Speaks_Process_Plugin_Schema(t *testing.T) {
+	registerTestPlugin(t)
+
+	var host hostMessages
+	host.u32(0)
+	host.u32(1).u32(kindGetPluginInfo).end()
+	host.u32(2).u32(kindRegisterConfig).u32(7).data(`{"newLineKind":"lf"}`).data(`{"suffix":"?"}`).end()
+	host.u32(3).u32(kindFormat).data("a.txt").u32(0).u32(4).u32(7).data("").data("text").end()
+	host.u32(4).u32(kindFormat).data("a.txt").u32(0).u32(5).u32(7).data("").data("text?").end()
+	host.u32(5).u32(kindFormat).data("a.txt").u32(0).u32(3).u32(7).data("").data("bad").end()
+	host.u32(6).u32(kindGetConfigDiagnostics).u32(7).end()
+	host.u32(7).u32(kindClose).end()
+
+	var out bytes.Buffer
+	if err := Serve(&host, &out, io.Discard, 0); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	responses := readResponses(t, &out,
+		kindDataResponse, kindSuccess, kindFormatResponse, kindFormatResponse,
+		kindErrorResponse, kindDataResponse, kindSuccess)
+	if !bytes.Contains(responses[0].body, []byte(`"name":"dprint-plugin-test"`)) {
+		t.Fatalf("plugin info = %s", responses[0].body)
+	}
+	if got := string(responses[2].body); got != "text?" {
+		t.Fatalf("formatted text = %q; want %q", got, "text?")
+	}
+	if responses[3].body != nil {
+		t.Fatalf("unchanged file returned text %q", responses[3].body)
+	}
+	if got := string(responses[4].body); got != "bad input" {
+		t.Fatalf("error text = %q; want %q", got, "bad input")
+	}
+	if got := string(responses[5].body); got != `[]` {
+		t.Fatalf("diagnostics = %s", got)
+	}
+	for i, resp := range responses {
+		if want := uint32(i + 1); resp.id != want {
+			t.Fatalf("response %d answers message %d; want %d", i, resp.id, want)
+		}
+	}
+}
+
+// TestWatchParent_Reports_Exited_Process verifies that watchParent closes
+// its channel once the watched process has exited, and leaves it open
+// while the process runs.
+func TestWatchParent_Reports_Exited_Process(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	child := exec.Command(os.Args[0], "-test.run=^$")
+	if err := child.Run(); err != nil {
+		t.Fatalf("run child: %v", err)
+	}
+	select {
+	case <-watchParent(ctx, child.Process.Pid, time.Millisecond):
+	case <-time.After(5 * time.Second):
+		t.Fatalf("watchParent did not notice process %d exited", child.Process.Pid)
+	}
+
+	select {
+	case <-watchParent(ctx, os.Getpid(), time.Millisecond):
+		t.Fatalf("watchParent reported the running test process as exited")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestParentPID_Reads_The_Flag_Value verifies that the process ID after
+// --parent-pid is read and that a missing or malformed one is rejected.
+func TestParentPID_Reads_The_Flag_Value(t *testing.T) {
+	if pid, err := parentPID([]string{"--parent-pid", "42"}); err != nil || pid != 42 {
+		t.Fatalf("parentPID = %d, %v; want 42", pid, err)
+	}
+	for _, args := range [][]string{{"--parent-pid"}, {"--parent-pid", "dprint"}, {"--parent-pid", "0"}} {
+		if _, err := parentPID(args); err == nil {
+			t.Fatalf("parentPID(%q) accepted a bad process ID", args)
+		}
+	}
+}
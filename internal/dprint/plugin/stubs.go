@@ -1,6 +1,6 @@
 //go:build tinygo
 
-package main
+package plugin
 
 // This file defines the host import functions provided by the dprint CLI
 // for WASM plugins to communicate back to the host environment.
@@ -0,0 +1,83 @@
+package dprint
+
+import "fmt"
+
+// processPluginSchemaVersion is the version of the plugin.json format
+// through which dprint loads process plugins.
+const processPluginSchemaVersion = 2
+
+// ReleaseDownloadURLPrefix is followed by a release tag, such as v1.0.0,
+// and the name of one of its assets.
+const ReleaseDownloadURLPrefix = "https://github.com/mridang/dprint-goat/releases/download/"
+
+// ProcessPlatform is a platform the process plugins are built for.
+type ProcessPlatform struct {
+	// Name names the platform's archives, such as "linux-x86_64".
+	Name string
+
+	// GOOS and GOARCH are the target the plugins are built with.
+	GOOS   string
+	GOARCH string
+
+	// Keys are the platforms the archives are listed under in a
+	// plugin.json. The plugins are built without cgo, so the Linux
+	// binaries run on musl as they are.
+	Keys []string
+}
+
+// ProcessPlatforms returns the platforms the process plugins are released
+// for.
+func ProcessPlatforms() []ProcessPlatform {
+	return []ProcessPlatform{
+		{Name: "darwin-x86_64", GOOS: "darwin", GOARCH: "amd64", Keys: []string{"darwin-x86_64"}},
+		{Name: "darwin-aarch64", GOOS: "darwin", GOARCH: "arm64", Keys: []string{"darwin-aarch64"}},
+		{Name: "linux-x86_64", GOOS: "linux", GOARCH: "amd64", Keys: []string{"linux-x86_64", "linux-x86_64-musl"}},
+		{Name: "linux-aarch64", GOOS: "linux", GOARCH: "arm64", Keys: []string{"linux-aarch64", "linux-aarch64-musl"}},
+		{Name: "linux-riscv64", GOOS: "linux", GOARCH: "riscv64", Keys: []string{"linux-riscv64"}},
+		{Name: "windows-x86_64", GOOS: "windows", GOARCH: "amd64", Keys: []string{"windows-x86_64"}},
+	}
+}
+
+// ProcessArchive returns the name of the release asset holding the
+// plugin's process build for platform, such as gofmt-linux-x86_64.zip.
+func (m Manifest) ProcessArchive(platform ProcessPlatform) string {
+	return m.Command + "-" + platform.Name + ".zip"
+}
+
+// ProcessExecutable returns the name of the executable in the plugin's
+// archive for platform. dprint runs the file named like the plugin, with
+// .exe on Windows.
+func (m Manifest) ProcessExecutable(platform ProcessPlatform) string {
+	if platform.GOOS == "windows" {
+		return m.Name + ".exe"
+	}
+	return m.Name
+}
+
+// ProcessPluginFile returns the plugin.json through which dprint loads the
+// plugin's process builds of the release version. It lists the archive of
+// every platform under the release's download URL with its SHA-256
+// checksum, which checksums holds by archive name.
+func (m Manifest) ProcessPluginFile(version string, checksums map[string]string) ([]byte, error) {
+	type archive struct {
+		Reference string `json:"reference"`
+		Checksum  string `json:"checksum"`
+	}
+	file := map[string]any{
+		"schemaVersion": processPluginSchemaVersion,
+		"kind":          "process",
+		"name":          m.Name,
+		"version":       version,
+	}
+	for _, platform := range ProcessPlatforms() {
+		name := m.ProcessArchive(platform)
+		checksum, ok := checksums[name]
+		if !ok {
+			return nil, fmt.Errorf("no checksum for %s", name)
+		}
+		for _, key := range platform.Keys {
+			file[key] = archive{Reference: ReleaseDownloadURLPrefix + "v" + version + "/" + name, Checksum: checksum}
+		}
+	}
+	return MarshalCanonical(file)
+}
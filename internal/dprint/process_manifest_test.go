@@ -0,0 +1,61 @@
+package dprint
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestManifest_ProcessPluginFile_Lists_Every_Platform checks the
+// plugin.json derived from a manifest: every platform key references its
+// archive in the release with its checksum, and a missing checksum is an
+// error.
+func TestManifest_ProcessPluginFile_Lists_Every_Platform(t *testing.T) {
+	checksums := map[string]string{}
+	for _, platform := range ProcessPlatforms() {
+		checksums[GofmtManifest.ProcessArchive(platform)] = "sum-" + platform.Name
+	}
+	data, err := GofmtManifest.ProcessPluginFile("1.2.3", checksums)
+	if err != nil {
+		t.Fatalf("ProcessPluginFile: %v", err)
+	}
+	var file map[string]any
+	if err = json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	if file["schemaVersion"] != float64(2) || file["kind"] != "process" ||
+		file["name"] != "dprint-plugin-gofmt" || file["version"] != "1.2.3" {
+		t.Fatalf("ProcessPluginFile = %s", data)
+	}
+	want := map[string]string{
+		"linux-x86_64":      "linux-x86_64",
+		"linux-x86_64-musl": "linux-x86_64",
+		"darwin-aarch64":    "darwin-aarch64",
+		"windows-x86_64":    "windows-x86_64",
+	}
+	for key, platform := range want {
+		archive, _ := file[key].(map[string]any)
+		reference := ReleaseDownloadURLPrefix + "v1.2.3/gofmt-" + platform + ".zip"
+		if archive["reference"] != reference || archive["checksum"] != "sum-"+platform {
+			t.Fatalf("ProcessPluginFile[%s] = %v; want %s with sum-%s", key, file[key], reference, platform)
+		}
+	}
+
+	delete(checksums, "gofmt-linux-riscv64.zip")
+	if _, err = GofmtManifest.ProcessPluginFile("1.2.3", checksums); err == nil {
+		t.Fatal("ProcessPluginFile accepted a missing checksum")
+	}
+}
+
+// TestManifest_ProcessExecutable_Is_Named_Like_The_Plugin checks the names
+// of the executables in the process plugin archives.
+func TestManifest_ProcessExecutable_Is_Named_Like_The_Plugin(t *testing.T) {
+	for _, platform := range ProcessPlatforms() {
+		want := "dprint-plugin-gohcl"
+		if platform.GOOS == "windows" {
+			want += ".exe"
+		}
+		if got := TffmtManifest.ProcessExecutable(platform); got != want {
+			t.Fatalf("ProcessExecutable(%s) = %q; want %q", platform.Name, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package dprint
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the generated schemas.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// ConfigSchema builds the JSON Schema of a plugin's configuration block
+// from the given configs, each a struct or pointer to a struct holding the
+// defaults. Property types, including the type of array items, come from
+// the Go field types; the description, enum, minimum and deprecated struct
+// tags add documentation and constraints. Unknown properties are rejected,
+// matching the diagnostics DecodeConfig reports.
+func ConfigSchema(title string, configs ...any) map[string]any {
+	properties := map[string]any{}
+	for _, cfg := range configs {
+		value := reflect.Indirect(reflect.ValueOf(cfg))
+		for name, index := range jsonFields(value.Type()) {
+			properties[name] = propertySchema(value.Type().Field(index), value.Field(index))
+		}
+	}
+	return map[string]any{
+		"$schema":              JSONSchemaDraft,
+		"title":                title,
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// propertySchema describes a single configuration property.
+func propertySchema(field reflect.StructField, value reflect.Value) map[string]any {
+	schema := map[string]any{"type": jsonTypeName(field.Type)}
+	if field.Type.Kind() == reflect.Slice {
+		schema["items"] = map[string]any{"type": jsonTypeName(field.Type.Elem())}
+	}
+	if description := field.Tag.Get("description"); description != "" {
+		schema["description"] = description
+	}
+	if enum := field.Tag.Get("enum"); enum != "" {
+		schema["enum"] = strings.Split(enum, ",")
+	}
+	if minimum, err := strconv.Atoi(field.Tag.Get("minimum")); err == nil {
+		schema["minimum"] = minimum
+	}
+	if _, deprecated := field.Tag.Lookup("deprecated"); deprecated {
+		schema["deprecated"] = true
+	}
+	switch value.Kind() { //nolint:exhaustive // only nillable kinds matter
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if value.IsNil() {
+			return schema
+		}
+	}
+	schema["default"] = value.Interface()
+	return schema
+}
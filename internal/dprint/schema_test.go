@@ -0,0 +1,38 @@
+package dprint
+
+import "testing"
+
+// schemaConfig exercises every tag ConfigSchema understands.
+type schemaConfig struct {
+	Width int    `json:"width" description:"Line width." minimum:"1"`
+	Mode  string `json:"mode"  enum:"a,b"`
+	Flag  *bool  `json:"flag,omitempty"`
+	Old   bool   `json:"old"   deprecated:"use width"`
+}
+
+// schemaExtra stands in for options shared by every plugin.
+type schemaExtra struct {
+	Kind string `json:"kind" description:"Kind."`
+}
+
+// TestConfigSchema_Describes_Properties checks the generated schema for a
+// config with descriptions, enums, minimums, a deprecated property and an
+// unset optional field.
+func TestConfigSchema_Describes_Properties(t *testing.T) {
+	schema := ConfigSchema("test", schemaConfig{Width: 80, Mode: "a"}, schemaExtra{Kind: "x"})
+
+	got, err := MarshalCanonical(schema)
+	if err != nil {
+		t.Fatalf("MarshalCanonical: %v", err)
+	}
+	want := `{"$schema":"http://json-schema.org/draft-07/schema#","additionalProperties":false,` +
+		`"properties":{"flag":{"type":"boolean"},` +
+		`"kind":{"default":"x","description":"Kind.","type":"string"},` +
+		`"mode":{"default":"a","enum":["a","b"],"type":"string"},` +
+		`"old":{"default":false,"deprecated":true,"type":"boolean"},` +
+		`"width":{"default":80,"description":"Line width.","minimum":1,"type":"integer"}},` +
+		`"title":"test","type":"object"}`
+	if string(got) != want {
+		t.Fatalf("ConfigSchema =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package dprint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// schemaNode is the part of a JSON Schema built by ConfigSchema that
+// ValidateSchema checks.
+type schemaNode struct {
+	Type                 string                 `json:"type"`
+	Enum                 []string               `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Items                *schemaNode            `json:"items"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+}
+
+// ValidateSchema checks the plugin section of a register_config payload
+// against schema, the JSON of a schema built by ConfigSchema, and reports
+// violations like DecodeConfig does. Values outside an enum or below a
+// minimum are returned as diags; unknown properties and values of the wrong
+// type, including array items, are returned as structural diagnostics.
+// Null values count as unset. Malformed payloads and schemas are left to
+// DecodeConfig and yield no diagnostics.
+func ValidateSchema(schema, raw []byte) (diags, structural []ConfigDiagnostic) {
+	diags, structural = []ConfigDiagnostic{}, []ConfigDiagnostic{}
+	var root schemaNode
+	if err := json.Unmarshal(schema, &root); err != nil || len(raw) == 0 {
+		return diags, structural
+	}
+	section, err := pluginSection(raw)
+	if err != nil {
+		return diags, structural
+	}
+	section, written, _ := resolveAliases(section)
+
+	for key, data := range section {
+		property := written[key]
+		node, ok := root.Properties[key]
+		if !ok {
+			if root.AdditionalProperties != nil && !*root.AdditionalProperties {
+				structural = append(structural, ConfigDiagnostic{PropertyName: property, Message: "unknown property"})
+			}
+			continue
+		}
+		d, s := node.check(property, data)
+		diags, structural = append(diags, d...), append(structural, s...)
+	}
+	SortDiagnostics(diags)
+	SortDiagnostics(structural)
+	return diags, structural
+}
+
+// check validates one value and, for arrays, each of its items.
+func (n *schemaNode) check(property string, data json.RawMessage) (diags, structural []ConfigDiagnostic) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil || value == nil {
+		return nil, nil
+	}
+
+	if got, ok := n.accepts(value); !ok {
+		message := fmt.Sprintf("expected %s but got %s", n.Type, got)
+		return nil, []ConfigDiagnostic{{PropertyName: property, Message: message}}
+	}
+	if s, isString := value.(string); isString && len(n.Enum) > 0 && !slices.Contains(n.Enum, s) {
+		message := "must be one of " + strings.Join(n.Enum, ", ")
+		diags = append(diags, ConfigDiagnostic{PropertyName: property, Message: message})
+	}
+	if number, isNumber := value.(json.Number); isNumber && n.Minimum != nil {
+		if f, err := number.Float64(); err == nil && f < *n.Minimum {
+			message := fmt.Sprintf("must be at least %v", *n.Minimum)
+			diags = append(diags, ConfigDiagnostic{PropertyName: property, Message: message})
+		}
+	}
+	if n.Items != nil {
+		var items []json.RawMessage
+		_ = json.Unmarshal(data, &items)
+		for i, item := range items {
+			d, s := n.Items.check(fmt.Sprintf("%s[%d]", property, i), item)
+			diags, structural = append(diags, d...), append(structural, s...)
+		}
+	}
+	return diags, structural
+}
+
+// accepts reports whether value has the node's type and, if not, names the
+// type it has the way encoding/json does.
+func (n *schemaNode) accepts(value any) (string, bool) {
+	switch v := value.(type) {
+	case bool:
+		return "bool", n.Type == "boolean"
+	case string:
+		return "string", n.Type == "string"
+	case json.Number:
+		if _, err := v.Int64(); err != nil && n.Type == "integer" {
+			return "number " + v.String(), false
+		}
+		return "number", n.Type == "integer" || n.Type == "number"
+	case []any:
+		return "array", n.Type == "array"
+	default:
+		return "object", n.Type == "object"
+	}
+}
+
+// MergeDiagnostics returns preferred followed by the diagnostics of others
+// about properties preferred says nothing about, so that a problem found by
+// two checks is reported once. A diagnostic about an array item or a nested
+// property covers its parent property and the other way around.
+func MergeDiagnostics(preferred, others []ConfigDiagnostic) []ConfigDiagnostic {
+	merged := slices.Clone(preferred)
+	for _, other := range others {
+		covered := slices.ContainsFunc(preferred, func(p ConfigDiagnostic) bool {
+			return sameProperty(p.PropertyName, other.PropertyName)
+		})
+		if !covered {
+			merged = append(merged, other)
+		}
+	}
+	SortDiagnostics(merged)
+	return merged
+}
+
+// sameProperty reports whether a and b name the same property or one names
+// an item or member of the other.
+func sameProperty(a, b string) bool {
+	within := func(inner, outer string) bool {
+		return strings.HasPrefix(inner, outer+"[") || strings.HasPrefix(inner, outer+".")
+	}
+	return a == b || (a != "" && within(b, a)) || (b != "" && within(a, b))
+}
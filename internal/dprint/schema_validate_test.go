@@ -0,0 +1,94 @@
+package dprint
+
+import (
+	"reflect"
+	"testing"
+)
+
+// validatedConfig is a plugin configuration used to exercise
+// ValidateSchema.
+type validatedConfig struct {
+	Width int      `json:"width" minimum:"1"`
+	Mode  string   `json:"mode"  enum:"a,b"`
+	Tabs  bool     `json:"useTabs"`
+	Names []string `json:"names"`
+}
+
+// TestValidateSchema_Reports_Violations verifies every kind of violation
+// against a schema built by ConfigSchema.
+func TestValidateSchema_Reports_Violations(t *testing.T) {
+	schema, err := MarshalCanonical(ConfigSchema("test", validatedConfig{Width: 80, Mode: "a"}))
+	if err != nil {
+		t.Fatalf("MarshalCanonical: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		raw        string
+		diags      []ConfigDiagnostic
+		structural []ConfigDiagnostic
+	}{
+		{
+			name:       "valid",
+			raw:        `{"plugin":{"width":100,"mode":"b","use-tabs":true,"names":["x"],"mode-x":null}}`,
+			diags:      []ConfigDiagnostic{},
+			structural: []ConfigDiagnostic{{PropertyName: "mode-x", Message: "unknown property"}},
+		},
+		{
+			name: "constraints",
+			raw:  `{"plugin":{"width":0,"mode":"c"}}`,
+			diags: []ConfigDiagnostic{
+				{PropertyName: "mode", Message: "must be one of a, b"},
+				{PropertyName: "width", Message: "must be at least 1"},
+			},
+			structural: []ConfigDiagnostic{},
+		},
+		{
+			name:  "types",
+			raw:   `{"plugin":{"width":1.5,"useTabs":"yes","names":["x",2,{}],"mode":["a"]}}`,
+			diags: []ConfigDiagnostic{},
+			structural: []ConfigDiagnostic{
+				{PropertyName: "mode", Message: "expected string but got array"},
+				{PropertyName: "names[1]", Message: "expected string but got number"},
+				{PropertyName: "names[2]", Message: "expected string but got object"},
+				{PropertyName: "useTabs", Message: "expected boolean but got string"},
+				{PropertyName: "width", Message: "expected integer but got number 1.5"},
+			},
+		},
+		{
+			name:       "malformed",
+			raw:        `{`,
+			diags:      []ConfigDiagnostic{},
+			structural: []ConfigDiagnostic{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags, structural := ValidateSchema(schema, []byte(tt.raw))
+			if !reflect.DeepEqual(diags, tt.diags) {
+				t.Fatalf("diagnostics = %+v; want %+v", diags, tt.diags)
+			}
+			if !reflect.DeepEqual(structural, tt.structural) {
+				t.Fatalf("structural diagnostics = %+v; want %+v", structural, tt.structural)
+			}
+		})
+	}
+}
+
+// TestMergeDiagnostics_Reports_Each_Property_Once verifies that a problem
+// found by two checks, possibly at different depths, is reported once.
+func TestMergeDiagnostics_Reports_Each_Property_Once(t *testing.T) {
+	preferred := []ConfigDiagnostic{{PropertyName: "names[1]", Message: "expected string but got number"}}
+	others := []ConfigDiagnostic{
+		{PropertyName: "names", Message: "expected array but got number"},
+		{PropertyName: "namesake", Message: "unknown property"},
+	}
+	want := []ConfigDiagnostic{
+		{PropertyName: "names[1]", Message: "expected string but got number"},
+		{PropertyName: "namesake", Message: "unknown property"},
+	}
+	if got := MergeDiagnostics(preferred, others); !reflect.DeepEqual(got, want) {
+		t.Fatalf("MergeDiagnostics = %+v; want %+v", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package dprint
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mridang/dprint-plugin-go/pkg/formatters"
+)
+
+// Values of SharedConfig.OversizedFiles.
+const (
+	OversizedFilesError = "error"
+	OversizedFilesSkip  = "skip"
+)
+
+// SharedConfig holds the options every plugin in this repository accepts
+// next to its own. The runtime applies them around the formatter, so the
+// formatters never see them.
+type SharedConfig struct {
+	// NewLineKind selects the line endings of the formatted output: lf,
+	// crlf, system, or auto to keep the ending the file already uses.
+	NewLineKind string `json:"newLineKind" description:"Line endings of the formatted output." enum:"auto,lf,crlf,system"`
+
+	// InsertFinalNewline, when set, makes the output end in exactly one
+	// newline (true) or in none (false). When unset the formatter's output
+	// is kept as is.
+	InsertFinalNewline *bool `json:"insertFinalNewline,omitempty" description:"End files in exactly one newline (true) or none (false)."`
+
+	// Debug makes the runtime and the formatters record trace messages
+	// about configuration resolution, timings and formatting decisions.
+	Debug bool `json:"debug" description:"Record trace messages for dprint fmt --log-level=debug."`
+
+	// Strict reports unknown properties and values of the wrong type as
+	// configuration diagnostics instead of skipping them.
+	Strict bool `json:"strict" description:"Report unknown properties and values of the wrong type."`
+
+	// MaxFileSize is the largest file, in bytes, that is formatted. Zero
+	// means no limit.
+	MaxFileSize int `json:"maxFileSize" description:"Largest file in bytes to format; 0 means no limit." minimum:"0"`
+
+	// OversizedFiles decides what happens to files over MaxFileSize: error
+	// reports them, skip leaves them unformatted.
+	OversizedFiles string `json:"oversizedFiles" description:"What to do with files over maxFileSize." enum:"error,skip"`
+
+	// FileExtensions, when set, replaces the extensions of the files the
+	// plugin formats.
+	FileExtensions []string `json:"fileExtensions,omitempty" description:"Extensions, without the dot, of the files to format; replaces the plugin's list."`
+
+	// FileNames, when set, replaces the names of the files the plugin
+	// formats regardless of extension.
+	FileNames []string `json:"fileNames,omitempty" description:"Names of files to format regardless of extension; replaces the plugin's list."`
+
+	// ExtraFileNames adds names of files the plugin formats regardless of
+	// extension to its list, or to FileNames when that is set.
+	ExtraFileNames []string `json:"extraFileNames,omitempty" description:"Names of files to format regardless of extension, added to the plugin's list."`
+
+	// Exclude lists glob patterns of files that are left unformatted. See
+	// Excludes for how they are matched against the file path.
+	Exclude []string `json:"exclude,omitempty" description:"Glob patterns of files to leave unformatted; a pattern ending in / names a directory."`
+
+	// Overrides lists options that apply only to files matching a pattern,
+	// applied in order on top of the rest of the configuration.
+	Overrides []Override `json:"overrides,omitempty" description:"Options for files matching glob patterns."`
+}
+
+// DefaultSharedConfig returns the shared options inherited from dprint's
+// global configuration, falling back to LF line endings.
+func DefaultSharedConfig(global GlobalConfig) SharedConfig {
+	cfg := SharedConfig{NewLineKind: NewLineKindLF, OversizedFiles: OversizedFilesError}
+	if global.NewLineKind != "" {
+		cfg.NewLineKind = global.NewLineKind
+	}
+	return cfg
+}
+
+// Validate reports shared options that have invalid values.
+func (c SharedConfig) Validate() error {
+	var errs []error
+	kinds := []string{NewLineKindAuto, NewLineKindLF, NewLineKindCRLF, NewLineKindSystem}
+	if !slices.Contains(kinds, c.NewLineKind) {
+		errs = append(errs, &formatters.ConfigError{
+			Property: "newLineKind",
+			Message:  "must be one of auto, lf, crlf, system",
+		})
+	}
+	if c.MaxFileSize < 0 {
+		errs = append(errs, &formatters.ConfigError{Property: "maxFileSize", Message: "must not be negative"})
+	}
+	if c.OversizedFiles != OversizedFilesError && c.OversizedFiles != OversizedFilesSkip {
+		errs = append(errs, &formatters.ConfigError{Property: "oversizedFiles", Message: "must be one of error, skip"})
+	}
+	for _, problem := range extensionProblems(c.FileExtensions) {
+		errs = append(errs, &formatters.ConfigError{Property: "fileExtensions", Message: problem})
+	}
+	for _, problem := range fileNameProblems(c.FileNames) {
+		errs = append(errs, &formatters.ConfigError{Property: "fileNames", Message: problem})
+	}
+	for _, problem := range fileNameProblems(c.ExtraFileNames) {
+		errs = append(errs, &formatters.ConfigError{Property: "extraFileNames", Message: problem})
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil || strings.Trim(pattern, "/") == "" {
+			message := fmt.Sprintf("invalid pattern %q", pattern)
+			errs = append(errs, &formatters.ConfigError{Property: "exclude", Message: message})
+		}
+	}
+	for i, o := range c.Overrides {
+		errs = append(errs, o.Validate(i))
+	}
+	return errors.Join(errs...)
+}
+
+// CheckSize decides whether a file of size bytes may be formatted. It
+// reports skip for oversized files when they are to be left alone and a
+// FileTooLargeError when they are to be reported.
+func (c SharedConfig) CheckSize(path string, size int) (bool, error) {
+	if c.MaxFileSize == 0 || size <= c.MaxFileSize {
+		return false, nil
+	}
+	if c.OversizedFiles == OversizedFilesSkip {
+		return true, nil
+	}
+	return false, &FileTooLargeError{Path: path, Size: size, Limit: c.MaxFileSize}
+}
+
+// Excludes reports whether the file at filePath matches one of the Exclude
+// patterns. A pattern ending in / names a directory and matches the files
+// below any directory it matches, such as vendor/ or internal/testdata/.
+// Other patterns are matched like override patterns, against the file name
+// or, when they contain a /, the trailing directories too.
+func (c SharedConfig) Excludes(filePath string) bool {
+	segments := pathSegments(filePath)
+	return slices.ContainsFunc(c.Exclude, func(pattern string) bool {
+		dir, isDir := strings.CutSuffix(pattern, "/")
+		if !isDir {
+			return matchTrailing(pattern, segments)
+		}
+		for end := len(segments) - 1; end > 0; end-- {
+			if matchTrailing(dir, segments[:end]) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Apply runs format on src with the shared options in effect. Line endings
+// are normalized to LF before formatting and converted to the configured
+// kind afterwards, after the final newline has been enforced.
+func (c SharedConfig) Apply(src []byte, format func([]byte) ([]byte, error)) ([]byte, error) {
+	formatted, err := format(NormalizeNewlines(src))
+	if err != nil {
+		return nil, err
+	}
+	if c.InsertFinalNewline != nil {
+		formatted = ApplyFinalNewline(formatted, *c.InsertFinalNewline)
+	}
+	return ApplyNewlineKind(formatted, src, c.NewLineKind), nil
+}
+
+// FileTooLargeError reports a file over the configured maxFileSize.
+type FileTooLargeError struct {
+	Path  string
+	Size  int
+	Limit int
+}
+
+// Error names the file, its size and the limit, and suggests how to deal
+// with it.
+func (e *FileTooLargeError) Error() string {
+	return fmt.Sprintf(
+		"%s is %d bytes, over the maxFileSize of %d bytes; exclude it in the dprint configuration, "+
+			"raise maxFileSize or set oversizedFiles to skip",
+		e.Path, e.Size, e.Limit,
+	)
+}
@@ -0,0 +1,24 @@
+package dprint
+
+import "testing"
+
+// TestSharedConfig_Excludes_Matching_Paths checks directory patterns
+// against every directory of the path and other patterns against its
+// trailing segments.
+func TestSharedConfig_Excludes_Matching_Paths(t *testing.T) {
+	cfg := SharedConfig{Exclude: []string{"vendor/", "internal/testdata/", "zz_generated*"}}
+	tests := map[string]bool{
+		"/repo/vendor/x/y.go":                true,
+		"vendor/y.go":                        true,
+		"/repo/vendor.go":                    false,
+		"/repo/internal/testdata/a/b.go":     true,
+		"/repo/testdata/b.go":                false,
+		"/repo/api/zz_generated.deepcopy.go": true,
+		"/repo/api/types.go":                 false,
+	}
+	for path, want := range tests {
+		if got := cfg.Excludes(path); got != want {
+			t.Fatalf("Excludes(%q) = %v; want %v", path, got, want)
+		}
+	}
+}
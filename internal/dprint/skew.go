@@ -0,0 +1,54 @@
+package dprint
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SchemaMismatchError reports a host call pattern that does not fit the
+// dprint schema version this plugin was built for. It is surfaced as error
+// text instead of formatting with defaults or returning empty results.
+type SchemaMismatchError struct {
+	Detail string
+}
+
+// Error renders the mismatch with the schema version the plugin expects.
+func (e *SchemaMismatchError) Error() string {
+	return fmt.Sprintf("plugin built for dprint schema v%d; %s", PluginSchemaVersion, e.Detail)
+}
+
+// UnregisteredConfigError reports that the host invoked op for a config id
+// that was never passed to register_config, which usually means the host
+// speaks a different plugin schema.
+func UnregisteredConfigError(op string, configID uint32) error {
+	return &SchemaMismatchError{
+		Detail: fmt.Sprintf("host called %s for config %d without register_config", op, configID),
+	}
+}
+
+// SupportedSchemaVersions returns the dprint WASM plugin schema versions
+// the runtime has an ABI adapter for, oldest first.
+func SupportedSchemaVersions() []uint32 {
+	return []uint32{PluginSchemaVersion}
+}
+
+// NegotiateSchemaVersion picks the newest schema version supported by both
+// the plugin and a host that supports hostVersions.
+func NegotiateSchemaVersion(hostVersions ...uint32) (uint32, error) {
+	supported := SupportedSchemaVersions()
+	for i := len(supported) - 1; i >= 0; i-- {
+		if slices.Contains(hostVersions, supported[i]) {
+			return supported[i], nil
+		}
+	}
+	if len(hostVersions) == 0 {
+		return 0, &SchemaMismatchError{Detail: "host did not offer a schema version"}
+	}
+	return 0, RequestedSchemaError(slices.Max(hostVersions))
+}
+
+// RequestedSchemaError reports that the host asked for a schema version
+// the plugin has no ABI adapter for.
+func RequestedSchemaError(version uint32) error {
+	return &SchemaMismatchError{Detail: fmt.Sprintf("host requested v%d", version)}
+}
@@ -0,0 +1,40 @@
+package dprint
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestUnregisteredConfigError_Names_Schema verifies that the error text
+// names the schema version the plugin was built for along with the call
+// that did not fit it.
+func TestUnregisteredConfigError_Names_Schema(t *testing.T) {
+	err := UnregisteredConfigError("format", 7)
+
+	want := "plugin built for dprint schema v4; host called format for config 7 without register_config"
+	if got := err.Error(); got != want {
+		t.Fatalf("error text = %q; want %q", got, want)
+	}
+
+	var mismatch *SchemaMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("error %T is not a *SchemaMismatchError", err)
+	}
+}
+
+// TestNegotiateSchemaVersion_Picks_Newest_Shared_Version covers hosts that
+// speak the plugin's schema, a newer one as well, or only a newer one.
+func TestNegotiateSchemaVersion_Picks_Newest_Shared_Version(t *testing.T) {
+	if got, err := NegotiateSchemaVersion(4); got != 4 || err != nil {
+		t.Fatalf("NegotiateSchemaVersion(4) = %d, %v; want 4", got, err)
+	}
+	if got, err := NegotiateSchemaVersion(4, 5); got != 4 || err != nil {
+		t.Fatalf("NegotiateSchemaVersion(4, 5) = %d, %v; want 4", got, err)
+	}
+
+	_, err := NegotiateSchemaVersion(5)
+	want := "plugin built for dprint schema v4; host requested v5"
+	if err == nil || err.Error() != want {
+		t.Fatalf("NegotiateSchemaVersion(5) error = %v; want %q", err, want)
+	}
+}
@@ -0,0 +1,43 @@
+package dprint
+
+import "time"
+
+// Stats counts the files a plugin instance formatted during a dprint run.
+// It is returned by the get_format_stats export so that CI jobs can report
+// formatter health without parsing logs.
+type Stats struct {
+	// Changed counts files whose formatted text differs from the input.
+	Changed int `json:"changed"`
+
+	// Unchanged counts files that were already formatted, skipped or left
+	// alone by an ignore comment.
+	Unchanged int `json:"unchanged"`
+
+	// Errors counts files that could not be formatted.
+	Errors int `json:"errors"`
+
+	// Cancelled counts requests the host cancelled.
+	Cancelled int `json:"cancelled"`
+
+	// Bytes is the total size of the files received.
+	Bytes int64 `json:"bytes"`
+
+	// FormatMicros is the total time spent formatting, in microseconds.
+	FormatMicros int64 `json:"formatMicros"`
+}
+
+// Record counts one format request of size bytes that took elapsed.
+func (s *Stats) Record(size int, changed, cancelled bool, err error, elapsed time.Duration) {
+	s.Bytes += int64(size)
+	s.FormatMicros += elapsed.Microseconds()
+	switch {
+	case cancelled:
+		s.Cancelled++
+	case err != nil:
+		s.Errors++
+	case changed:
+		s.Changed++
+	default:
+		s.Unchanged++
+	}
+}
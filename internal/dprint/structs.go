@@ -18,3 +18,11 @@ type FileMatchingInfo struct {
 	FileExtensions []string `json:"fileExtensions"`
 	FileNames      []string `json:"fileNames"`
 }
+
+// ConfigDiagnostic represents a single entry of the JSON array returned by
+// get_config_diagnostics.
+// See: https://dprint.dev/plugins/wasm/#get_config_diagnostics
+type ConfigDiagnostic struct {
+	PropertyName string `json:"propertyName"`
+	Message      string `json:"message"`
+}
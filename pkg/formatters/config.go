@@ -0,0 +1,20 @@
+package formatters
+
+// ConfigError describes an invalid configuration value. Validate methods
+// on the config types return these, joined with errors.Join when there is
+// more than one.
+type ConfigError struct {
+	Property string
+	Message  string
+}
+
+// Error renders the property name followed by the problem.
+func (e *ConfigError) Error() string {
+	return e.Property + ": " + e.Message
+}
+
+// ConfigDiagnostic returns the property name and message separately so that
+// the dprint plugins can report them as configuration diagnostics.
+func (e *ConfigError) ConfigDiagnostic() (string, string) {
+	return e.Property, e.Message
+}
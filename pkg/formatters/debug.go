@@ -0,0 +1,37 @@
+package formatters
+
+import "context"
+
+// debugLogKey is the context key of the debug logger.
+type debugLogKey struct{}
+
+// WithDebugLog returns a context that makes the formatters report the
+// decisions they take, such as the dialect picked for a script, to logf.
+func WithDebugLog(ctx context.Context, logf func(format string, args ...any)) context.Context {
+	return context.WithValue(ctx, debugLogKey{}, logf)
+}
+
+// debugf reports a formatting decision if ctx carries a debug logger.
+func debugf(ctx context.Context, format string, args ...any) {
+	if logf, ok := ctx.Value(debugLogKey{}).(func(string, ...any)); ok {
+		logf(format, args...)
+	}
+}
+
+// warningLogKey is the context key of the warning logger.
+type warningLogKey struct{}
+
+// WithWarningLog returns a context that makes the formatters report
+// problems that do not stop formatting, such as bash-only commands in a
+// POSIX script, to logf.
+func WithWarningLog(ctx context.Context, logf func(format string, args ...any)) context.Context {
+	return context.WithValue(ctx, warningLogKey{}, logf)
+}
+
+// warnf reports a problem that does not stop formatting if ctx carries a
+// warning logger.
+func warnf(ctx context.Context, format string, args ...any) {
+	if logf, ok := ctx.Value(warningLogKey{}).(func(string, ...any)); ok {
+		logf(format, args...)
+	}
+}
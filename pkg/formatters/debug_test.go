@@ -0,0 +1,30 @@
+package formatters
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestWithDebugLog_Reports_Decisions verifies that the formatters report
+// the decisions they take to the debug logger.
+func TestWithDebugLog_Reports_Decisions(t *testing.T) {
+	var log []string
+	ctx := WithDebugLog(context.Background(), func(format string, args ...any) {
+		log = append(log, fmt.Sprintf(format, args...))
+	})
+
+	if _, err := FormatShellContext(ctx, "run.sh", []byte("#!/bin/sh\necho hi\n"), DefaultShellConfig()); err != nil {
+		t.Fatalf("FormatShellContext: %v", err)
+	}
+	src := []byte("resource \"a\" \"b\" {\n  name = \"${var.name}\"\n}\n")
+	if _, err := FormatHCLContext(ctx, "main.tf", src, DefaultHCLConfig()); err != nil {
+		t.Fatalf("FormatHCLContext: %v", err)
+	}
+
+	want := []string{`parsing run.sh as posix (language "auto")`, "unwrapped interpolation in resource.name"}
+	if strings.Join(log, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("debug log = %q; want %q", log, want)
+	}
+}
@@ -0,0 +1,162 @@
+package formatters
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"go/scanner"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// SyntaxError is a problem at a position in the formatted file. The
+// formatters return one for each parse error, joined with errors.Join when
+// there are several, so that callers can point at the offending line
+// instead of parsing flattened messages.
+type SyntaxError struct {
+	// Path is the file the error belongs to; it may be empty.
+	Path string
+	// Line and Column are 1-based; zero means the position is unknown.
+	Line   int
+	Column int
+	// EndLine and EndColumn are where the offending source ends, exclusive;
+	// zero means only the start is known.
+	EndLine   int
+	EndColumn int
+	// Severity is SeverityError or SeverityWarning for problems reported
+	// with one, such as HCL diagnostics, and empty otherwise.
+	Severity string
+	// Message describes the problem.
+	Message string
+}
+
+// Values of SyntaxError.Severity.
+const (
+	// SeverityError marks a problem that stops the file from formatting.
+	SeverityError = "error"
+	// SeverityWarning marks a problem reported alongside the errors that
+	// would not have stopped the file from formatting on its own.
+	SeverityWarning = "warning"
+)
+
+// Error renders the error as path:line:column-endLine:endColumn: severity:
+// message, leaving out the parts that are unknown.
+func (e *SyntaxError) Error() string {
+	prefix := e.Path
+	if e.Line > 0 {
+		if prefix != "" {
+			prefix += ":"
+		}
+		prefix += fmt.Sprintf("%d:%d", e.Line, e.Column)
+		if e.EndLine > 0 && (e.EndLine != e.Line || e.EndColumn != e.Column) {
+			prefix += fmt.Sprintf("-%d:%d", e.EndLine, e.EndColumn)
+		}
+	}
+	message := e.Message
+	if e.Severity != "" {
+		message = e.Severity + ": " + message
+	}
+	if prefix == "" {
+		return message
+	}
+	return prefix + ": " + message
+}
+
+// goSyntaxErrors converts the errors reported by go/format.
+func goSyntaxErrors(path string, err error) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		return err
+	}
+	errs := make([]error, 0, len(list))
+	for _, e := range list {
+		errs = append(errs, &SyntaxError{Path: path, Line: e.Pos.Line, Column: e.Pos.Column, Message: e.Msg})
+	}
+	return errors.Join(errs...)
+}
+
+// sortSyntaxErrors orders errors by the position of the SyntaxError values
+// among them, keeping errors without one where they are.
+func sortSyntaxErrors(errs []error) {
+	slices.SortStableFunc(errs, func(a, b error) int {
+		var x, y *SyntaxError
+		if !errors.As(a, &x) || !errors.As(b, &y) {
+			return 0
+		}
+		return cmp.Or(cmp.Compare(x.Line, y.Line), cmp.Compare(x.Column, y.Column))
+	})
+}
+
+// shiftSyntaxErrors moves the SyntaxError values in err, which may be
+// joined, down by delta lines, for errors found in a fragment of the file.
+func shiftSyntaxErrors(err error, delta int) error {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint // joined by goSyntaxErrors
+		errs = joined.Unwrap()
+	}
+	for _, e := range errs {
+		var syntaxErr *SyntaxError
+		if errors.As(e, &syntaxErr) && syntaxErr.Line > 0 {
+			syntaxErr.Line += delta
+			if syntaxErr.EndLine > 0 {
+				syntaxErr.EndLine += delta
+			}
+		}
+	}
+	return err
+}
+
+// shellSyntaxError converts the errors reported by the shell parser.
+func shellSyntaxError(err error) error {
+	var parseErr syntax.ParseError
+	if errors.As(err, &parseErr) {
+		return &SyntaxError{
+			Path:    parseErr.Filename,
+			Line:    int(parseErr.Pos.Line()),
+			Column:  int(parseErr.Pos.Col()),
+			Message: parseErr.Text,
+		}
+	}
+	var langErr syntax.LangError
+	if errors.As(err, &langErr) {
+		// LangError only renders its message together with the position.
+		_, message, _ := strings.Cut(langErr.Error(), langErr.Pos.String()+": ")
+		return &SyntaxError{
+			Path:    langErr.Filename,
+			Line:    int(langErr.Pos.Line()),
+			Column:  int(langErr.Pos.Col()),
+			Message: message,
+		}
+	}
+	return err
+}
+
+// hclSyntaxErrors converts the diagnostics reported by the HCL parsers,
+// keeping the range of source each one is about and its severity, so that
+// editors and CI annotations can point at the offending expression.
+func hclSyntaxErrors(diags hcl.Diagnostics) error {
+	errs := make([]error, 0, len(diags))
+	for _, diag := range diags {
+		e := &SyntaxError{Severity: SeverityError, Message: diag.Summary}
+		switch diag.Severity {
+		case hcl.DiagError:
+		case hcl.DiagWarning:
+			e.Severity = SeverityWarning
+		default:
+			continue
+		}
+		if diag.Detail != "" {
+			e.Message += "; " + diag.Detail
+		}
+		if diag.Subject != nil {
+			e.Path = diag.Subject.Filename
+			e.Line, e.Column = diag.Subject.Start.Line, diag.Subject.Start.Column
+			e.EndLine, e.EndColumn = diag.Subject.End.Line, diag.Subject.End.Column
+		}
+		errs = append(errs, e)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,129 @@
+package formatters
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestFormatters_Report_Syntax_Error_Positions verifies that every
+// formatter reports parse errors as SyntaxError values with the position
+// of the problem.
+func TestFormatters_Report_Syntax_Error_Positions(t *testing.T) {
+	tests := []struct {
+		name   string
+		format func() error
+		want   SyntaxError
+	}{
+		{
+			name: "go",
+			format: func() error {
+				_, err := FormatGo([]byte("package main\n\nfunc {\n"))
+				return err
+			},
+			want: SyntaxError{Path: "", Line: 3, Column: 6, Message: "expected 'IDENT', found '{'"},
+		},
+		{
+			name: "gofumpt with path",
+			format: func() error {
+				cfg := DefaultGoConfig()
+				cfg.Style = GoStyleGofumpt
+				_, err := FormatGoContext(context.Background(), "cmd/foo/main.go", []byte("package main\n\nfunc {\n"), cfg)
+				return err
+			},
+			want: SyntaxError{Path: "cmd/foo/main.go", Line: 3, Column: 6, Message: "expected 'IDENT', found '{'"},
+		},
+		{
+			name: "shell",
+			format: func() error {
+				src := []byte("echo ok\nif true; then\n")
+				_, err := FormatShellContext(context.Background(), "run.sh", src, DefaultShellConfig())
+				return err
+			},
+			want: SyntaxError{Path: "run.sh", Line: 2, Column: 1, Message: `if statement must end with "fi"`},
+		},
+		{
+			name: "hcl",
+			format: func() error {
+				_, err := FormatHCL("main.tf", []byte("a = 1\nb = \n"), DefaultHCLConfig())
+				return err
+			},
+			want: SyntaxError{
+				Path:      "main.tf",
+				Line:      2,
+				Column:    5,
+				EndLine:   3,
+				EndColumn: 1,
+				Severity:  SeverityError,
+				Message:   "Invalid expression; Expected the start of an expression, but found an invalid expression token.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *SyntaxError
+			if err := tt.format(); !errors.As(err, &got) {
+				t.Fatalf("error %v is not a SyntaxError", err)
+			}
+			if *got != tt.want {
+				t.Fatalf("SyntaxError = %#v; want %#v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatShell_Reports_Every_Recoverable_Syntax_Error verifies that
+// errors the shell parser can recover from are all reported, in order of
+// position.
+func TestFormatShell_Reports_Every_Recoverable_Syntax_Error(t *testing.T) {
+	src := []byte("if true; then\n\techo\nwhile x; do\n")
+	_, err := FormatShell("run.sh", src, DefaultShellConfig())
+	want := "run.sh:1:1: if statement must end with \"fi\"\nrun.sh:3:1: while statement must end with \"done\""
+	if err == nil || err.Error() != want {
+		t.Fatalf("FormatShell error = %v; want %s", err, want)
+	}
+}
+
+// TestFormatHCL_Reports_Diagnostic_Ranges verifies that HCL errors carry
+// the range of the offending source and their severity.
+func TestFormatHCL_Reports_Diagnostic_Ranges(t *testing.T) {
+	_, err := FormatHCL("main.tf", []byte("a = {\n  b = 1 c = 2\n}\n"), DefaultHCLConfig())
+	want := "main.tf:2:9-2:10: error: Missing attribute separator; " +
+		"Expected a newline or comma to mark the beginning of the next attribute."
+	if err == nil || err.Error() != want {
+		t.Fatalf("FormatHCL error = %v; want %s", err, want)
+	}
+}
+
+// TestShiftSyntaxErrors_Moves_Joined_Errors verifies that errors found in a
+// fragment of a file are moved to the lines they have in the file.
+func TestShiftSyntaxErrors_Moves_Joined_Errors(t *testing.T) {
+	first := &SyntaxError{Path: "main.go", Line: 3, Column: 1, EndLine: 4, EndColumn: 2, Message: "a"}
+	unknown := &SyntaxError{Path: "main.go", Message: "b"}
+	err := shiftSyntaxErrors(errors.Join(first, unknown), 10)
+	if want := "main.go:13:1-14:2: a\nmain.go: b"; err.Error() != want {
+		t.Fatalf("shiftSyntaxErrors = %q; want %q", err, want)
+	}
+}
+
+// TestSyntaxError_Error_Omits_Unknown_Parts checks the rendering of errors
+// with and without a path and position.
+func TestSyntaxError_Error_Omits_Unknown_Parts(t *testing.T) {
+	tests := []struct {
+		err  SyntaxError
+		want string
+	}{
+		{SyntaxError{Path: "a.sh", Line: 1, Column: 2, Message: "bad"}, "a.sh:1:2: bad"},
+		{SyntaxError{Line: 1, Column: 2, Message: "bad"}, "1:2: bad"},
+		{SyntaxError{Path: "a.sh", Message: "bad"}, "a.sh: bad"},
+		{SyntaxError{Message: "bad"}, "bad"},
+		{SyntaxError{Path: "a.tf", Line: 1, Column: 2, EndLine: 1, EndColumn: 5, Message: "bad"}, "a.tf:1:2-1:5: bad"},
+		{SyntaxError{Path: "a.tf", Line: 1, Column: 2, EndLine: 1, EndColumn: 2, Message: "bad"}, "a.tf:1:2: bad"},
+		{SyntaxError{Path: "a.tf", Severity: SeverityWarning, Message: "bad"}, "a.tf: warning: bad"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Fatalf("Error() = %q; want %q", got, tt.want)
+		}
+	}
+}
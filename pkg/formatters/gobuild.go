@@ -0,0 +1,254 @@
+package formatters
+
+import (
+	"bytes"
+	"go/build/constraint"
+	gofmt "go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// Values of GoConfig.BuildConstraints.
+const (
+	// BuildConstraintsSync keeps //go:build and // +build lines consistent
+	// like gofmt: a //go:build line is added above legacy lines that lack
+	// one, the legacy lines are rewritten to match it and its expression is
+	// spaced canonically. An empty value means the same.
+	BuildConstraintsSync = "sync"
+	// BuildConstraintsModernOnly syncs the constraints and then drops the
+	// legacy // +build lines.
+	BuildConstraintsModernOnly = "modern-only"
+	// BuildConstraintsLeaveAlone keeps the constraint lines as written.
+	BuildConstraintsLeaveAlone = "leave-alone"
+)
+
+// buildConstraintModes lists the accepted values of GoConfig.BuildConstraints.
+var buildConstraintModes = []string{ //nolint:gochecknoglobals // read-only lookup
+	BuildConstraintsSync,
+	BuildConstraintsModernOnly,
+	BuildConstraintsLeaveAlone,
+}
+
+// Prefixes of the constraint lines and of the placeholders that hide them
+// from the printer, which otherwise syncs them whenever it prints a file.
+const (
+	goBuildPrefix         = "//go:build"
+	plusBuildPrefix       = "// +build"
+	hiddenGoBuildPrefix   = "//go:dprint-build"
+	hiddenPlusBuildPrefix = "// +dprint-build"
+)
+
+// goHeaderLines returns the lines of src before the package clause, each
+// with its line ending, and the rest of src. It reports false when the
+// package clause cannot be parsed.
+func goHeaderLines(src []byte) ([][]byte, []byte, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return nil, nil, false
+	}
+	offset := fset.Position(file.Package).Offset
+	offset = bytes.LastIndexByte(src[:offset], '\n') + 1
+	return bytes.SplitAfter(src[:offset], []byte("\n")), src[offset:], true
+}
+
+// replaceHeaderPrefixes swaps the given line prefixes in the lines before
+// the package clause. Lines are matched after leading blanks.
+func replaceHeaderPrefixes(src []byte, replacements map[string]string) []byte {
+	header, rest, ok := goHeaderLines(src)
+	if !ok {
+		return src
+	}
+	var out bytes.Buffer
+	for _, line := range header {
+		trimmed := bytes.TrimLeft(line, " \t")
+		for from, to := range replacements {
+			if after, found := bytes.CutPrefix(trimmed, []byte(from)); found {
+				line = slices.Concat(line[:len(line)-len(trimmed)], []byte(to), after)
+				break
+			}
+		}
+		out.Write(line)
+	}
+	out.Write(rest)
+	return out.Bytes()
+}
+
+// hideBuildConstraints disguises the constraint lines of src so that the
+// printer leaves them as written.
+func hideBuildConstraints(src []byte) []byte {
+	return replaceHeaderPrefixes(src, map[string]string{
+		goBuildPrefix:   hiddenGoBuildPrefix,
+		plusBuildPrefix: hiddenPlusBuildPrefix,
+	})
+}
+
+// revealBuildConstraints undoes hideBuildConstraints.
+func revealBuildConstraints(src []byte) []byte {
+	return replaceHeaderPrefixes(src, map[string]string{
+		hiddenGoBuildPrefix:   goBuildPrefix,
+		hiddenPlusBuildPrefix: plusBuildPrefix,
+	})
+}
+
+// dropPlusBuildLines removes the // +build lines of formatted src, which
+// the printer has already paired with a //go:build line.
+func dropPlusBuildLines(path string, src []byte) ([]byte, error) {
+	header, rest, ok := goHeaderLines(src)
+	if !ok {
+		return src, nil
+	}
+	var out bytes.Buffer
+	dropped := false
+	for _, line := range header {
+		if constraint.IsPlusBuild(string(line)) {
+			dropped = true
+			continue
+		}
+		out.Write(line)
+	}
+	if !dropped {
+		return src, nil
+	}
+	out.Write(rest)
+	formatted, err := gofmt.Source(out.Bytes())
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	return formatted, nil
+}
+
+// tidyBuildConstraints rewrites the constraint lines before the package
+// clause of src as a single //go:build line, followed by the matching
+// // +build lines when the file has any. Repeated //go:build lines are
+// combined, the terms of every && and || are deduplicated and sorted by
+// tag, and // +build lines that disagree with the //go:build line are
+// replaced. Files with constraints that do not parse are left alone.
+func tidyBuildConstraints(src []byte) []byte {
+	header, rest, ok := goHeaderLines(src)
+	if !ok {
+		return src
+	}
+	var goBuild, plusBuild []constraint.Expr
+	first := -1
+	for i, line := range header {
+		text := string(bytes.TrimSpace(line))
+		isGoBuild, isPlusBuild := constraint.IsGoBuild(text), constraint.IsPlusBuild(text)
+		if !isGoBuild && !isPlusBuild {
+			continue
+		}
+		x, err := constraint.Parse(text)
+		if err != nil {
+			return src
+		}
+		if isGoBuild {
+			goBuild = append(goBuild, x)
+		} else {
+			plusBuild = append(plusBuild, x)
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		return src
+	}
+
+	// As for the go command, the //go:build lines win over // +build lines.
+	exprs := goBuild
+	if len(exprs) == 0 {
+		exprs = plusBuild
+	}
+	x := exprs[0]
+	for _, y := range exprs[1:] {
+		x = &constraint.AndExpr{X: x, Y: y}
+	}
+	x = tidyConstraint(x)
+	block := goBuildPrefix + " " + x.String() + "\n"
+	if len(plusBuild) > 0 {
+		lines, err := constraint.PlusBuildLines(x)
+		if err != nil {
+			return src
+		}
+		block += strings.Join(lines, "\n") + "\n"
+	}
+
+	var out bytes.Buffer
+	for i, line := range header {
+		text := string(bytes.TrimSpace(line))
+		switch {
+		case i == first:
+			out.WriteString(block)
+		case constraint.IsGoBuild(text) || constraint.IsPlusBuild(text):
+		default:
+			out.Write(line)
+		}
+	}
+	out.Write(rest)
+	return out.Bytes()
+}
+
+// tidyConstraint flattens the chains of && and || of x, drops repeated
+// operands and sorts them by tag, ignoring negation.
+func tidyConstraint(x constraint.Expr) constraint.Expr {
+	switch x := x.(type) {
+	case *constraint.NotExpr:
+		return &constraint.NotExpr{X: tidyConstraint(x.X)}
+	case *constraint.AndExpr:
+		operands := tidyOperands(x, func(e constraint.Expr) (constraint.Expr, constraint.Expr, bool) {
+			and, ok := e.(*constraint.AndExpr)
+			if !ok {
+				return nil, nil, false
+			}
+			return and.X, and.Y, true
+		})
+		y := operands[0]
+		for _, operand := range operands[1:] {
+			y = &constraint.AndExpr{X: y, Y: operand}
+		}
+		return y
+	case *constraint.OrExpr:
+		operands := tidyOperands(x, func(e constraint.Expr) (constraint.Expr, constraint.Expr, bool) {
+			or, ok := e.(*constraint.OrExpr)
+			if !ok {
+				return nil, nil, false
+			}
+			return or.X, or.Y, true
+		})
+		y := operands[0]
+		for _, operand := range operands[1:] {
+			y = &constraint.OrExpr{X: y, Y: operand}
+		}
+		return y
+	default:
+		return x
+	}
+}
+
+// tidyOperands returns the tidied operands of a chain of one operator,
+// which split takes apart, without repeats and sorted.
+func tidyOperands(
+	x constraint.Expr,
+	split func(constraint.Expr) (constraint.Expr, constraint.Expr, bool),
+) []constraint.Expr {
+	var operands []constraint.Expr
+	var walk func(constraint.Expr)
+	walk = func(e constraint.Expr) {
+		if left, right, ok := split(e); ok {
+			walk(left)
+			walk(right)
+			return
+		}
+		e = tidyConstraint(e)
+		if !slices.ContainsFunc(operands, func(o constraint.Expr) bool { return o.String() == e.String() }) {
+			operands = append(operands, e)
+		}
+	}
+	walk(x)
+	slices.SortStableFunc(operands, func(a, b constraint.Expr) int {
+		return strings.Compare(strings.TrimLeft(a.String(), "!("), strings.TrimLeft(b.String(), "!("))
+	})
+	return operands
+}
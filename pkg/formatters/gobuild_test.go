@@ -0,0 +1,116 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatGoContext_Normalizes_Build_Constraints verifies that build
+// constraints are synced like gofmt, reduced to //go:build lines or left as
+// written, depending on the configuration.
+func TestFormatGoContext_Normalizes_Build_Constraints(t *testing.T) {
+	legacy := "// +build linux,amd64\n\npackage p\n"
+	mismatched := "// Copyright.\n\n//go:build  linux&&amd64\n// +build linux\n\n// Package p.\npackage p\n"
+	tests := []struct {
+		name string
+		mode string
+		src  string
+		want string
+	}{
+		{
+			name: "sync adds go:build",
+			mode: BuildConstraintsSync,
+			src:  legacy,
+			want: "//go:build linux && amd64\n// +build linux,amd64\n\npackage p\n",
+		},
+		{
+			name: "sync fixes mismatch",
+			mode: BuildConstraintsSync,
+			src:  mismatched,
+			want: "// Copyright.\n\n//go:build linux && amd64\n// +build linux,amd64\n\n// Package p.\npackage p\n",
+		},
+		{
+			name: "modern-only converts legacy",
+			mode: BuildConstraintsModernOnly,
+			src:  legacy,
+			want: "//go:build linux && amd64\n\npackage p\n",
+		},
+		{
+			name: "modern-only drops legacy",
+			mode: BuildConstraintsModernOnly,
+			src:  mismatched,
+			want: "// Copyright.\n\n//go:build linux && amd64\n\n// Package p.\npackage p\n",
+		},
+		{
+			name: "leave-alone",
+			mode: BuildConstraintsLeaveAlone,
+			src:  mismatched + "var  x = 1\n",
+			want: mismatched + "\nvar x = 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultGoConfig()
+			cfg.BuildConstraints = tt.mode
+			got, err := FormatGoContext(context.Background(), "p.go", []byte(tt.src), cfg)
+			if err != nil {
+				t.Fatalf("FormatGoContext: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatGoContext = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatGoContext_Tidies_Build_Constraints verifies that repeated
+// //go:build lines are combined, terms are deduplicated and sorted and
+// stale // +build lines are replaced.
+func TestFormatGoContext_Tidies_Build_Constraints(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		src  string
+		want string
+	}{
+		{
+			name: "repeated go:build lines",
+			mode: BuildConstraintsSync,
+			src:  "//go:build linux || darwin || linux\n//go:build !windows && amd64\n\npackage p\n",
+			want: "//go:build amd64 && (darwin || linux) && !windows\n\npackage p\n",
+		},
+		{
+			name: "stale plus build lines",
+			mode: BuildConstraintsLeaveAlone,
+			src:  "// Copyright.\n\n//go:build linux && linux\n// +build darwin\n\npackage p\n",
+			want: "// Copyright.\n\n//go:build linux\n// +build linux\n\npackage p\n",
+		},
+		{
+			name: "legacy lines only",
+			mode: BuildConstraintsSync,
+			src:  "// +build linux darwin\n// +build linux darwin\n\npackage p\n",
+			want: "//go:build darwin || linux\n// +build darwin linux\n\npackage p\n",
+		},
+		{
+			name: "unparsable",
+			mode: BuildConstraintsLeaveAlone,
+			src:  "//go:build linux &&\n//go:build linux\n\npackage p\n",
+			want: "//go:build linux &&\n//go:build linux\n\npackage p\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultGoConfig()
+			cfg.BuildConstraints, cfg.TidyBuildConstraints = tt.mode, true
+			got, err := FormatGoContext(context.Background(), "p.go", []byte(tt.src), cfg)
+			if err != nil {
+				t.Fatalf("FormatGoContext: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatGoContext = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package formatters
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+)
+
+// isCgoImport reports whether an import spec is cgo's import "C".
+func isCgoImport(spec ast.Spec) bool {
+	imp, ok := spec.(*ast.ImportSpec)
+	return ok && imp.Path.Value == `"C"`
+}
+
+// hasCgoImport reports whether an import declaration imports "C".
+func hasCgoImport(decl *ast.GenDecl) bool {
+	return decl.Tok == token.IMPORT && slices.ContainsFunc(decl.Specs, isCgoImport)
+}
+
+// cgoPreambles returns the cgo preambles of file, the comments right before
+// every import "C", in source order.
+func cgoPreambles(file *ast.File) []*ast.CommentGroup {
+	var preambles []*ast.CommentGroup
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || !hasCgoImport(gen) {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if !isCgoImport(spec) {
+				continue
+			}
+			doc := spec.(*ast.ImportSpec).Doc
+			if doc == nil && !gen.Lparen.IsValid() {
+				doc = gen.Doc
+			}
+			if doc != nil {
+				preambles = append(preambles, doc)
+			}
+		}
+	}
+	return preambles
+}
+
+// restoreCgoPreambles puts the cgo preambles of src back into formatted,
+// byte for byte, where a pass changed them: cgo compiles them as C, which
+// no Go formatting rule applies to. Only the comments themselves are
+// restored, not the indentation before them. formatted is returned as it
+// is when the preambles cannot be matched up.
+func restoreCgoPreambles(src, formatted []byte) []byte {
+	parse := func(b []byte) (*token.FileSet, []*ast.CommentGroup, bool) {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", b, parser.ImportsOnly|parser.ParseComments)
+		if err != nil {
+			return nil, nil, false
+		}
+		return fset, cgoPreambles(file), true
+	}
+	srcSet, want, ok := parse(src)
+	if !ok || len(want) == 0 {
+		return formatted
+	}
+	gotSet, got, ok := parse(formatted)
+	if !ok || len(got) != len(want) {
+		return formatted
+	}
+
+	offset := func(fset *token.FileSet, pos token.Pos) int { return fset.Position(pos).Offset }
+	var edits []goEdit
+	for i, group := range got {
+		original := want[i]
+		if len(group.List) != len(original.List) {
+			text := src[offset(srcSet, original.Pos()):offset(srcSet, original.End())]
+			edits = append(edits, goEdit{span{offset(gotSet, group.Pos()), offset(gotSet, group.End())}, string(text)})
+			continue
+		}
+		for j, c := range group.List {
+			if c.Text != original.List[j].Text {
+				edits = append(edits, goEdit{span{offset(gotSet, c.Pos()), offset(gotSet, c.End())}, original.List[j].Text})
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return formatted
+	}
+	return applyGoEdits(formatted, edits)
+}
@@ -0,0 +1,99 @@
+package formatters
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// TestFormatGoContext_Preserves_Cgo_Preambles verifies on the fixtures in
+// testdata/cgo that every configuration keeps cgo preambles byte for byte
+// and import "C" in its place, and that formatting is idempotent.
+func TestFormatGoContext_Preserves_Cgo_Preambles(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "cgo", "*.go"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("no cgo fixtures: %v", err)
+	}
+	configs := map[string]func(*GoConfig){
+		"defaults": func(*GoConfig) {},
+		"gofumpt":  func(c *GoConfig) { c.Style = GoStyleGofumpt },
+		"everything": func(c *GoConfig) {
+			c.Style, c.LineWidth = GoStyleGofumpt, 60
+			c.ImportLayout, c.ImportSections = ImportLayoutSections, []string{"standard", "default"}
+			c.ReflowComments, c.WrapLongLines, c.OrganizeImports, c.Simplify = true, true, true, true
+			c.RemoveEmptyImportComments, c.NoEmptyLineAtBlockStart = true, true
+		},
+	}
+
+	for _, fixture := range fixtures {
+		src, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		for name, configure := range configs {
+			t.Run(filepath.Base(fixture)+"/"+name, func(t *testing.T) {
+				cfg := DefaultGoConfig()
+				configure(&cfg)
+				got, err := FormatGoContext(context.Background(), fixture, src, cfg)
+				if err != nil {
+					t.Fatalf("FormatGoContext: %v", err)
+				}
+				if want, have := cgoShape(t, src), cgoShape(t, got); !slices.Equal(have, want) {
+					t.Fatalf("cgo preambles and imports = %q; want %q", have, want)
+				}
+				again, err := FormatGoContext(context.Background(), fixture, got, cfg)
+				if err != nil {
+					t.Fatalf("FormatGoContext again: %v", err)
+				}
+				if string(again) != string(got) {
+					t.Fatalf("FormatGoContext is not idempotent: %q, then %q", got, again)
+				}
+			})
+		}
+	}
+}
+
+// cgoShape returns the text of every comment of the cgo preambles of src,
+// followed by the paths of the import declarations holding import "C".
+func cgoShape(t *testing.T, src []byte) []string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile: %v", err)
+	}
+	var shape []string
+	for _, group := range cgoPreambles(file) {
+		for _, c := range group.List {
+			shape = append(shape, c.Text)
+		}
+	}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && hasCgoImport(gen) {
+			for _, spec := range gen.Specs {
+				path, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value)
+				shape = append(shape, path)
+			}
+		}
+	}
+	return shape
+}
+
+// TestRestoreCgoPreambles_Undoes_Changes verifies that a preamble a pass
+// changed is put back, whether its comments were edited or merged.
+func TestRestoreCgoPreambles_Undoes_Changes(t *testing.T) {
+	src := []byte("package p\n\n// #include <a.h>\n// int x;\nimport \"C\"\n")
+	for _, formatted := range []string{
+		"package p\n\n// #include <a.h>\n// int  x;\nimport \"C\"\n",
+		"package p\n\n// #include <a.h> int x;\nimport \"C\"\n",
+	} {
+		if got := restoreCgoPreambles(src, []byte(formatted)); string(got) != string(src) {
+			t.Fatalf("restoreCgoPreambles(%q) = %q; want %q", formatted, got, src)
+		}
+	}
+}
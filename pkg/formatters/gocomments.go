@@ -0,0 +1,167 @@
+package formatters
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// goTabWidth is the number of columns a tab of indentation counts for when
+// measuring lines against the line width.
+const goTabWidth = 4
+
+// goListItem matches the text of a comment line that starts a list item,
+// such as "- item", "* item" or "1. item".
+var goListItem = regexp.MustCompile(`^([-*+•]|[0-9]+[.)])\s`) //nolint:gochecknoglobals // read-only lookup
+
+// goDirectivePrefixes lists the starts of comment text that tools read as a
+// directive even after a space, such as "// +build" constraints, and that a
+// rewrapped line must therefore never begin with.
+var goDirectivePrefixes = []string{"+build", "go:", "line ", "nolint:", "export ", "extern ", "lint:"} //nolint:gochecknoglobals // read-only lookup
+
+// reflowGoComments rewraps the paragraphs of // comments that have a line
+// longer than width, filling lines up to width. Only comments on lines of
+// their own are rewrapped. Directives such as //go:generate, code blocks,
+// list items and headings are kept as written and end a paragraph, like
+// blank comment lines do. cgo preambles are C and are left alone. src must
+// be gofmt output.
+func reflowGoComments(path string, src []byte, width int) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	preambles := cgoPreambles(file)
+	replaced := make(map[int][]string)
+	for _, group := range file.Comments {
+		first := fset.Position(group.Pos())
+		indent := lines[first.Line-1][:first.Column-1]
+		if len(bytes.Trim(indent, " \t")) > 0 || !lineCommentsOnly(group) || slices.Contains(preambles, group) {
+			continue
+		}
+		texts := make([]string, len(group.List))
+		for i, c := range group.List {
+			texts[i] = strings.TrimPrefix(c.Text, "//")
+		}
+		if reflowed, changed := reflowCommentLines(texts, width-columns(string(indent))-len("//")); changed {
+			replaced[first.Line-1] = prefixLines(string(indent)+"//", reflowed)
+			for i := 1; i < len(group.List); i++ {
+				replaced[first.Line-1+i] = nil
+			}
+		}
+	}
+	if len(replaced) == 0 {
+		return src, nil
+	}
+
+	var out bytes.Buffer
+	for i, line := range lines {
+		if texts, ok := replaced[i]; ok {
+			for _, text := range texts {
+				out.WriteString(text)
+			}
+			continue
+		}
+		out.Write(line)
+	}
+	return out.Bytes(), nil
+}
+
+// lineCommentsOnly reports whether a comment group holds only // comments.
+func lineCommentsOnly(group *ast.CommentGroup) bool {
+	for _, c := range group.List {
+		if !strings.HasPrefix(c.Text, "//") {
+			return false
+		}
+	}
+	return true
+}
+
+// reflowCommentLines rewraps the comment texts, each without its leading
+// //, so that every line fits in width columns where words allow. It
+// reports whether any paragraph was rewrapped.
+func reflowCommentLines(texts []string, width int) ([]string, bool) {
+	var out, paragraph []string
+	changed := false
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		long := false
+		for _, text := range paragraph {
+			long = long || columns(text) > width
+		}
+		if long {
+			out = append(out, fillWords(strings.Fields(strings.Join(paragraph, " ")), width)...)
+			changed = true
+		} else {
+			out = append(out, paragraph...)
+		}
+		paragraph = nil
+	}
+	for _, text := range texts {
+		if isProse(text) {
+			paragraph = append(paragraph, text)
+			continue
+		}
+		flush()
+		out = append(out, text)
+	}
+	flush()
+	return out, changed
+}
+
+// isProse reports whether a comment text, without its leading //, is
+// ordinary text that may be rewrapped. Directives have no space after the
+// //, code blocks are indented further, and lists, headings and lines that
+// read as directives, such as "// +build", keep their lines.
+func isProse(text string) bool {
+	body, ok := strings.CutPrefix(text, " ")
+	if !ok || body == "" || body[0] == ' ' || body[0] == '\t' {
+		return false
+	}
+	for _, prefix := range goDirectivePrefixes {
+		if strings.HasPrefix(body, prefix) {
+			return false
+		}
+	}
+	return !goListItem.MatchString(body) && !strings.HasPrefix(body, "# ")
+}
+
+// fillWords fills lines of " "-prefixed words up to width columns. A word
+// longer than width gets a line of its own, and a word that would read as a
+// list marker, heading or directive at the start of a line stays on the
+// previous one.
+func fillWords(words []string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	for _, word := range words {
+		if line.Len() > 0 && columns(line.String())+1+columns(word) > width && isProse(" "+word+" ") {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		line.WriteString(" ")
+		line.WriteString(word)
+	}
+	return append(lines, line.String())
+}
+
+// prefixLines prefixes every text and ends it with a newline.
+func prefixLines(prefix string, texts []string) []string {
+	lines := make([]string, len(texts))
+	for i, text := range texts {
+		lines[i] = prefix + text + "\n"
+	}
+	return lines
+}
+
+// columns returns the display width of s, counting tabs as goTabWidth.
+func columns(s string) int {
+	return utf8.RuneCountInString(s) + strings.Count(s, "\t")*(goTabWidth-1)
+}
@@ -0,0 +1,99 @@
+package formatters
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestFormatGoContext_Reflows_Long_Comments verifies that paragraphs with a
+// line over the line width are rewrapped while short paragraphs,
+// directives, code blocks, lists and trailing comments keep their lines.
+func TestFormatGoContext_Reflows_Long_Comments(t *testing.T) {
+	src := "package p\n\n" +
+		"//\tcode := example(with, a, very, long, argument, list, that, must, stay)\n" +
+		"//\n" +
+		"//   - a list item that is far too long to fit but must keep its line\n\n" +
+		"// F does something useful with a rather long explanation that runs past the limit.\n" +
+		"// Short line.\n" +
+		"//\n" +
+		"// Untouched paragraph.\n" +
+		"//\n" +
+		"//go:noinline\n" +
+		"func F() {\n" +
+		"\t// An indented comment that is long enough to need wrapping at forty.\n" +
+		"\t_ = 1 // a trailing comment that is also quite long but stays put\n" +
+		"}\n"
+	want := "package p\n\n" +
+		"//\tcode := example(with, a, very, long, argument, list, that, must, stay)\n" +
+		"//\n" +
+		"//   - a list item that is far too long to fit but must keep its line\n\n" +
+		"// F does something useful with a rather\n" +
+		"// long explanation that runs past the\n" +
+		"// limit. Short line.\n" +
+		"//\n" +
+		"// Untouched paragraph.\n" +
+		"//\n" +
+		"//go:noinline\n" +
+		"func F() {\n" +
+		"\t// An indented comment that is long\n" +
+		"\t// enough to need wrapping at forty.\n" +
+		"\t_ = 1 // a trailing comment that is also quite long but stays put\n" +
+		"}\n"
+	cfg := DefaultGoConfig()
+	cfg.ReflowComments, cfg.LineWidth = true, 40
+
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	if string(got) != want {
+		t.Fatalf("FormatGoContext = %q; want %q", got, want)
+	}
+	for _, line := range strings.Split(want, "\n")[5:8] {
+		if len(line) > cfg.LineWidth {
+			t.Fatalf("line %q is longer than %d", line, cfg.LineWidth)
+		}
+	}
+}
+
+// TestFillWords_Keeps_Markers_Off_Line_Starts verifies that rewrapping
+// never starts a line with a word that would turn it into a list item.
+func TestFillWords_Keeps_Markers_Off_Line_Starts(t *testing.T) {
+	got := fillWords(strings.Fields("see step 1. then - more"), 10)
+	want := []string{" see step 1.", " then -", " more"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("fillWords = %q; want %q", got, want)
+	}
+}
+
+// TestFormatGoContext_Keeps_Directives_Off_Reflowed_Line_Starts verifies
+// that rewrapping never starts a line with a word that reads as a
+// directive, such as +build, which the next pass would move above the
+// package clause as a build constraint, so formatting twice changes
+// nothing.
+func TestFormatGoContext_Keeps_Directives_Off_Reflowed_Line_Starts(t *testing.T) {
+	src := "// Package p handles the lines printed after the last +build comments. That's just after the\n" +
+		"// last blank line, and nolint: go: line markers.\n" +
+		"package p\n"
+	cfg := DefaultGoConfig()
+	cfg.ReflowComments = true
+	for _, width := range []int{40, 45, 50, 55, 60, 65} {
+		cfg.LineWidth = width
+		got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+		if err != nil {
+			t.Fatalf("FormatGoContext at %d: %v", width, err)
+		}
+		for _, line := range strings.Split(string(got), "\n") {
+			for _, prefix := range goDirectivePrefixes {
+				if strings.HasPrefix(line, "// "+prefix) {
+					t.Fatalf("FormatGoContext at %d starts line %q with a directive", width, line)
+				}
+			}
+		}
+		again, err := FormatGoContext(context.Background(), "p.go", got, cfg)
+		if err != nil || string(again) != string(got) {
+			t.Fatalf("FormatGoContext twice at %d = %q, %v; want %q", width, again, err, got)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+// Package formatters exposes the formatting cores used by the dprint plugins
+// in this repository so that other Go programs can apply exactly the same
+// formatting without going through dprint.
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/ast"
+	gofmt "go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+
+	gofumpt "mvdan.cc/gofumpt/format"
+)
+
+// Values of GoConfig.Style.
+const (
+	GoStyleGofmt   = "gofmt"
+	GoStyleGofumpt = "gofumpt"
+)
+
+// GoConfig holds the options of the Go formatter.
+type GoConfig struct {
+	Style                     string   `json:"style"                     description:"gofmt, or gofumpt for its stricter rules on top of gofmt." enum:"gofmt,gofumpt"`
+	RewriteRules              []string `json:"rewriteRules"              description:"gofmt -r rules of the form pattern -> replacement, applied in order."`
+	LocalPrefixes             []string `json:"localPrefixes"             description:"Import path prefixes whose imports go in their own group after third-party ones, like goimports -local."`
+	ImportLayout              string   `json:"importLayout"              description:"runs keeps the blank-line separated runs of imports, single merges them into one group, sections groups them by importSections." enum:"runs,single,sections"`
+	ImportSections            []string `json:"importSections"            description:"Ordered import groups for the sections layout: standard, default and prefix(<path>)."`
+	BuildConstraints          string   `json:"buildConstraints"          description:"sync keeps //go:build and // +build lines consistent like gofmt, modern-only also drops the // +build lines, leave-alone keeps them as written." enum:"sync,modern-only,leave-alone"`
+	LineWidth                 int      `json:"lineWidth"                 description:"Maximum line width for wrapped lines and comments; tabs count as four columns." minimum:"1"`
+	WrapLongLines             bool     `json:"wrapLongLines"             description:"Split call arguments, parameters, results and composite literals of lines over lineWidth one per line, like golines."`
+	ReflowComments            bool     `json:"reflowComments"            description:"Rewrap // comment paragraphs that exceed lineWidth, keeping directives, code blocks and lists."`
+	NormalizeStructTags       bool     `json:"normalizeStructTags"       description:"Order struct tag keys by structTagOrder and separate them with single spaces."`
+	StructTagOrder            []string `json:"structTagOrder"            description:"Struct tag keys that come first, in order; other keys keep their order after them."`
+	AlignStructTags           bool     `json:"alignStructTags"           description:"Normalize struct tags and pad their keys into columns across fields on adjacent lines."`
+	NoEmptyLineAtBlockStart   bool     `json:"noEmptyLineAtBlockStart"   description:"Remove empty lines at the start of a block."`
+	GroupStdImports           bool     `json:"groupStdImports"           description:"Put standard library imports in a group of their own at the start of each run of imports."`
+	ShortCaseClauses          bool     `json:"shortCaseClauses"          description:"Join case clauses spread over several lines onto one line when it fits in lineWidth."`
+	OctalLiteralStyle         string   `json:"octalLiteralStyle"         description:"keep leaves octal literals as written, modern writes them as 0o755, legacy as 0755." enum:"keep,modern,legacy"`
+	Simplify                  bool     `json:"simplify"                  description:"Apply the simplifications of gofmt -s."`
+	OrganizeImports           bool     `json:"organizeImports"           description:"Add missing standard library imports and remove imports the file does not use."`
+	SkipGenerated             bool     `json:"skipGenerated"             description:"Leave files marked with a Code generated ... DO NOT EDIT. comment unformatted."`
+	ImportComments            string   `json:"importComments"            description:"keep leaves // import \"path\" comments on package clauses as written, normalize rewrites them canonically, strip removes them." enum:"keep,normalize,strip"`
+	RemoveEmptyImportComments bool     `json:"removeEmptyImportComments" description:"Remove comments of import specs that hold no text."`
+	TidyBuildConstraints      bool     `json:"tidyBuildConstraints"      description:"Combine repeated //go:build lines, deduplicate and sort their terms and replace // +build lines that disagree with them."`
+	AllowPartial              bool     `json:"allowPartial"              description:"Format the declarations before the first syntax error and keep the rest as written instead of failing."`
+	HexDigitCase              string   `json:"hexDigitCase"              description:"keep leaves the digits of hexadecimal literals as written, lower writes them as 0xff, upper as 0xFF." enum:"keep,lower,upper"`
+	DigitSeparators           string   `json:"digitSeparators"           description:"keep leaves _ separators in number literals as written, strip removes them, group separates integer digits of five or more in threes, or fours in hex and binary." enum:"keep,strip,group"`
+	NormalizeExponents        bool     `json:"normalizeExponents"        description:"Write exponents of floating-point literals without a plus sign or leading zeros, such as 1e6 for 1E+06."`
+}
+
+// DefaultGoLineWidth is the default of GoConfig.LineWidth, dprint's own
+// default line width.
+const DefaultGoLineWidth = 120
+
+// DefaultGoConfig returns the configuration used when no options are set.
+func DefaultGoConfig() GoConfig {
+	return GoConfig{
+		Style:             GoStyleGofmt,
+		ImportLayout:      ImportLayoutRuns,
+		BuildConstraints:  BuildConstraintsSync,
+		LineWidth:         DefaultGoLineWidth,
+		StructTagOrder:    []string{"json", "yaml"},
+		OctalLiteralStyle: OctalLiteralsKeep,
+		ImportComments:    ImportCommentsKeep,
+		HexDigitCase:      HexDigitsKeep,
+		DigitSeparators:   DigitSeparatorsKeep,
+	}
+}
+
+// goStyles lists the accepted values of GoConfig.Style.
+var goStyles = []string{GoStyleGofmt, GoStyleGofumpt} //nolint:gochecknoglobals // read-only lookup
+
+// Validate reports options that are not recognised.
+func (c GoConfig) Validate() error {
+	var errs []error
+	if !slices.Contains(goStyles, c.Style) {
+		errs = append(errs, &ConfigError{
+			Property: "style",
+			Message:  "must be one of " + strings.Join(goStyles, ", "),
+		})
+	}
+	for _, rule := range c.RewriteRules {
+		if _, err := parseGoRewriteRule(rule); err != nil {
+			errs = append(errs, &ConfigError{Property: "rewriteRules", Message: err.Error()})
+		}
+	}
+	if slices.Contains(c.LocalPrefixes, "") {
+		errs = append(errs, &ConfigError{Property: "localPrefixes", Message: "must not contain an empty prefix"})
+	}
+	if c.ImportLayout != "" && !slices.Contains(importLayouts, c.ImportLayout) {
+		errs = append(errs, &ConfigError{
+			Property: "importLayout",
+			Message:  "must be one of " + strings.Join(importLayouts, ", "),
+		})
+	}
+	if len(c.ImportSections) > 0 {
+		if _, err := parseImportSections(c.ImportSections); err != nil {
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() { //nolint:errorlint // joined by parseImportSections
+				errs = append(errs, &ConfigError{Property: "importSections", Message: e.Error()})
+			}
+		}
+	}
+	if c.BuildConstraints != "" && !slices.Contains(buildConstraintModes, c.BuildConstraints) {
+		errs = append(errs, &ConfigError{
+			Property: "buildConstraints",
+			Message:  "must be one of " + strings.Join(buildConstraintModes, ", "),
+		})
+	}
+	if c.OctalLiteralStyle != "" && !slices.Contains(octalLiteralStyles, c.OctalLiteralStyle) {
+		errs = append(errs, &ConfigError{
+			Property: "octalLiteralStyle",
+			Message:  "must be one of " + strings.Join(octalLiteralStyles, ", "),
+		})
+	}
+	if c.ImportComments != "" && !slices.Contains(importCommentModes, c.ImportComments) {
+		errs = append(errs, &ConfigError{
+			Property: "importComments",
+			Message:  "must be one of " + strings.Join(importCommentModes, ", "),
+		})
+	}
+	if c.HexDigitCase != "" && !slices.Contains(hexDigitCases, c.HexDigitCase) {
+		errs = append(errs, &ConfigError{
+			Property: "hexDigitCase",
+			Message:  "must be one of " + strings.Join(hexDigitCases, ", "),
+		})
+	}
+	if c.DigitSeparators != "" && !slices.Contains(digitSeparatorModes, c.DigitSeparators) {
+		errs = append(errs, &ConfigError{
+			Property: "digitSeparators",
+			Message:  "must be one of " + strings.Join(digitSeparatorModes, ", "),
+		})
+	}
+	if (c.ReflowComments || c.WrapLongLines || c.ShortCaseClauses) && c.LineWidth < 1 {
+		errs = append(errs, &ConfigError{Property: "lineWidth", Message: "must be positive"})
+	}
+	if err := validateStructTagOrder(c.StructTagOrder); err != nil {
+		errs = append(errs, &ConfigError{Property: "structTagOrder", Message: err.Error()})
+	}
+	return errors.Join(errs...)
+}
+
+// FormatGo formats Go source code using Go's canonical formatter, producing
+// the same output as running gofmt on the file. Syntax errors are returned
+// as SyntaxError values.
+func FormatGo(src []byte) ([]byte, error) {
+	return FormatGoContext(context.Background(), "", src, DefaultGoConfig())
+}
+
+// FormatGoContext formats Go source code in the configured style. The
+// gofumpt style applies mvdan.cc/gofumpt's stricter rules, such as no empty
+// lines at the start of a block and standard library imports in their own
+// group, on top of gofmt; the individual style options apply such rules one
+// at a time. Rewrite rules are applied like gofmt -r before the file is
+// printed, followed by the simplifications of gofmt -s when Simplify is set.
+// OrganizeImports then adds missing standard library imports and removes
+// unused ones, and import comments are tidied as configured. The style
+// options and struct tag normalization are applied next, then the imports
+// are arranged by the import layout and local prefixes. With WrapLongLines,
+// lists on lines over the line width are split, and with ReflowComments,
+// long comment paragraphs are rewrapped to it. Build constraints are tidied
+// first when TidyBuildConstraints is set, then synced like gofmt does unless
+// the configuration drops the legacy lines or leaves them alone. The path is
+// used in syntax errors; it may be empty. With SkipGenerated, generated
+// files are returned as they are. With AllowPartial, a file with syntax
+// errors has the declarations before the first one formatted instead of
+// failing. Lines between //gofmt:off and //gofmt:on comments are kept as
+// written, moved only when the indentation around them changes. cgo
+// preambles are kept byte for byte and import "C" keeps its place. The
+// context is checked before rewriting and before formatting.
+func FormatGoContext(ctx context.Context, path string, src []byte, cfg GoConfig) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cfg.SkipGenerated && isGeneratedGo(src) {
+		debugf(ctx, "leaving generated file unformatted")
+		return src, nil
+	}
+	if cfg.TidyBuildConstraints {
+		debugf(ctx, "tidying build constraints")
+		src = tidyBuildConstraints(src)
+	}
+	formatted, err := formatGoConstraints(ctx, path, src, cfg)
+	var syntaxErr *SyntaxError
+	if err != nil && cfg.AllowPartial && errors.As(err, &syntaxErr) {
+		return formatGoPartial(ctx, path, src, cfg, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return restoreOffRegions(src, restoreCgoPreambles(src, formatted), goFormatOff, goFormatOn)
+}
+
+// formatGoConstraints formats src, treating its build constraints as the
+// configuration asks.
+func formatGoConstraints(ctx context.Context, path string, src []byte, cfg GoConfig) ([]byte, error) {
+	if cfg.BuildConstraints == BuildConstraintsLeaveAlone {
+		formatted, err := formatGo(ctx, path, hideBuildConstraints(src), cfg)
+		if err != nil {
+			return nil, err
+		}
+		return revealBuildConstraints(formatted), nil
+	}
+	formatted, err := formatGo(ctx, path, src, cfg)
+	if err != nil || cfg.BuildConstraints != BuildConstraintsModernOnly {
+		return formatted, err
+	}
+	debugf(ctx, "dropping // +build lines")
+	return dropPlusBuildLines(path, formatted)
+}
+
+// formatGo rewrites, prints and arranges the imports of src, leaving build
+// constraints to the printer.
+func formatGo(ctx context.Context, path string, src []byte, cfg GoConfig) ([]byte, error) {
+	var formatted []byte
+	var err error
+	if len(cfg.RewriteRules) > 0 {
+		debugf(ctx, "applying %d rewrite rules", len(cfg.RewriteRules))
+		if src, err = rewriteGo(path, src, cfg.RewriteRules); err != nil {
+			return nil, err
+		}
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Simplify {
+		debugf(ctx, "simplifying")
+		if src, err = simplifyGo(path, src); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Style == GoStyleGofumpt {
+		debugf(ctx, "applying gofumpt rules")
+		formatted, err = gofumptSource(src)
+	} else {
+		formatted, err = gofmt.Source(src)
+	}
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	if cfg.OrganizeImports {
+		debugf(ctx, "organizing imports")
+		if formatted, err = organizeImports(ctx, path, formatted); err != nil {
+			return nil, err
+		}
+	}
+	if formatted, err = tidyImportComments(path, formatted, cfg); err != nil {
+		return nil, err
+	}
+	if formatted, err = applyGoStyleRules(path, formatted, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.NormalizeStructTags || cfg.AlignStructTags {
+		debugf(ctx, "normalizing struct tags")
+		if formatted, err = normalizeStructTags(path, formatted, cfg.StructTagOrder, cfg.AlignStructTags); err != nil {
+			return nil, err
+		}
+	}
+	if group, merge, ok := cfg.importGrouping(); ok {
+		debugf(ctx, "arranging imports with the %s layout", cfg.ImportLayout)
+		if formatted, err = layoutImports(path, formatted, group, merge); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.WrapLongLines {
+		debugf(ctx, "wrapping lines longer than %d columns", cfg.LineWidth)
+		if formatted, err = wrapGoLines(path, formatted, cfg.LineWidth); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ReflowComments {
+		debugf(ctx, "reflowing comments to %d columns", cfg.LineWidth)
+		return reflowGoComments(path, formatted, cfg.LineWidth)
+	}
+	return formatted, nil
+}
+
+// FormatGoRange formats the top-level declarations of src that overlap the
+// byte range [start, end), leaving the rest of the file untouched. Doc
+// comments belong to their declaration. ErrRangeUnsupported is returned
+// when the range covers no declaration, such as the package clause.
+func FormatGoRange(src []byte, start, end int) ([]byte, error) {
+	return FormatGoRangeContext(context.Background(), "", src, start, end, DefaultGoConfig())
+}
+
+// goRangePackageClause precedes the declarations FormatGoRangeContext
+// prints so that they form a file.
+const goRangePackageClause = "package p\n\n"
+
+// FormatGoRangeContext is like FormatGoRange but formats in the configured
+// style and reports syntax errors against path. Imports are not organized,
+// since the code using them may be outside the range.
+func FormatGoRangeContext(ctx context.Context, path string, src []byte, start, end int, cfg GoConfig) ([]byte, error) {
+	if cfg.SkipGenerated && isGeneratedGo(src) {
+		return src, nil
+	}
+	cfg.OrganizeImports = false
+	spans, err := goDeclSpans(path, src)
+	if err != nil {
+		return nil, err
+	}
+	first, last := -1, -1
+	for i, s := range spans {
+		if s.overlaps(start, end) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return nil, ErrRangeUnsupported
+	}
+
+	// Only the covered declarations are printed, after a package clause of
+	// their own, so the rest of the file is left byte-identical.
+	target := span{start: spans[first].start, end: spans[last].end}
+	snippet := append([]byte(goRangePackageClause), src[target.start:target.end]...)
+	formatted, err := FormatGoContext(ctx, path, snippet, cfg)
+	if err != nil {
+		delta := bytes.Count(src[:target.start], []byte("\n")) - strings.Count(goRangePackageClause, "\n")
+		return nil, shiftSyntaxErrors(err, delta)
+	}
+	formattedSpans, err := goDeclSpans(path, formatted)
+	if err != nil || len(formattedSpans) != last-first+1 {
+		return nil, ErrRangeUnsupported
+	}
+	replacement := formatted[formattedSpans[0].start:formattedSpans[len(formattedSpans)-1].end]
+	return splice(src, target, replacement), nil
+}
+
+// maxGofumptRuns bounds how often gofumptSource applies gofumpt's rules.
+const maxGofumptRuns = 3
+
+// gofumptSource applies gofumpt's rules to src. A one-line function that
+// gofumpt splits only gets its empty line before the next declaration on
+// the following run, so the rules are applied again until the output
+// stops changing.
+func gofumptSource(src []byte) ([]byte, error) {
+	formatted, err := gofumpt.Source(src, gofumpt.Options{})
+	for range maxGofumptRuns - 1 {
+		if err != nil || bytes.Equal(formatted, src) {
+			break
+		}
+		src = formatted
+		formatted, err = gofumpt.Source(src, gofumpt.Options{})
+	}
+	return formatted, err
+}
+
+// isGeneratedGo reports whether src carries the comment marking generated
+// files, "// Code generated ... DO NOT EDIT.", before its package clause.
+// Files whose package clause does not parse are not.
+func isGeneratedGo(src []byte) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly|parser.ParseComments)
+	return err == nil && ast.IsGenerated(file)
+}
+
+// goDeclSpans returns the span of every top-level declaration in src,
+// including its doc comment. Syntax errors are reported against path.
+func goDeclSpans(path string, src []byte) ([]span, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	spans := make([]span, 0, len(file.Decls))
+	for _, decl := range file.Decls {
+		pos := decl.Pos()
+		if doc := declDoc(decl); doc != nil {
+			pos = doc.Pos()
+		}
+		spans = append(spans, span{
+			start: fset.Position(pos).Offset,
+			end:   fset.Position(decl.End()).Offset,
+		})
+	}
+	return spans, nil
+}
+
+// declDoc returns the doc comment of a top-level declaration.
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,297 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestFormatGo_Formats_Source verifies that FormatGo produces canonical
+// gofmt output for malformed input and leaves formatted input untouched.
+func TestFormatGo_Formats_Source(t *testing.T) {
+	bad := []byte("package main\nimport \"fmt\"\nfunc main(){fmt.Println(\"ok\")}\n")
+	want := []byte("package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println(\"ok\") }\n")
+
+	got, err := FormatGo(bad)
+	if err != nil {
+		t.Fatalf("FormatGo: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("FormatGo mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+
+	again, err := FormatGo(got)
+	if err != nil {
+		t.Fatalf("FormatGo second pass: %v", err)
+	}
+	if !bytes.Equal(again, got) {
+		t.Fatalf("FormatGo not idempotent")
+	}
+}
+
+// TestFormatGo_Reports_Syntax_Errors verifies that unparsable input is
+// reported as an error rather than returned unchanged.
+func TestFormatGo_Reports_Syntax_Errors(t *testing.T) {
+	if _, err := FormatGo([]byte("package main\nfunc {")); err == nil {
+		t.Fatalf("FormatGo accepted invalid source")
+	}
+}
+
+// TestFormatGoContext_Applies_Gofumpt_Style verifies that the gofumpt style
+// applies rules gofmt leaves alone and that gofmt output is unchanged by
+// default.
+func TestFormatGoContext_Applies_Gofumpt_Style(t *testing.T) {
+	src := "package main\n\nimport (\n\t\"example.com/x\"\n\t\"fmt\"\n)\n\n" +
+		"func main() {\n\n\tfmt.Println(x.Y)\n\n}\n"
+
+	got, err := FormatGoContext(context.Background(), "main.go", []byte(src), DefaultGoConfig())
+	if err != nil {
+		t.Fatalf("FormatGoContext gofmt: %v", err)
+	}
+	if string(got) != src {
+		t.Fatalf("FormatGoContext gofmt = %q; want %q", got, src)
+	}
+
+	got, err = FormatGoContext(context.Background(), "main.go", []byte(src), GoConfig{Style: GoStyleGofumpt})
+	if err != nil {
+		t.Fatalf("FormatGoContext gofumpt: %v", err)
+	}
+	want := "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/x\"\n)\n\n" +
+		"func main() {\n\tfmt.Println(x.Y)\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoContext gofumpt = %q; want %q", got, want)
+	}
+}
+
+// TestFormatGoContext_Gofumpt_Style_Is_Idempotent verifies that gofumpt
+// output is left alone when formatted again, across the rules that move
+// lines, imports and comments around, including a one-line function that
+// gofumpt splits and only separates from the next one on its second run.
+func TestFormatGoContext_Gofumpt_Style_Is_Idempotent(t *testing.T) {
+	src := "package main\n\nimport (\n\t\"example.com/x\"\n\t\"fmt\"\n\t\"os\"\n)\n\n" +
+		"var a = 1\nvar b = 2\n\n" +
+		"func run(ctx interface{},\n\tname string, mode os.FileMode) (err error) {\n\n" +
+		"\tvar (\n\t\tn = 0755\n\t)\n" +
+		"\tif err := os.Chmod(name, mode|os.FileMode(n)); err != nil { // keep\n\n\t\treturn err\n\n\t}\n" +
+		"\tswitch {\n\tcase a > b:\n\n\t\tfmt.Println(x.Y)\n\t}\n\treturn\n}\n\n" +
+		"func TestRunChangesTheModeOfTheFile(t *testing.T) { run(t, testRunChangesTheModeOfTheFile, os.ModePerm) }\n" +
+		"func other() {\n\t//comment\n}\n"
+	cfg := GoConfig{Style: GoStyleGofumpt}
+
+	got, err := FormatGoContext(context.Background(), "main.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext gofumpt: %v", err)
+	}
+	again, err := FormatGoContext(context.Background(), "main.go", got, cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext gofumpt second pass: %v", err)
+	}
+	if !bytes.Equal(again, got) {
+		t.Fatalf("FormatGoContext gofumpt not idempotent\n--- first ---\n%s\n--- second ---\n%s", got, again)
+	}
+}
+
+// TestGoConfig_Validate_Rejects_Bad_Values verifies that only the known
+// styles and well-formed rewrite rules are accepted.
+func TestGoConfig_Validate_Rejects_Bad_Values(t *testing.T) {
+	valid := GoConfig{Style: GoStyleGofumpt, RewriteRules: []string{"interface{} -> any", "a[b:len(a)] -> a[b:]"}}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate valid config: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  GoConfig
+		want string
+	}{
+		{"style", GoConfig{Style: "goimports"}, "style: must be one of gofmt, gofumpt"},
+		{
+			"no arrow",
+			GoConfig{Style: GoStyleGofmt, RewriteRules: []string{"a + b"}},
+			`rewriteRules: "a + b" must have the form pattern -> replacement`,
+		},
+		{
+			"bad pattern",
+			GoConfig{Style: GoStyleGofmt, RewriteRules: []string{"a + -> b"}},
+			`rewriteRules: pattern of "a + -> b" is not an expression`,
+		},
+		{
+			"empty prefix",
+			GoConfig{Style: GoStyleGofmt, LocalPrefixes: []string{""}},
+			"localPrefixes: must not contain an empty prefix",
+		},
+		{
+			"build constraints",
+			GoConfig{Style: GoStyleGofmt, BuildConstraints: "modern"},
+			"buildConstraints: must be one of sync, modern-only, leave-alone",
+		},
+		{
+			"line width",
+			GoConfig{Style: GoStyleGofmt, ReflowComments: true},
+			"lineWidth: must be positive",
+		},
+		{
+			"struct tag order",
+			GoConfig{Style: GoStyleGofmt, StructTagOrder: []string{"json", "json"}},
+			`structTagOrder: "json" is listed twice`,
+		},
+		{
+			"octal literal style",
+			GoConfig{Style: GoStyleGofmt, OctalLiteralStyle: "0o"},
+			"octalLiteralStyle: must be one of keep, modern, legacy",
+		},
+		{
+			"import comments",
+			GoConfig{Style: GoStyleGofmt, ImportComments: "drop"},
+			"importComments: must be one of keep, normalize, strip",
+		},
+		{
+			"hex digit case",
+			GoConfig{Style: GoStyleGofmt, HexDigitCase: "mixed"},
+			"hexDigitCase: must be one of keep, lower, upper",
+		},
+		{
+			"digit separators",
+			GoConfig{Style: GoStyleGofmt, DigitSeparators: "thousands"},
+			"digitSeparators: must be one of keep, strip, group",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("Validate = %v; want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatGoContext_Applies_Rewrite_Rules verifies that rewrite rules
+// behave like gofmt -r, including wildcards, and run in order.
+func TestFormatGoContext_Applies_Rewrite_Rules(t *testing.T) {
+	src := "package p\n\n// F takes anything.\nfunc F(v interface{}, s []int) []int {\n\treturn s[1:len(s)] // tail\n}\n"
+	cfg := GoConfig{Style: GoStyleGofmt, RewriteRules: []string{"interface{} -> any", "a[b:len(a)] -> a[b:]"}}
+
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	want := "package p\n\n// F takes anything.\nfunc F(v any, s []int) []int {\n\treturn s[1:] // tail\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoContext = %q; want %q", got, want)
+	}
+}
+
+// TestFormatGoRange_Formats_Overlapping_Declarations verifies that only the
+// declarations touched by the range are formatted.
+func TestFormatGoRange_Formats_Overlapping_Declarations(t *testing.T) {
+	src := "package main\n\nfunc a(){ }\n\n// b does b.\nfunc b(){ }\n"
+	start := strings.Index(src, "// b")
+
+	got, err := FormatGoRange([]byte(src), start+3, start+4)
+	if err != nil {
+		t.Fatalf("FormatGoRange: %v", err)
+	}
+	want := "package main\n\nfunc a(){ }\n\n// b does b.\nfunc b() {}\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoRange = %q; want %q", got, want)
+	}
+}
+
+// TestFormatGoRange_Rejects_Ranges_Without_Declarations verifies that a
+// range inside the package clause asks for a whole-file format.
+func TestFormatGoRange_Rejects_Ranges_Without_Declarations(t *testing.T) {
+	_, err := FormatGoRange([]byte("package main\n\nfunc a(){ }\n"), 0, 3)
+	if !errors.Is(err, ErrRangeUnsupported) {
+		t.Fatalf("FormatGoRange error = %v; want %v", err, ErrRangeUnsupported)
+	}
+}
+
+// TestFormatGoRangeContext_Reprints_Only_Covered_Declarations verifies that
+// a range spanning several declarations prints them, and what lies between
+// them, with the configured options while the imports and the declarations
+// outside the range stay byte-identical.
+func TestFormatGoRangeContext_Reprints_Only_Covered_Declarations(t *testing.T) {
+	src := "package main\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)\n\n" +
+		"type T struct{ A int `yaml:\"a\" json:\"a\"` }\n\n// between\n\nvar  x = 1\n\nfunc c(){ }\n"
+	start, end := strings.Index(src, "type"), strings.Index(src, "var")+3
+	cfg := DefaultGoConfig()
+	cfg.NormalizeStructTags = true
+
+	got, err := FormatGoRangeContext(context.Background(), "main.go", []byte(src), start, end, cfg)
+	if err != nil {
+		t.Fatalf("FormatGoRangeContext: %v", err)
+	}
+	want := "package main\n\nimport (\n\t\"os\"\n\t\"fmt\"\n)\n\n" +
+		"type T struct {\n\tA int `json:\"a\" yaml:\"a\"`\n}\n\n// between\n\nvar x = 1\n\nfunc c(){ }\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoRangeContext = %q; want %q", got, want)
+	}
+}
+
+// TestFormatGoRangeContext_Keeps_Imports_Used_Outside_The_Range verifies
+// that organizeImports does not drop an import that only declarations
+// outside the range use.
+func TestFormatGoRangeContext_Keeps_Imports_Used_Outside_The_Range(t *testing.T) {
+	src := "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc a(){ fmt.Println() }\n\nfunc b() { os.Exit(1) }\n"
+	cfg := DefaultGoConfig()
+	cfg.OrganizeImports = true
+
+	got, err := FormatGoRangeContext(context.Background(), "main.go", []byte(src), 0, strings.Index(src, "func b"), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoRangeContext: %v", err)
+	}
+	want := "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc a() { fmt.Println() }\n\nfunc b() { os.Exit(1) }\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoRangeContext = %q; want %q", got, want)
+	}
+}
+
+// TestFormatGoContext_Skips_Generated_Files verifies that skipGenerated
+// leaves generated files, and ranges of them, as they are.
+func TestFormatGoContext_Skips_Generated_Files(t *testing.T) {
+	src := []byte("// Code generated by stringer. DO NOT EDIT.\n\npackage p\nvar  x=1\n")
+	cfg := DefaultGoConfig()
+	cfg.SkipGenerated = true
+
+	got, err := FormatGoContext(context.Background(), "p.go", src, cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	if string(got) != string(src) {
+		t.Fatalf("FormatGoContext = %q; want it unchanged", got)
+	}
+	got, err = FormatGoRangeContext(context.Background(), "p.go", src, 0, len(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoRangeContext: %v", err)
+	}
+	if string(got) != string(src) {
+		t.Fatalf("FormatGoRangeContext = %q; want it unchanged", got)
+	}
+}
+
+// TestFormatGoContext_Formats_Partial_Files verifies that allowPartial
+// formats the declarations before a syntax error, keeps the rest and its
+// imports as written, and still fails when nothing before the error parses.
+func TestFormatGoContext_Formats_Partial_Files(t *testing.T) {
+	cfg := DefaultGoConfig()
+	cfg.AllowPartial, cfg.OrganizeImports = true, true
+
+	src := "package p\n\nimport  \"fmt\"\n\nfunc  a() {\n}\n\nfunc b() {\n\tfmt.Println(  1)\n"
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	want := "package p\n\nimport \"fmt\"\n\nfunc a() {\n}\n\nfunc b() {\n\tfmt.Println(  1)\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoContext = %q; want %q", got, want)
+	}
+
+	_, err = FormatGoContext(context.Background(), "p.go", []byte("package p\n\nfunc a( {\n}\n"), cfg)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("FormatGoContext error = %v; want a SyntaxError", err)
+	}
+}
@@ -0,0 +1,127 @@
+package formatters
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Values of GoConfig.ImportComments.
+const (
+	// ImportCommentsKeep leaves import comments as written. An empty value
+	// means the same.
+	ImportCommentsKeep = "keep"
+	// ImportCommentsNormalize rewrites import comments in the canonical
+	// form, // import "path".
+	ImportCommentsNormalize = "normalize"
+	// ImportCommentsStrip removes import comments. Modules declare their
+	// path in go.mod, so the go command ignores them in module mode.
+	ImportCommentsStrip = "strip"
+)
+
+// importCommentModes lists the accepted values of GoConfig.ImportComments.
+var importCommentModes = []string{ //nolint:gochecknoglobals // read-only lookup
+	ImportCommentsKeep,
+	ImportCommentsNormalize,
+	ImportCommentsStrip,
+}
+
+// importCommentEdits normalizes or strips the import comment of file, the
+// // import "path" comment on the line of its package clause, in the given
+// mode.
+func importCommentEdits(fset *token.FileSet, file *ast.File, mode string) []goEdit {
+	line := fset.Position(file.Name.End()).Line
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if c.Pos() < file.Name.End() || fset.Position(c.Pos()).Line != line {
+				continue
+			}
+			importPath, ok := importCommentPath(c.Text)
+			if !ok {
+				continue
+			}
+			start, end := fset.Position(c.Pos()).Offset, fset.Position(c.End()).Offset
+			if mode == ImportCommentsStrip {
+				return []goEdit{{span{fset.Position(file.Name.End()).Offset, end}, ""}}
+			}
+			if text := "// import " + strconv.Quote(importPath); text != c.Text {
+				return []goEdit{{span{start, end}, text}}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// importCommentPath returns the path of an import comment, such as
+// // import "example.com/pkg" or /* import `example.com/pkg` */.
+func importCommentPath(text string) (string, bool) {
+	body, isLine := strings.CutPrefix(text, "//")
+	if !isLine {
+		body = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	}
+	rest, ok := strings.CutPrefix(strings.TrimLeft(body, " \t"), "import")
+	if !ok || rest == "" || rest[0] != ' ' && rest[0] != '\t' {
+		return "", false
+	}
+	rest = strings.TrimSpace(rest)
+	if rest == "" || rest[0] != '"' && rest[0] != '`' {
+		return "", false
+	}
+	importPath, err := strconv.Unquote(rest)
+	return importPath, err == nil
+}
+
+// emptyImportCommentEdits removes the comments of import specs that hold no
+// text, such as a bare // left behind when the text was deleted.
+func emptyImportCommentEdits(fset *token.FileSet, file *ast.File, src []byte) []goEdit {
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	var edits []goEdit
+	for _, spec := range file.Imports {
+		if isEmptyCommentGroup(spec.Doc) {
+			edits = append(edits, lineEdit(src, offset(spec.Doc.Pos()), offset(spec.Doc.End())))
+		}
+		if isEmptyCommentGroup(spec.Comment) {
+			edits = append(edits, goEdit{span{offset(spec.Path.End()), offset(spec.Comment.End())}, ""})
+		}
+	}
+	return edits
+}
+
+// isEmptyCommentGroup reports whether every comment of a group is empty.
+func isEmptyCommentGroup(group *ast.CommentGroup) bool {
+	if group == nil {
+		return false
+	}
+	for _, c := range group.List {
+		body, isLine := strings.CutPrefix(c.Text, "//")
+		if !isLine {
+			body = strings.TrimSuffix(strings.TrimPrefix(c.Text, "/*"), "*/")
+		}
+		if strings.TrimSpace(body) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// tidyImportComments normalizes or strips the import comment and removes
+// empty import spec comments as cfg asks. src must be gofmt output.
+func tidyImportComments(path string, src []byte, cfg GoConfig) ([]byte, error) {
+	var err error
+	if cfg.ImportComments == ImportCommentsNormalize || cfg.ImportComments == ImportCommentsStrip {
+		src, err = applyGoRule(path, src, func(fset *token.FileSet, file *ast.File) []goEdit {
+			return importCommentEdits(fset, file, cfg.ImportComments)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.RemoveEmptyImportComments {
+		return applyGoRule(path, src, func(fset *token.FileSet, file *ast.File) []goEdit {
+			return emptyImportCommentEdits(fset, file, src)
+		})
+	}
+	return src, nil
+}
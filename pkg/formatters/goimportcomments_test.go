@@ -0,0 +1,76 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatGoContext_Tidies_Import_Comments verifies that import comments
+// are kept, normalized or stripped, and that empty import spec comments
+// are removed on request.
+func TestFormatGoContext_Tidies_Import_Comments(t *testing.T) {
+	src := "package p /* import `example.com/p` */\n\nimport (\n\t//\n\t\"fmt\" //\n\t\"os\"  // exit\n)\n\n" +
+		"var _, _ = fmt.Sprint, os.Exit\n"
+	tests := []struct {
+		name string
+		cfg  func(*GoConfig)
+		want string
+	}{
+		{
+			name: "keep",
+			cfg:  func(*GoConfig) {},
+			want: src,
+		},
+		{
+			name: "normalize",
+			cfg:  func(c *GoConfig) { c.ImportComments = ImportCommentsNormalize },
+			want: "package p // import \"example.com/p\"\n\nimport (\n\t//\n\t\"fmt\" //\n\t\"os\"  // exit\n)\n\n" +
+				"var _, _ = fmt.Sprint, os.Exit\n",
+		},
+		{
+			name: "strip",
+			cfg:  func(c *GoConfig) { c.ImportComments = ImportCommentsStrip },
+			want: "package p\n\nimport (\n\t//\n\t\"fmt\" //\n\t\"os\"  // exit\n)\n\n" +
+				"var _, _ = fmt.Sprint, os.Exit\n",
+		},
+		{
+			name: "remove empty import comments",
+			cfg:  func(c *GoConfig) { c.RemoveEmptyImportComments = true },
+			want: "package p /* import `example.com/p` */\n\nimport (\n\t\"fmt\"\n\t\"os\" // exit\n)\n\n" +
+				"var _, _ = fmt.Sprint, os.Exit\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultGoConfig()
+			tt.cfg(&cfg)
+			got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatGoContext: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatGoContext = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestImportCommentPath_Recognizes_Import_Comments verifies which comments
+// count as import comments.
+func TestImportCommentPath_Recognizes_Import_Comments(t *testing.T) {
+	tests := map[string]string{
+		`// import "a/b"`:     "a/b",
+		"//import `a/b`":      "a/b",
+		`/* import "a/b" */`:  "a/b",
+		`// important "a/b"`:  "",
+		`// import a/b`:       "",
+		`// import 'a'`:       "",
+		`// imports are here`: "",
+	}
+	for text, want := range tests {
+		if got, ok := importCommentPath(text); got != want || ok != (want != "") {
+			t.Fatalf("importCommentPath(%q) = %q, %v; want %q", text, got, ok, want)
+		}
+	}
+}
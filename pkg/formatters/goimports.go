@@ -0,0 +1,275 @@
+package formatters
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"go/ast"
+	gofmt "go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Values of GoConfig.ImportLayout.
+const (
+	// ImportLayoutRuns keeps imports in the runs the file separates with
+	// blank lines, sorting each run like gofmt and, with local prefixes,
+	// grouping it like goimports -local. An empty layout means the same.
+	ImportLayoutRuns = "runs"
+	// ImportLayoutSingle merges the imports of a declaration into one
+	// sorted group.
+	ImportLayoutSingle = "single"
+	// ImportLayoutSections merges the imports of a declaration and groups
+	// them by GoConfig.ImportSections.
+	ImportLayoutSections = "sections"
+)
+
+// Kinds of import sections.
+const (
+	importSectionStandard = "standard"
+	importSectionDefault  = "default"
+	importSectionPrefix   = "prefix"
+)
+
+// importLayouts lists the accepted values of GoConfig.ImportLayout.
+var importLayouts = []string{ImportLayoutRuns, ImportLayoutSingle, ImportLayoutSections} //nolint:gochecknoglobals // read-only lookup
+
+// importSection is a group of imports: the standard library, every import
+// no other section claims, or the imports matching one of some prefixes.
+type importSection struct {
+	kind     string
+	prefixes []string
+}
+
+// importChunk is an import spec with its doc and line comments, as the
+// lines of formatted source that hold them.
+type importChunk struct {
+	group     int
+	path      string
+	text      string
+	startLine int
+	endLine   int
+}
+
+// parseImportSections parses sections written as standard, default or
+// prefix(<import path prefix>). Both standard and default must be listed.
+func parseImportSections(sections []string) ([]importSection, error) {
+	parsed := make([]importSection, 0, len(sections))
+	var errs []error
+	for i, s := range sections {
+		if slices.Contains(sections[:i], s) {
+			errs = append(errs, fmt.Errorf("%q is listed twice", s))
+			continue
+		}
+		prefix, isPrefix := strings.CutPrefix(s, importSectionPrefix+"(")
+		prefix, closed := strings.CutSuffix(prefix, ")")
+		switch {
+		case s == importSectionStandard || s == importSectionDefault:
+			parsed = append(parsed, importSection{kind: s})
+		case isPrefix && closed && prefix != "":
+			parsed = append(parsed, importSection{kind: importSectionPrefix, prefixes: []string{prefix}})
+		default:
+			errs = append(errs, fmt.Errorf("%q must be standard, default or prefix(<path>)", s))
+		}
+	}
+	for _, required := range []string{importSectionStandard, importSectionDefault} {
+		if !slices.Contains(sections, required) {
+			errs = append(errs, fmt.Errorf("must include %s", required))
+		}
+	}
+	return parsed, errors.Join(errs...)
+}
+
+// importSections returns the configured sections, or standard, default
+// and the local prefixes when none are configured.
+func (c GoConfig) importSections() []importSection {
+	if len(c.ImportSections) > 0 {
+		sections, _ := parseImportSections(c.ImportSections)
+		return sections
+	}
+	sections := []importSection{{kind: importSectionStandard}, {kind: importSectionDefault}}
+	if len(c.LocalPrefixes) > 0 {
+		sections = append(sections, importSection{kind: importSectionPrefix, prefixes: c.LocalPrefixes})
+	}
+	return sections
+}
+
+// importGrouping returns how the configured layout assigns imports to
+// groups and whether it merges the runs of a declaration. It reports false
+// when the layout leaves imports as gofmt sorted them. The runs layout
+// groups imports by section when local prefixes are set or standard
+// library imports are to be grouped.
+func (c GoConfig) importGrouping() (func(importPath string) int, bool, bool) {
+	switch c.ImportLayout {
+	case ImportLayoutSingle:
+		return func(string) int { return 0 }, true, true
+	case ImportLayoutSections:
+		return sectionOf(c.importSections()), true, true
+	default:
+		if len(c.LocalPrefixes) == 0 && !c.GroupStdImports {
+			return nil, false, false
+		}
+		return sectionOf(c.importSections()), false, true
+	}
+}
+
+// sectionOf returns a function that finds the section of an import path.
+// The longest matching prefix wins; other paths go to the standard section
+// if their first element has no dot, like goimports decides, and to the
+// default section otherwise.
+func sectionOf(sections []importSection) func(importPath string) int {
+	return func(importPath string) int {
+		best, bestLen := -1, -1
+		for i, s := range sections {
+			for _, prefix := range s.prefixes {
+				matches := strings.HasPrefix(importPath, prefix) || strings.TrimSuffix(prefix, "/") == importPath
+				if matches && len(prefix) > bestLen {
+					best, bestLen = i, len(prefix)
+				}
+			}
+		}
+		if best >= 0 {
+			return best
+		}
+		kind := importSectionDefault
+		if isStdlibPath(importPath) {
+			kind = importSectionStandard
+		}
+		return slices.IndexFunc(sections, func(s importSection) bool { return s.kind == kind })
+	}
+}
+
+// isStdlibPath reports whether an import path belongs to the standard
+// library, judging like goimports by whether its first element has a dot.
+func isStdlibPath(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// layoutImports arranges the imports of every parenthesized import
+// declaration. Imports are sorted by group and path within every run of
+// imports not separated by a blank line, or within the whole declaration
+// when merge is set, and groups are separated by a blank line. Import
+// declarations holding comments of their own or import "C" are left alone.
+// src must be gofmt output.
+func layoutImports(path string, src []byte, group func(importPath string) int, merge bool) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	line := func(pos token.Pos) int { return fset.Position(pos).Line - 1 }
+
+	var out bytes.Buffer
+	next := 0
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Lparen.IsValid() {
+			continue
+		}
+		if hasOwnComments(gen, file.Comments) || hasCgoImport(gen) {
+			continue
+		}
+		chunks := importChunks(gen, lines, line, group)
+		if len(chunks) == 0 || chunks[0].startLine <= line(gen.Lparen) {
+			continue
+		}
+		first, last := chunks[0].startLine, chunks[len(chunks)-1].endLine
+		for _, l := range lines[next:first] {
+			out.Write(l)
+		}
+		out.WriteString(regroupImports(chunks, merge))
+		next = last + 1
+	}
+	if next == 0 {
+		return src, nil
+	}
+	for _, l := range lines[next:] {
+		out.Write(l)
+	}
+	formatted, err := gofmt.Source(out.Bytes())
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	return formatted, nil
+}
+
+// hasOwnComments reports whether an import declaration holds comments that
+// are neither the doc nor the line comment of one of its specs.
+func hasOwnComments(decl *ast.GenDecl, comments []*ast.CommentGroup) bool {
+	attached := map[*ast.CommentGroup]bool{}
+	for _, spec := range decl.Specs {
+		imp := spec.(*ast.ImportSpec)
+		attached[imp.Doc], attached[imp.Comment] = true, true
+	}
+	for _, c := range comments {
+		if c.Pos() > decl.Lparen && c.End() < decl.Rparen && !attached[c] {
+			return true
+		}
+	}
+	return false
+}
+
+// importChunks returns the specs of an import declaration with the lines
+// they span.
+func importChunks(
+	decl *ast.GenDecl,
+	lines [][]byte,
+	line func(token.Pos) int,
+	group func(importPath string) int,
+) []importChunk {
+	chunks := make([]importChunk, 0, len(decl.Specs))
+	for _, spec := range decl.Specs {
+		imp := spec.(*ast.ImportSpec)
+		start, end := imp.Pos(), imp.End()
+		if imp.Doc != nil {
+			start = imp.Doc.Pos()
+		}
+		if imp.Comment != nil {
+			end = imp.Comment.End()
+		}
+		importPath, _ := strconv.Unquote(imp.Path.Value)
+		chunk := importChunk{
+			group:     group(importPath),
+			path:      importPath,
+			startLine: line(start),
+			endLine:   line(end),
+		}
+		chunk.text = string(bytes.Join(lines[chunk.startLine:chunk.endLine+1], nil))
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// regroupImports renders the chunks of one import declaration, sorting
+// every run of adjacent chunks, or all of them when merge is set, by group
+// and path and separating the groups of a run with a blank line.
+func regroupImports(chunks []importChunk, merge bool) string {
+	var b strings.Builder
+	for start := 0; start < len(chunks); {
+		end := start + 1
+		for end < len(chunks) && (merge || chunks[end].startLine == chunks[end-1].endLine+1) {
+			end++
+		}
+		if start > 0 {
+			b.WriteString("\n")
+		}
+		run := slices.Clone(chunks[start:end])
+		slices.SortStableFunc(run, func(a, b importChunk) int {
+			return cmp.Or(cmp.Compare(a.group, b.group), strings.Compare(a.path, b.path))
+		})
+		for i, c := range run {
+			if i > 0 && c.group != run[i-1].group {
+				b.WriteString("\n")
+			}
+			b.WriteString(c.text)
+		}
+		start = end
+	}
+	return b.String()
+}
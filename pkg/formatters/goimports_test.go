@@ -0,0 +1,123 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatGoContext_Groups_Local_Imports verifies that local imports get
+// their own trailing group within each run of imports, as with goimports
+// -local, and that comments travel with their import.
+func TestFormatGoContext_Groups_Local_Imports(t *testing.T) {
+	cfg := GoConfig{Style: GoStyleGofmt, LocalPrefixes: []string{"github.com/acme/"}}
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "one run",
+			src: "package p\n\nimport (\n\t\"github.com/acme/b\"\n\t\"fmt\"\n" +
+				"\t// x is third-party.\n\t\"example.com/x\"\n\t\"github.com/acme/a\" // a\n)\n",
+			want: "package p\n\nimport (\n\t\"fmt\"\n\n\t// x is third-party.\n\t\"example.com/x\"\n\n" +
+				"\t\"github.com/acme/a\" // a\n\t\"github.com/acme/b\"\n)\n",
+		},
+		{
+			name: "separate runs",
+			src: "package p\n\nimport (\n\t\"fmt\"\n\t\"github.com/acme/a\"\n\n" +
+				"\t\"example.com/x\"\n)\n",
+			want: "package p\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/acme/a\"\n\n" +
+				"\t\"example.com/x\"\n)\n",
+		},
+		{
+			name: "floating comment",
+			src: "package p\n\nimport (\n\t\"github.com/acme/a\"\n\n\t// Standard library.\n\n" +
+				"\t\"fmt\"\n)\n",
+			want: "package p\n\nimport (\n\t\"github.com/acme/a\"\n\n\t// Standard library.\n\n" +
+				"\t\"fmt\"\n)\n",
+		},
+		{
+			name: "single import",
+			src:  "package p\n\nimport \"github.com/acme/a\"\n",
+			want: "package p\n\nimport \"github.com/acme/a\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatGoContext(context.Background(), "p.go", []byte(tt.src), cfg)
+			if err != nil {
+				t.Fatalf("FormatGoContext: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatGoContext = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatGoContext_Applies_Import_Layouts verifies the single and
+// sections layouts, which merge the runs of a declaration.
+func TestFormatGoContext_Applies_Import_Layouts(t *testing.T) {
+	src := "package p\n\nimport (\n\t\"github.com/acme/a\"\n\t\"os\"\n\n" +
+		"\t\"example.com/x\"\n\t\"github.com/acme/tools/b\"\n\n\t\"fmt\"\n)\n"
+	tests := []struct {
+		name string
+		cfg  GoConfig
+		want string
+	}{
+		{
+			name: "single",
+			cfg:  GoConfig{Style: GoStyleGofmt, ImportLayout: ImportLayoutSingle},
+			want: "package p\n\nimport (\n\t\"example.com/x\"\n\t\"fmt\"\n\t\"github.com/acme/a\"\n" +
+				"\t\"github.com/acme/tools/b\"\n\t\"os\"\n)\n",
+		},
+		{
+			name: "local prefixes",
+			cfg: GoConfig{
+				Style:         GoStyleGofmt,
+				ImportLayout:  ImportLayoutSections,
+				LocalPrefixes: []string{"github.com/acme/"},
+			},
+			want: "package p\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\n\t\"example.com/x\"\n\n" +
+				"\t\"github.com/acme/a\"\n\t\"github.com/acme/tools/b\"\n)\n",
+		},
+		{
+			name: "custom sections",
+			cfg: GoConfig{
+				Style:        GoStyleGofmt,
+				ImportLayout: ImportLayoutSections,
+				ImportSections: []string{
+					"prefix(github.com/acme/tools)", "standard", "default", "prefix(github.com/acme)",
+				},
+			},
+			want: "package p\n\nimport (\n\t\"github.com/acme/tools/b\"\n\n\t\"fmt\"\n\t\"os\"\n\n" +
+				"\t\"example.com/x\"\n\n\t\"github.com/acme/a\"\n)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatGoContext(context.Background(), "p.go", []byte(src), tt.cfg)
+			if err != nil {
+				t.Fatalf("FormatGoContext: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatGoContext = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGoConfig_Validate_Rejects_Bad_Import_Sections verifies that every
+// problem with the import sections is reported.
+func TestGoConfig_Validate_Rejects_Bad_Import_Sections(t *testing.T) {
+	cfg := DefaultGoConfig()
+	cfg.ImportSections = []string{"standard", "prefix()", "standard"}
+	want := "importSections: \"prefix()\" must be standard, default or prefix(<path>)\n" +
+		"importSections: \"standard\" is listed twice\n" +
+		"importSections: must include default"
+	if err := cfg.Validate(); err == nil || err.Error() != want {
+		t.Fatalf("Validate = %v; want %s", err, want)
+	}
+}
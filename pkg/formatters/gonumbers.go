@@ -0,0 +1,166 @@
+package formatters
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Values of GoConfig.HexDigitCase.
+const (
+	// HexDigitsKeep leaves the digits of hexadecimal literals as written.
+	HexDigitsKeep = "keep"
+	// HexDigitsLower writes the digits of hexadecimal literals in lower
+	// case, such as 0xff.
+	HexDigitsLower = "lower"
+	// HexDigitsUpper writes the digits of hexadecimal literals in upper
+	// case, such as 0xFF.
+	HexDigitsUpper = "upper"
+)
+
+// Values of GoConfig.DigitSeparators.
+const (
+	// DigitSeparatorsKeep leaves the _ separators of number literals as
+	// written.
+	DigitSeparatorsKeep = "keep"
+	// DigitSeparatorsStrip removes the _ separators of number literals.
+	DigitSeparatorsStrip = "strip"
+	// DigitSeparatorsGroup separates the integer digits of long number
+	// literals into groups of three, or four in hexadecimal and binary
+	// literals, and removes the separators of short ones.
+	DigitSeparatorsGroup = "group"
+)
+
+// minGroupedDigits is the number of integer digits from which
+// DigitSeparatorsGroup separates them, so that 10000 becomes 10_000 while
+// 1000 stays as it is.
+const minGroupedDigits = 5
+
+// hexDigitCases lists the accepted values of GoConfig.HexDigitCase.
+var hexDigitCases = []string{HexDigitsKeep, HexDigitsLower, HexDigitsUpper} //nolint:gochecknoglobals // read-only lookup
+
+// digitSeparatorModes lists the accepted values of GoConfig.DigitSeparators.
+var digitSeparatorModes = []string{ //nolint:gochecknoglobals // read-only lookup
+	DigitSeparatorsKeep,
+	DigitSeparatorsStrip,
+	DigitSeparatorsGroup,
+}
+
+// numberLiteral is a number literal split into the parts the options
+// rewrite: 0x in 0x1.8p+3, 1, .8, p+3 and no imaginary suffix.
+type numberLiteral struct {
+	prefix   string
+	integer  string
+	fraction string
+	exponent string
+	suffix   string
+}
+
+// numberLiteralEdits rewrites the number literals of file as cfg asks.
+func numberLiteralEdits(fset *token.FileSet, file *ast.File, cfg GoConfig) []goEdit {
+	var edits []goEdit
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT && lit.Kind != token.FLOAT && lit.Kind != token.IMAG {
+			return true
+		}
+		if text := normalizeNumber(lit.Kind, lit.Value, cfg); text != lit.Value {
+			offset := fset.Position(lit.Pos()).Offset
+			edits = append(edits, goEdit{span{offset, offset + len(lit.Value)}, text})
+		}
+		return true
+	})
+	return edits
+}
+
+// normalizeNumber rewrites a number literal of the given kind as cfg asks.
+func normalizeNumber(kind token.Token, value string, cfg GoConfig) string {
+	n := splitNumber(kind, value)
+	hex := n.prefix == "0x" || n.prefix == "0X"
+	if hex {
+		switch cfg.HexDigitCase {
+		case HexDigitsLower:
+			n.integer, n.fraction = strings.ToLower(n.integer), strings.ToLower(n.fraction)
+		case HexDigitsUpper:
+			n.integer, n.fraction = strings.ToUpper(n.integer), strings.ToUpper(n.fraction)
+		}
+	}
+	if cfg.NormalizeExponents && n.exponent != "" {
+		n.exponent = canonicalExponent(n.exponent)
+	}
+	switch cfg.DigitSeparators {
+	case DigitSeparatorsStrip:
+		n.integer = strings.ReplaceAll(n.integer, "_", "")
+		n.fraction = strings.ReplaceAll(n.fraction, "_", "")
+		n.exponent = strings.ReplaceAll(n.exponent, "_", "")
+	case DigitSeparatorsGroup:
+		size := 3
+		if hex || n.prefix == "0b" || n.prefix == "0B" {
+			size = 4
+		}
+		n.integer = groupDigits(strings.ReplaceAll(n.integer, "_", ""), size)
+	}
+	return n.prefix + n.integer + n.fraction + n.exponent + n.suffix
+}
+
+// splitNumber splits a number literal into its parts. Integer literals
+// with a leading zero are legacy octal literals, whose prefix is the zero;
+// floating-point and imaginary literals with one are decimal.
+func splitNumber(kind token.Token, value string) numberLiteral {
+	var n numberLiteral
+	if kind == token.IMAG {
+		value, n.suffix = value[:len(value)-1], "i"
+	}
+	exponentMarks := "eE"
+	switch {
+	case len(value) > 1 && value[0] == '0' && strings.ContainsRune("xXbBoO", rune(value[1])):
+		n.prefix, value = value[:2], value[2:]
+		if n.prefix == "0x" || n.prefix == "0X" {
+			exponentMarks = "pP"
+		}
+	case kind == token.INT && len(value) > 1 && value[0] == '0':
+		n.prefix, value = "0", value[1:]
+	}
+	if i := strings.IndexAny(value, exponentMarks); i >= 0 {
+		value, n.exponent = value[:i], value[i:]
+	}
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		value, n.fraction = value[:i], value[i:]
+	}
+	n.integer = value
+	return n
+}
+
+// canonicalExponent writes an exponent such as E+06 as e6: a lower case
+// mark, no plus sign and no leading zeros.
+func canonicalExponent(exponent string) string {
+	mark, digits := strings.ToLower(exponent[:1]), exponent[1:]
+	sign := ""
+	if digits != "" && (digits[0] == '+' || digits[0] == '-') {
+		if digits[0] == '-' {
+			sign = "-"
+		}
+		digits = digits[1:]
+	}
+	digits = strings.TrimLeft(strings.ReplaceAll(digits, "_", ""), "0")
+	if digits == "" {
+		digits, sign = "0", ""
+	}
+	return mark + sign + digits
+}
+
+// groupDigits separates digits into groups of size from the right when
+// there are at least minGroupedDigits of them.
+func groupDigits(digits string, size int) string {
+	if len(digits) < minGroupedDigits {
+		return digits
+	}
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%size == 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package formatters
+
+import (
+	"context"
+	"go/token"
+	"testing"
+)
+
+// TestNormalizeNumber_Applies_Options verifies every number literal option
+// on literals of each base and kind.
+func TestNormalizeNumber_Applies_Options(t *testing.T) {
+	lower := GoConfig{HexDigitCase: HexDigitsLower}
+	upper := GoConfig{HexDigitCase: HexDigitsUpper}
+	strip := GoConfig{DigitSeparators: DigitSeparatorsStrip}
+	group := GoConfig{DigitSeparators: DigitSeparatorsGroup}
+	exponents := GoConfig{NormalizeExponents: true}
+	tests := []struct {
+		kind  token.Token
+		value string
+		cfg   GoConfig
+		want  string
+	}{
+		{token.INT, "0xDeadBeef", lower, "0xdeadbeef"},
+		{token.INT, "0xDeadBeef", upper, "0xDEADBEEF"},
+		{token.FLOAT, "0x1.aBp+3", upper, "0x1.ABp+3"},
+		{token.INT, "1e6", upper, "1e6"},
+		{token.INT, "1_000_000", strip, "1000000"},
+		{token.FLOAT, "1_0.0_1e1_0", strip, "10.01e10"},
+		{token.INT, "1000000", group, "1_000_000"},
+		{token.INT, "10_00", group, "1000"},
+		{token.INT, "10000", group, "10_000"},
+		{token.INT, "0xFFFFFFFF", group, "0xFFFF_FFFF"},
+		{token.INT, "0b101010101", group, "0b1_0101_0101"},
+		{token.INT, "0_7777777", group, "07_777_777"},
+		{token.FLOAT, "1234567.891011", group, "1_234_567.891011"},
+		{token.IMAG, "1234567i", group, "1_234_567i"},
+		{token.FLOAT, "1E+06", exponents, "1e6"},
+		{token.FLOAT, "2.5e-007", exponents, "2.5e-7"},
+		{token.FLOAT, "1e-00", exponents, "1e0"},
+		{token.FLOAT, "0x1p+04", exponents, "0x1p4"},
+		{token.IMAG, "1e+2i", exponents, "1e2i"},
+	}
+	for _, tt := range tests {
+		if got := normalizeNumber(tt.kind, tt.value, tt.cfg); got != tt.want {
+			t.Fatalf("normalizeNumber(%s, %+v) = %s; want %s", tt.value, tt.cfg, got, tt.want)
+		}
+	}
+}
+
+// TestFormatGoContext_Normalizes_Number_Literals verifies that the number
+// literal options rewrite the literals of a file and leave other tokens
+// alone.
+func TestFormatGoContext_Normalizes_Number_Literals(t *testing.T) {
+	src := "package p\n\nconst (\n\tmask = 0XffFF0000 // 0XffFF0000\n\tbig  = 6_0000_0000\n\teps  = 1E-09\n)\n"
+	cfg := DefaultGoConfig()
+	cfg.HexDigitCase = HexDigitsUpper
+	cfg.DigitSeparators = DigitSeparatorsGroup
+	cfg.NormalizeExponents = true
+
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	want := "package p\n\nconst (\n\tmask = 0xFFFF_0000 // 0XffFF0000\n\tbig  = 600_000_000\n\teps  = 1e-9\n)\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoContext = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,321 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// packageFilesKey is the context key of the package file reader.
+type packageFilesKey struct{}
+
+// WithPackageFiles returns a context that lets organizeImports read the
+// other files of the Go package a file belongs to, so that names they
+// declare are not taken for missing imports. files returns the contents of
+// the .go files in the directory of the file at path, by name.
+func WithPackageFiles(ctx context.Context, files func(path string) (map[string][]byte, error)) context.Context {
+	return context.WithValue(ctx, packageFilesKey{}, files)
+}
+
+// organizeImports adds the standard library imports src is missing and
+// removes the imports it does not refer to. Imports are only added when
+// ctx lets the other files of the package be read, since any name the file
+// selects from could be declared by one of them. src must be gofmt output.
+func organizeImports(ctx context.Context, filePath string, src []byte) ([]byte, error) {
+	src, err := applyGoRule(filePath, src, func(fset *token.FileSet, file *ast.File) []goEdit {
+		declared, ok := packageDeclarations(ctx, filePath, file)
+		if !ok {
+			debugf(ctx, "not adding imports, the other files of the package cannot be read")
+			return nil
+		}
+		return missingStdlibImportEdits(fset, file, src, declared)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applyGoRule(filePath, src, func(fset *token.FileSet, file *ast.File) []goEdit {
+		return unusedImportEdits(fset, file, src)
+	})
+}
+
+// packageDeclarations returns the names declared at package level by the
+// other files of the package file belongs to, and whether ctx lets them be
+// read. Files of another package are left out, and so are test files
+// unless file is one.
+func packageDeclarations(ctx context.Context, filePath string, file *ast.File) (map[string]bool, bool) {
+	files, ok := ctx.Value(packageFilesKey{}).(func(string) (map[string][]byte, error))
+	if !ok || filePath == "" {
+		return nil, false
+	}
+	sources, err := files(filePath)
+	if err != nil {
+		debugf(ctx, "reading the package of %s: %v", filePath, err)
+		return nil, false
+	}
+	test := strings.HasSuffix(filePath, "_test.go")
+	declared := map[string]bool{}
+	for name, src := range sources {
+		if name == filepath.Base(filePath) || !strings.HasSuffix(name, ".go") || !test && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		// A file that does not parse may still declare the names parsed
+		// before its first error.
+		sibling, _ := parser.ParseFile(token.NewFileSet(), name, src, parser.SkipObjectResolution)
+		if sibling == nil || sibling.Name.Name != file.Name.Name {
+			continue
+		}
+		for _, decl := range sibling.Decls {
+			for _, name := range declaredNames(decl) {
+				declared[name] = true
+			}
+		}
+	}
+	return declared, true
+}
+
+// declaredNames returns the names a top-level declaration declares in the
+// package scope.
+func declaredNames(decl ast.Decl) []string {
+	var names []string
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv == nil {
+			names = append(names, decl.Name.Name)
+		}
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.ValueSpec:
+				for _, ident := range spec.Names {
+					names = append(names, ident.Name)
+				}
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			}
+		}
+	}
+	return names
+}
+
+// missingStdlibImportEdits adds an import for every package file refers to
+// that no import claims and that resolves to a single standard library
+// package declaring every name the file selects from it. Names declared by
+// the other files of the package, and packages that do not resolve, are
+// left for the compiler to report. Files with dot imports are left alone,
+// since the package may be a name they declare.
+func missingStdlibImportEdits(fset *token.FileSet, file *ast.File, src []byte, declared map[string]bool) []goEdit {
+	claimed := map[string]bool{}
+	for _, spec := range file.Imports {
+		name, _ := importName(spec)
+		if name == "." {
+			return nil
+		}
+		claimed[name] = true
+	}
+	used := qualifierNames(file)
+	var missing []string
+	for _, name := range slices.Sorted(maps.Keys(used)) {
+		if claimed[name] || declared[name] {
+			continue
+		}
+		if importPath, ok := resolveStdlibImport(name, used[name]); ok {
+			missing = append(missing, importPath)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	slices.Sort(missing)
+	return []goEdit{insertImportsEdit(fset, file, src, missing)}
+}
+
+// insertImportsEdit adds imports of the given standard library paths to the
+// first import declaration that is not an import of "C": to its first run
+// of standard library imports, or as a run of their own at its start. A
+// declaration of a single import is parenthesized. Without such a
+// declaration they get one of their own after the other imports or the
+// package clause, parenthesized when there is more than one.
+func insertImportsEdit(fset *token.FileSet, file *ast.File, src []byte, paths []string) goEdit {
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	lineStart := func(pos token.Pos) int { return bytes.LastIndexByte(src[:offset(pos)], '\n') + 1 }
+	var lines strings.Builder
+	for _, importPath := range paths {
+		lines.WriteString("\t" + strconv.Quote(importPath) + "\n")
+	}
+
+	after := file.Name.End()
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		after = gen.End()
+		if hasCgoImport(gen) {
+			continue
+		}
+		if !gen.Lparen.IsValid() {
+			start, end := offset(gen.Specs[0].Pos()), offset(gen.End())
+			return goEdit{span{start, end}, "(\n\t" + string(src[start:end]) + "\n" + lines.String() + ")"}
+		}
+		for _, s := range gen.Specs {
+			spec := s.(*ast.ImportSpec)
+			if importPath, err := strconv.Unquote(spec.Path.Value); err == nil && isStdlibPath(importPath) {
+				pos := spec.Pos()
+				if spec.Doc != nil {
+					pos = spec.Doc.Pos()
+				}
+				return goEdit{span{lineStart(pos), lineStart(pos)}, lines.String()}
+			}
+		}
+		open := offset(gen.Lparen) + 1
+		return goEdit{span{open, open}, "\n" + lines.String() + "\n"}
+	}
+	end := offset(after)
+	if i := bytes.IndexByte(src[end:], '\n'); i >= 0 {
+		end += i
+	}
+	if len(paths) == 1 {
+		return goEdit{span{end, end}, "\n\nimport " + strconv.Quote(paths[0])}
+	}
+	return goEdit{span{end, end}, "\n\nimport (\n" + lines.String() + ")"}
+}
+
+// unusedImportEdits removes the lines of the unused import specs of file,
+// and the declarations left empty by it. An import is used when its name
+// appears as the package of a qualified identifier. Imports without an
+// explicit name are assumed to be named like the last element of their
+// path, as goimports assumes; when the file refers to a package no import
+// claims, they are all kept, since one of them must be named differently.
+// Blank, dot and cgo imports are always kept.
+func unusedImportEdits(fset *token.FileSet, file *ast.File, src []byte) []goEdit {
+	used := qualifierNames(file)
+	unclaimed := maps.Clone(used)
+	for _, spec := range file.Imports {
+		name, _ := importName(spec)
+		delete(unclaimed, name)
+	}
+
+	unused := func(spec *ast.ImportSpec) bool {
+		name, explicit := importName(spec)
+		if name == "" || name == "_" || name == "." || name == "C" && spec.Name == nil {
+			return false
+		}
+		_, isUsed := used[name]
+		return !isUsed && (explicit || len(unclaimed) == 0)
+	}
+
+	var edits []goEdit
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		var drop []*ast.ImportSpec
+		for _, s := range gen.Specs {
+			if spec := s.(*ast.ImportSpec); unused(spec) {
+				drop = append(drop, spec)
+			}
+		}
+		if len(drop) == 0 {
+			continue
+		}
+		if len(drop) == len(gen.Specs) {
+			start := gen.Pos()
+			if gen.Doc != nil {
+				start = gen.Doc.Pos()
+			}
+			edits = append(edits, lineEdit(src, fset.Position(start).Offset, fset.Position(gen.End()).Offset))
+			continue
+		}
+		for _, spec := range drop {
+			start, end := spec.Pos(), spec.End()
+			if spec.Doc != nil {
+				start = spec.Doc.Pos()
+			}
+			if spec.Comment != nil {
+				end = spec.Comment.End()
+			}
+			edits = append(edits, lineEdit(src, fset.Position(start).Offset, fset.Position(end).Offset))
+		}
+	}
+	return edits
+}
+
+// lineEdit removes src[start:end], together with the lines it is on when
+// nothing else is on them.
+func lineEdit(src []byte, start, end int) goEdit {
+	lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+	lineEnd := len(src)
+	if i := bytes.IndexByte(src[end:], '\n'); i >= 0 {
+		lineEnd = end + i + 1
+	}
+	if len(bytes.TrimSpace(src[lineStart:start])) > 0 || len(bytes.TrimSpace(src[end:lineEnd])) > 0 {
+		return goEdit{span{start, end}, ""}
+	}
+	return goEdit{span{lineStart, lineEnd}, ""}
+}
+
+// qualifierNames returns the names used as the package of a qualified
+// identifier, the X of selectors whose X is an identifier declared nowhere
+// in the file, with the names selected from each of them.
+func qualifierNames(file *ast.File) map[string][]string {
+	names := map[string][]string{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && !slices.Contains(names[x.Name], sel.Sel.Name) {
+				names[x.Name] = append(names[x.Name], sel.Sel.Name)
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// importName returns the name an import is referred to by and whether the
+// spec gives it explicitly.
+func importName(spec *ast.ImportSpec) (string, bool) {
+	if spec.Name != nil {
+		return spec.Name.Name, true
+	}
+	importPath, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		return "", false
+	}
+	return assumedImportName(importPath), false
+}
+
+// assumedImportName guesses the package name of an import path from its
+// last element the way goimports does: a major version element such as v2
+// is skipped, a go- prefix is dropped and the name ends at the first
+// character that cannot be part of an identifier, so gopkg.in/yaml.v3 is
+// taken to be yaml.
+func assumedImportName(importPath string) string {
+	base := path.Base(importPath)
+	if dir := path.Dir(importPath); isMajorVersion(base) && dir != "." {
+		base = path.Base(dir)
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// isMajorVersion reports whether a path element is a major version suffix
+// such as v2.
+func isMajorVersion(elem string) bool {
+	major, ok := strings.CutPrefix(elem, "v")
+	_, err := strconv.Atoi(major)
+	return ok && err == nil
+}
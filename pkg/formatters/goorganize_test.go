@@ -0,0 +1,158 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatGoContext_Removes_Unused_Imports verifies that unused imports
+// and the declarations they leave empty are removed, while blank, dot, cgo
+// and used imports stay.
+func TestFormatGoContext_Removes_Unused_Imports(t *testing.T) {
+	src := "package p\n\n// #include <stdio.h>\nimport \"C\"\n\nimport \"strings\"\n\nimport (\n" +
+		"\t\"fmt\"\n\t// The OS.\n\t\"os\"\n\n\t_ \"embed\"\n\t. \"math\"\n\tyml \"gopkg.in/yaml.v3\"\n" +
+		"\t\"github.com/mattn/go-isatty\" // tty\n\t\"k8s.io/client-go/v2\"\n)\n\n" +
+		"func f() {\n\tfmt.Println(Pi, isatty.IsTerminal(0))\n}\n"
+	cfg := DefaultGoConfig()
+	cfg.OrganizeImports = true
+
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	want := "package p\n\n// #include <stdio.h>\nimport \"C\"\n\nimport (\n" +
+		"\t\"fmt\"\n\n\t_ \"embed\"\n" +
+		"\t\"github.com/mattn/go-isatty\" // tty\n\t. \"math\"\n)\n\n" +
+		"func f() {\n\tfmt.Println(Pi, isatty.IsTerminal(0))\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoContext = %q; want %q", got, want)
+	}
+}
+
+// TestFormatGoContext_Keeps_Imports_Of_Unclaimed_Packages verifies that an
+// import whose package name cannot be told from its path is kept when the
+// file refers to a package no import claims.
+func TestFormatGoContext_Keeps_Imports_Of_Unclaimed_Packages(t *testing.T) {
+	src := "package p\n\nimport (\n\t\"example.com/golib\"\n\t\"os\"\n)\n\nvar _ = lib.Name\n"
+	cfg := DefaultGoConfig()
+	cfg.OrganizeImports = true
+
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	if string(got) != src {
+		t.Fatalf("FormatGoContext = %q; want it unchanged", got)
+	}
+}
+
+// TestAssumedImportName_Follows_Goimports verifies the package names
+// assumed for import paths.
+func TestAssumedImportName_Follows_Goimports(t *testing.T) {
+	tests := map[string]string{
+		"fmt":                        "fmt",
+		"net/http":                   "http",
+		"gopkg.in/yaml.v3":           "yaml",
+		"github.com/mattn/go-isatty": "isatty",
+		"example.com/mod/v2":         "mod",
+		"v2":                         "v2",
+	}
+	for path, want := range tests {
+		if got := assumedImportName(path); got != want {
+			t.Fatalf("assumedImportName(%q) = %q; want %q", path, got, want)
+		}
+	}
+}
+
+// TestFormatGoContext_Adds_Missing_Stdlib_Imports verifies that missing
+// standard library imports are added to the standard library run of the
+// imports, to a parenthesized single import or after the package clause,
+// and that names the other files of the package declare and packages that
+// do not resolve to one package are left alone.
+func TestFormatGoContext_Adds_Missing_Stdlib_Imports(t *testing.T) {
+	body := "\nfunc f() {\n\tfmt.Println(strings.ToUpper(\"x\"), rand.IntN(2), template.HTMLEscapeString(\"\"), x.Y)\n" +
+		"\tlog.Printf(\"\")\n}\n"
+	ctx := WithPackageFiles(context.Background(), func(string) (map[string][]byte, error) {
+		return map[string][]byte{
+			"p.go":      []byte("package p\n\nvar fmt = 1\n"),
+			"log.go":    []byte("package p\n\nvar log = logrus.New()\n"),
+			"p_test.go": []byte("package p\n\nvar strings = 1\n"),
+			"q.go":      []byte("package q\n\nfunc rand() {}\n"),
+		}, nil
+	})
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "grouped",
+			src:  "package p\n\nimport (\n\t\"example.com/x\"\n\n\t\"os\"\n)\n\nvar _ = os.Args\n" + body,
+			want: "package p\n\nimport (\n\t\"example.com/x\"\n\n\t\"fmt\"\n\t\"math/rand/v2\"\n\t\"os\"\n\t\"strings\"\n)\n\n" +
+				"var _ = os.Args\n" + body,
+		},
+		{
+			name: "third-party only",
+			src:  "package p\n\nimport (\n\t\"example.com/x\"\n)\n" + body,
+			want: "package p\n\nimport (\n\t\"fmt\"\n\t\"math/rand/v2\"\n\t\"strings\"\n\n\t\"example.com/x\"\n)\n" + body,
+		},
+		{
+			name: "single",
+			src:  "package p\n\nimport \"example.com/x\"\n" + body,
+			want: "package p\n\nimport (\n\t\"example.com/x\"\n\t\"fmt\"\n\t\"math/rand/v2\"\n\t\"strings\"\n)\n" + body,
+		},
+		{
+			name: "none",
+			src:  "package p // import \"example.com/p\"\n" + body,
+			want: "package p // import \"example.com/p\"\n\nimport (\n\t\"fmt\"\n\t\"math/rand/v2\"\n\t\"strings\"\n)\n" + body,
+		},
+		{
+			name: "none, one missing",
+			src:  "package p\n\nvar _ = os.Args\n",
+			want: "package p\n\nimport \"os\"\n\nvar _ = os.Args\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultGoConfig()
+			cfg.OrganizeImports = true
+			got, err := FormatGoContext(ctx, "p.go", []byte(tt.src), cfg)
+			if err != nil {
+				t.Fatalf("FormatGoContext: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatGoContext = %q; want %q", got, tt.want)
+			}
+
+			// Without the other files of the package, any name could be
+			// declared by one of them.
+			got, err = FormatGoContext(context.Background(), "p.go", []byte(tt.src), cfg)
+			if err != nil || string(got) != tt.src {
+				t.Fatalf("FormatGoContext without package files = %q, %v; want it unchanged", got, err)
+			}
+		})
+	}
+}
+
+// TestResolveStdlibImport_Picks_One_Package verifies how package names are
+// resolved through the standard library index.
+func TestResolveStdlibImport_Picks_One_Package(t *testing.T) {
+	tests := []struct {
+		name    string
+		symbols []string
+		want    string
+	}{
+		{"http", []string{"Get", "StatusOK"}, "net/http"},
+		{"rand", []string{"Intn"}, "math/rand"},
+		{"rand", []string{"Int"}, ""},
+		{"template", []string{"New"}, ""},
+		{"strings", []string{"NoSuchFunction"}, ""},
+		{"acme", []string{"New"}, ""},
+	}
+	for _, tt := range tests {
+		if got, _ := resolveStdlibImport(tt.name, tt.symbols); got != tt.want {
+			t.Fatalf("resolveStdlibImport(%q, %q) = %q; want %q", tt.name, tt.symbols, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+)
+
+// formatGoPartial formats the declarations of src that come before its
+// first syntax error and leaves the rest of the file as written, for files
+// that are being edited. It returns err, the error formatting the whole
+// file failed with, when src has no syntax error or no declaration ends
+// before the first one. Unused imports are kept, since the code using them
+// may be in the part that does not parse.
+func formatGoPartial(ctx context.Context, path string, src []byte, cfg GoConfig, err error) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, parseErr := parser.ParseFile(fset, path, src, parser.ParseComments)
+	var list scanner.ErrorList
+	if file == nil || !errors.As(parseErr, &list) || len(list) == 0 {
+		return nil, err
+	}
+	list.Sort()
+	broken := list[0].Pos.Offset
+
+	end := -1
+	for _, decl := range file.Decls {
+		// Declarations cut short by the error may end before they start.
+		declStart, declEnd := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+		if _, bad := decl.(*ast.BadDecl); bad || declEnd <= declStart || declEnd >= broken {
+			break
+		}
+		end = declEnd
+	}
+	if end < 0 {
+		return nil, err
+	}
+
+	debugf(ctx, "formatting up to the syntax error at line %d", list[0].Pos.Line)
+	cfg.OrganizeImports = false
+	formatted, prefixErr := FormatGoContext(ctx, path, src[:end], cfg)
+	if prefixErr != nil {
+		return nil, err
+	}
+	return append(bytes.TrimRight(formatted, "\n"), src[end:]...), nil
+}
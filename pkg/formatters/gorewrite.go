@@ -0,0 +1,267 @@
+package formatters
+
+// The rewriting below is adapted from cmd/gofmt/rewrite.go of the Go
+// project, which is distributed under a BSD-style license.
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	gofmt "go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// goRewriteRule is a parsed gofmt -r rule. Single lower-case letters in
+// the pattern are wildcards that match any expression and stand for the
+// matched expression in the replacement.
+type goRewriteRule struct {
+	pattern, replacement ast.Expr
+}
+
+// parseGoRewriteRule parses a rule of the form "pattern -> replacement",
+// where both sides are Go expressions.
+func parseGoRewriteRule(rule string) (goRewriteRule, error) {
+	pattern, replacement, ok := strings.Cut(rule, "->")
+	if !ok || strings.Contains(replacement, "->") {
+		return goRewriteRule{}, fmt.Errorf("%q must have the form pattern -> replacement", rule)
+	}
+	p, err := parser.ParseExpr(strings.TrimSpace(pattern))
+	if err != nil {
+		return goRewriteRule{}, fmt.Errorf("pattern of %q is not an expression: %w", rule, err)
+	}
+	r, err := parser.ParseExpr(strings.TrimSpace(replacement))
+	if err != nil {
+		return goRewriteRule{}, fmt.Errorf("replacement of %q is not an expression: %w", rule, err)
+	}
+	return goRewriteRule{pattern: p, replacement: r}, nil
+}
+
+// rewriteGo applies the rules in order to src and prints the result. The
+// rules must have been checked by GoConfig.Validate.
+func rewriteGo(path string, src []byte, rules []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	for _, text := range rules {
+		rule, err := parseGoRewriteRule(text)
+		if err != nil {
+			return nil, err
+		}
+		file = rule.apply(fset, file)
+	}
+	var out bytes.Buffer
+	if err = gofmt.Node(&out, fset, file); err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	return out.Bytes(), nil
+}
+
+// apply rewrites every match of the rule's pattern in file, innermost
+// expressions first.
+func (r goRewriteRule) apply(fset *token.FileSet, file *ast.File) *ast.File {
+	cmap := ast.NewCommentMap(fset, file, file.Comments)
+	m := make(map[string]reflect.Value)
+	pattern, replacement := reflect.ValueOf(r.pattern), reflect.ValueOf(r.replacement)
+
+	var rewriteVal func(val reflect.Value) reflect.Value
+	rewriteVal = func(val reflect.Value) reflect.Value {
+		if !val.IsValid() {
+			return reflect.Value{}
+		}
+		val = applyToFields(rewriteVal, val)
+		clear(m)
+		if matchNode(m, pattern, val) {
+			val = substNode(m, replacement, reflect.ValueOf(val.Interface().(ast.Node).Pos()))
+		}
+		return val
+	}
+
+	rewritten := applyToFields(rewriteVal, reflect.ValueOf(file)).Interface().(*ast.File)
+	rewritten.Comments = cmap.Filter(rewritten).Comments()
+	return rewritten
+}
+
+// Types with special handling while rewriting. Objects and scopes are
+// deprecated in go/ast and only referenced to clear them.
+//
+//nolint:gochecknoglobals,staticcheck // read-only lookups
+var (
+	objectPtrNil  = reflect.ValueOf((*ast.Object)(nil))
+	scopePtrNil   = reflect.ValueOf((*ast.Scope)(nil))
+	identType     = reflect.TypeOf((*ast.Ident)(nil))
+	objectPtrType = reflect.TypeOf((*ast.Object)(nil))
+	scopePtrType  = reflect.TypeOf((*ast.Scope)(nil))
+	positionType  = reflect.TypeOf(token.NoPos)
+	callExprType  = reflect.TypeOf((*ast.CallExpr)(nil))
+)
+
+// setValue sets x to y unless x cannot hold y, in which case the rewrite
+// of that node is skipped.
+func setValue(x, y reflect.Value) {
+	if !x.CanSet() || !y.IsValid() || !y.Type().AssignableTo(x.Type()) {
+		return
+	}
+	x.Set(y)
+}
+
+// applyToFields replaces each AST field x in val with f(x) and returns val.
+// Objects and scopes are dropped because a rewrite invalidates them.
+func applyToFields(f func(reflect.Value) reflect.Value, val reflect.Value) reflect.Value {
+	if !val.IsValid() {
+		return reflect.Value{}
+	}
+	switch val.Type() {
+	case objectPtrType:
+		return objectPtrNil
+	case scopePtrType:
+		return scopePtrNil
+	}
+
+	switch v := reflect.Indirect(val); v.Kind() { //nolint:exhaustive // other kinds hold no nodes
+	case reflect.Slice:
+		for i := range v.Len() {
+			e := v.Index(i)
+			setValue(e, f(e))
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			e := v.Field(i)
+			setValue(e, f(e))
+		}
+	case reflect.Interface:
+		setValue(v, f(v.Elem()))
+	}
+	return val
+}
+
+// isWildcard reports whether an identifier of the pattern is a wildcard.
+func isWildcard(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size == len(s) && unicode.IsLower(r)
+}
+
+// matchNode reports whether pattern matches val, recording what each
+// wildcard matched in m. With a nil m it reports whether they are equal.
+func matchNode(m map[string]reflect.Value, pattern, val reflect.Value) bool {
+	// A wildcard matches any expression, but the same one every time it
+	// appears in the pattern.
+	if m != nil && pattern.IsValid() && pattern.Type() == identType {
+		name := pattern.Interface().(*ast.Ident).Name
+		if isWildcard(name) && val.IsValid() {
+			if _, ok := val.Interface().(ast.Expr); ok && !val.IsNil() {
+				if old, seen := m[name]; seen {
+					return matchNode(nil, old, val)
+				}
+				m[name] = val
+				return true
+			}
+		}
+	}
+
+	if !pattern.IsValid() || !val.IsValid() {
+		return !pattern.IsValid() && !val.IsValid()
+	}
+	if pattern.Type() != val.Type() {
+		return false
+	}
+
+	switch pattern.Type() {
+	case identType:
+		p, v := pattern.Interface().(*ast.Ident), val.Interface().(*ast.Ident)
+		return p == nil && v == nil || p != nil && v != nil && p.Name == v.Name
+	case objectPtrType, positionType:
+		return true
+	case callExprType:
+		// f(x) and f(x...) differ only in the position of the ellipsis.
+		p, v := pattern.Interface().(*ast.CallExpr), val.Interface().(*ast.CallExpr)
+		if p.Ellipsis.IsValid() != v.Ellipsis.IsValid() {
+			return false
+		}
+	}
+
+	p, v := reflect.Indirect(pattern), reflect.Indirect(val)
+	if !p.IsValid() || !v.IsValid() {
+		return !p.IsValid() && !v.IsValid()
+	}
+	switch p.Kind() { //nolint:exhaustive // remaining kinds are compared as values
+	case reflect.Slice:
+		if p.Len() != v.Len() {
+			return false
+		}
+		for i := range p.Len() {
+			if !matchNode(m, p.Index(i), v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		for i := range p.NumField() {
+			if !matchNode(m, p.Field(i), v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		return matchNode(m, p.Elem(), v.Elem())
+	}
+	return p.Interface() == v.Interface()
+}
+
+// substNode returns a copy of pattern with the wildcards replaced by what
+// they matched in m and the positions of the pattern's own tokens set to
+// pos.
+func substNode(m map[string]reflect.Value, pattern, pos reflect.Value) reflect.Value {
+	if !pattern.IsValid() {
+		return reflect.Value{}
+	}
+	if m != nil && pattern.Type() == identType {
+		if old, ok := m[pattern.Interface().(*ast.Ident).Name]; ok {
+			return substNode(nil, old, reflect.Value{})
+		}
+	}
+	if pos.IsValid() && pattern.Type() == positionType {
+		if old := pattern.Interface().(token.Pos); !old.IsValid() {
+			return pattern
+		}
+		return pos
+	}
+
+	switch p := pattern; p.Kind() { //nolint:exhaustive // remaining kinds are copied as values
+	case reflect.Slice:
+		if p.IsNil() {
+			// go/ast relies on some lists being nil when empty.
+			return reflect.Zero(p.Type())
+		}
+		v := reflect.MakeSlice(p.Type(), p.Len(), p.Len())
+		for i := range p.Len() {
+			v.Index(i).Set(substNode(m, p.Index(i), pos))
+		}
+		return v
+	case reflect.Struct:
+		v := reflect.New(p.Type()).Elem()
+		for i := range p.NumField() {
+			v.Field(i).Set(substNode(m, p.Field(i), pos))
+		}
+		return v
+	case reflect.Pointer:
+		v := reflect.New(p.Type()).Elem()
+		if elem := p.Elem(); elem.IsValid() {
+			v.Set(substNode(m, elem, pos).Addr())
+		}
+		return v
+	case reflect.Interface:
+		v := reflect.New(p.Type()).Elem()
+		if elem := p.Elem(); elem.IsValid() {
+			v.Set(substNode(m, elem, pos))
+		}
+		return v
+	}
+	return pattern
+}
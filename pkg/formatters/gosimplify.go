@@ -0,0 +1,149 @@
+package formatters
+
+// The simplification below is adapted from cmd/gofmt/simplify.go of the Go
+// project, which is distributed under a BSD-style license.
+
+import (
+	"bytes"
+	"go/ast"
+	gofmt "go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+)
+
+// simplifyGo applies the simplifications of gofmt -s to src and prints the
+// result: redundant types are dropped from composite literals, s[a:len(s)]
+// becomes s[a:], blank range variables are dropped and empty declaration
+// groups are removed.
+func simplifyGo(path string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	removeEmptyDeclGroups(file)
+	ast.Walk(simplifier{}, file)
+
+	var out bytes.Buffer
+	if err = gofmt.Node(&out, fset, file); err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	return out.Bytes(), nil
+}
+
+// simplifier is the ast.Visitor that simplifies nodes in place.
+type simplifier struct{}
+
+// Visit simplifies composite literals, slice expressions and range
+// statements.
+func (s simplifier) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.CompositeLit:
+		// Array, slice and map literals may omit the types of their elements.
+		var keyType, eltType ast.Expr
+		switch typ := n.Type.(type) {
+		case *ast.ArrayType:
+			eltType = typ.Elt
+		case *ast.MapType:
+			keyType, eltType = typ.Key, typ.Value
+		}
+		if eltType == nil {
+			break
+		}
+		for i, x := range n.Elts {
+			px := &n.Elts[i]
+			if kv, ok := x.(*ast.KeyValueExpr); ok {
+				if keyType != nil {
+					s.simplifyLiteral(keyType, kv.Key, &kv.Key)
+				}
+				x, px = kv.Value, &kv.Value
+			}
+			s.simplifyLiteral(eltType, x, px)
+		}
+		// The elements have been walked by simplifyLiteral.
+		return nil
+
+	case *ast.SliceExpr:
+		// s[a:len(s)] is s[a:] when s is an identifier. Three-index slices
+		// need every index, and len is assumed not to be redeclared.
+		if n.Max != nil {
+			break
+		}
+		s, _ := n.X.(*ast.Ident)
+		call, _ := n.High.(*ast.CallExpr)
+		if s == nil || call == nil || len(call.Args) != 1 || call.Ellipsis.IsValid() {
+			break
+		}
+		fun, _ := call.Fun.(*ast.Ident)
+		arg, _ := call.Args[0].(*ast.Ident)
+		if fun != nil && fun.Name == "len" && arg != nil && arg.Name == s.Name {
+			n.High = nil
+		}
+
+	case *ast.RangeStmt:
+		// for x, _ = range v becomes for x = range v, and for _ = range v
+		// becomes for range v.
+		if isBlank(n.Value) {
+			n.Value = nil
+		}
+		if isBlank(n.Key) && n.Value == nil {
+			n.Key = nil
+		}
+	}
+	return s
+}
+
+// simplifyLiteral simplifies x, an element or key of a composite literal
+// whose elements or keys have type astType, stored at px. A composite
+// literal of exactly that type loses its type, and &T{...} loses &T when
+// the type is *T.
+func (s simplifier) simplifyLiteral(astType, x ast.Expr, px *ast.Expr) {
+	ast.Walk(s, x)
+
+	sameType := func(a, b ast.Expr) bool { return matchNode(nil, reflect.ValueOf(a), reflect.ValueOf(b)) }
+	if inner, ok := x.(*ast.CompositeLit); ok && sameType(astType, inner.Type) {
+		inner.Type = nil
+	}
+	ptr, ok := astType.(*ast.StarExpr)
+	if !ok {
+		return
+	}
+	if addr, ok := x.(*ast.UnaryExpr); ok && addr.Op == token.AND {
+		if inner, ok := addr.X.(*ast.CompositeLit); ok && sameType(ptr.X, inner.Type) {
+			inner.Type = nil
+			*px = inner
+		}
+	}
+}
+
+// isBlank reports whether x is the blank identifier.
+func isBlank(x ast.Expr) bool {
+	ident, ok := x.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
+// removeEmptyDeclGroups removes declarations such as "const ()" that
+// declare nothing and hold no comments.
+func removeEmptyDeclGroups(file *ast.File) {
+	kept := file.Decls[:0]
+	for _, d := range file.Decls {
+		if g, ok := d.(*ast.GenDecl); !ok || !isEmptyDecl(file, g) {
+			kept = append(kept, d)
+		}
+	}
+	file.Decls = kept
+}
+
+// isEmptyDecl reports whether a declaration has no specs and no comments.
+func isEmptyDecl(file *ast.File, g *ast.GenDecl) bool {
+	if g.Doc != nil || g.Specs != nil {
+		return false
+	}
+	for _, c := range file.Comments {
+		if g.Pos() <= c.Pos() && c.End() <= g.End() {
+			return false
+		}
+	}
+	return true
+}
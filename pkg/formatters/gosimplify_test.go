@@ -0,0 +1,29 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatGoContext_Simplifies_Like_Gofmt_S verifies the simplifications
+// of gofmt -s: redundant composite literal types, len slices, blank range
+// variables and empty declaration groups.
+func TestFormatGoContext_Simplifies_Like_Gofmt_S(t *testing.T) {
+	src := "package p\n\nconst ()\n\ntype T struct{ n int }\n\n" +
+		"var ts = []*T{&T{n: 1}, &T{n: 2}}\n\nvar m = map[T][]int{T{n: 1}: []int{1}}\n\n" +
+		"func f(s []int) []int {\n\tfor _ = range s {\n\t}\n" +
+		"\tfor i, _ := range s {\n\t\t_ = i\n\t}\n\treturn s[1:len(s)]\n}\n"
+	cfg := DefaultGoConfig()
+	cfg.Simplify = true
+
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	want := "package p\n\ntype T struct{ n int }\n\n" +
+		"var ts = []*T{{n: 1}, {n: 2}}\n\nvar m = map[T][]int{{n: 1}: {1}}\n\n" +
+		"func f(s []int) []int {\n\tfor range s {\n\t}\n\tfor i := range s {\n\t\t_ = i\n\t}\n\treturn s[1:]\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoContext = %q; want %q", got, want)
+	}
+}
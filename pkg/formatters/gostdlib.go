@@ -0,0 +1,70 @@
+package formatters
+
+import (
+	_ "embed"
+	"slices"
+	"strings"
+	"sync"
+)
+
+//go:generate go run ../../cmd/stdlibindex gostdlib.txt
+
+// stdlibIndexText lists every importable standard library package, one per
+// line, as its path followed by its exported package-level names. It is
+// generated from the API files of the Go release by cmd/stdlibindex, so
+// imports can be added without access to GOROOT.
+//
+//go:embed gostdlib.txt
+var stdlibIndexText string
+
+// stdlibPackage is a standard library package of the index.
+type stdlibPackage struct {
+	path    string
+	symbols []string
+}
+
+// stdlibIndex returns the packages of the index by their assumed name. It
+// is parsed on first use.
+var stdlibIndex = sync.OnceValue(func() map[string][]stdlibPackage { //nolint:gochecknoglobals // parsed once
+	index := map[string][]stdlibPackage{}
+	for _, line := range strings.Split(stdlibIndexText, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		name := assumedImportName(fields[0])
+		index[name] = append(index[name], stdlibPackage{path: fields[0], symbols: fields[1:]})
+	}
+	return index
+})
+
+// resolveStdlibImport returns the standard library package that a file
+// referring to name.symbol for every given symbol means. Packages with a
+// major version suffix, such as math/rand/v2, give way to the package they
+// succeed. No package is returned when none or several remain.
+func resolveStdlibImport(name string, symbols []string) (string, bool) {
+	var candidates []string
+	for _, pkg := range stdlibIndex()[name] {
+		if pkg.exports(symbols) {
+			candidates = append(candidates, pkg.path)
+		}
+	}
+	candidates = slices.DeleteFunc(slices.Clone(candidates), func(path string) bool {
+		i := strings.LastIndexByte(path, '/')
+		return i >= 0 && isMajorVersion(path[i+1:]) && slices.Contains(candidates, path[:i])
+	})
+	if len(candidates) != 1 {
+		return "", false
+	}
+	return candidates[0], true
+}
+
+// exports reports whether the package declares every one of symbols.
+func (p stdlibPackage) exports(symbols []string) bool {
+	for _, s := range symbols {
+		if _, found := slices.BinarySearch(p.symbols, s); !found {
+			return false
+		}
+	}
+	return true
+}
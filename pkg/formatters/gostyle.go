@@ -0,0 +1,206 @@
+package formatters
+
+import (
+	"bytes"
+	"cmp"
+	"go/ast"
+	gofmt "go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// Values of GoConfig.OctalLiteralStyle.
+const (
+	// OctalLiteralsKeep leaves octal literals as written.
+	OctalLiteralsKeep = "keep"
+	// OctalLiteralsModern writes octal literals with the 0o prefix, such as
+	// 0o755, like gofumpt does.
+	OctalLiteralsModern = "modern"
+	// OctalLiteralsLegacy writes octal literals with a leading zero, such as
+	// 0755.
+	OctalLiteralsLegacy = "legacy"
+)
+
+// octalLiteralStyles lists the accepted values of GoConfig.OctalLiteralStyle.
+var octalLiteralStyles = []string{ //nolint:gochecknoglobals // read-only lookup
+	OctalLiteralsKeep,
+	OctalLiteralsModern,
+	OctalLiteralsLegacy,
+}
+
+// goEdit replaces a half-open byte range of a file with text.
+type goEdit struct {
+	span
+	text string
+}
+
+// applyGoStyleRules applies the style rules cfg opts in to, each of them
+// on its own so that teams can adopt them one at a time. src must be gofmt
+// output.
+func applyGoStyleRules(path string, src []byte, cfg GoConfig) ([]byte, error) {
+	var err error
+	if cfg.OctalLiteralStyle != "" && cfg.OctalLiteralStyle != OctalLiteralsKeep {
+		src, err = applyGoRule(path, src, func(fset *token.FileSet, file *ast.File) []goEdit {
+			return octalLiteralEdits(fset, file, cfg.OctalLiteralStyle)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.HexDigitCase != "" && cfg.HexDigitCase != HexDigitsKeep ||
+		cfg.DigitSeparators != "" && cfg.DigitSeparators != DigitSeparatorsKeep || cfg.NormalizeExponents {
+		src, err = applyGoRule(path, src, func(fset *token.FileSet, file *ast.File) []goEdit {
+			return numberLiteralEdits(fset, file, cfg)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ShortCaseClauses {
+		src, err = applyGoRule(path, src, func(fset *token.FileSet, file *ast.File) []goEdit {
+			return shortCaseClauseEdits(fset, file, src, cfg.LineWidth)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.NoEmptyLineAtBlockStart {
+		return applyGoRule(path, src, func(fset *token.FileSet, file *ast.File) []goEdit {
+			return blockStartEdits(fset, file, src)
+		})
+	}
+	return src, nil
+}
+
+// applyGoRule parses src, applies the edits a rule asks for and prints the
+// result.
+func applyGoRule(path string, src []byte, rule func(*token.FileSet, *ast.File) []goEdit) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	edits := rule(fset, file)
+	if len(edits) == 0 {
+		return src, nil
+	}
+	formatted, err := gofmt.Source(applyGoEdits(src, edits))
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	return formatted, nil
+}
+
+// applyGoEdits applies edits that do not overlap.
+func applyGoEdits(src []byte, edits []goEdit) []byte {
+	slices.SortFunc(edits, func(a, b goEdit) int { return cmp.Compare(a.start, b.start) })
+	var out bytes.Buffer
+	next := 0
+	for _, edit := range edits {
+		out.Write(src[next:edit.start])
+		out.WriteString(edit.text)
+		next = edit.end
+	}
+	out.Write(src[next:])
+	return out.Bytes()
+}
+
+// octalLiteralEdits rewrites the prefix of octal integer literals in the
+// given style.
+func octalLiteralEdits(fset *token.FileSet, file *ast.File, style string) []goEdit {
+	var edits []goEdit
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT || len(lit.Value) < 2 || lit.Value[0] != '0' {
+			return true
+		}
+		var digits string
+		switch prefix := lit.Value[1]; {
+		case prefix == 'o' || prefix == 'O':
+			digits = lit.Value[2:]
+		case prefix >= '0' && prefix <= '7' || prefix == '_':
+			digits = strings.TrimPrefix(lit.Value[1:], "_")
+		default:
+			return true
+		}
+		text := "0o" + digits
+		if style == OctalLiteralsLegacy {
+			text = "0" + digits
+		}
+		if text != lit.Value {
+			offset := fset.Position(lit.Pos()).Offset
+			edits = append(edits, goEdit{span{offset, offset + len(lit.Value)}, text})
+		}
+		return true
+	})
+	return edits
+}
+
+// shortCaseClauseEdits joins the expressions of case clauses that span
+// several lines onto the line of the case keyword when they fit in width.
+// Clauses holding comments or multi-line expressions are left alone.
+func shortCaseClauseEdits(fset *token.FileSet, file *ast.File, src []byte, width int) []goEdit {
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	var edits []goEdit
+	ast.Inspect(file, func(n ast.Node) bool {
+		clause, ok := n.(*ast.CaseClause)
+		if !ok || len(clause.List) < 2 || line(clause.Case) == line(clause.Colon) {
+			return true
+		}
+		if hasCommentBetween(file.Comments, clause.Case, clause.Colon) {
+			return true
+		}
+		items := make([]string, len(clause.List))
+		for i, expr := range clause.List {
+			if line(expr.Pos()) != line(expr.End()) {
+				return true
+			}
+			items[i] = string(src[offset(expr.Pos()):offset(expr.End())])
+		}
+		text := "case " + strings.Join(items, ", ") + ":"
+		start := offset(clause.Case)
+		indent := src[bytes.LastIndexByte(src[:start], '\n')+1 : start]
+		if columns(string(indent)+text) <= width {
+			edits = append(edits, goEdit{span{start, offset(clause.Colon) + 1}, text})
+		}
+		return true
+	})
+	return edits
+}
+
+// blockStartEdits removes the empty lines between the line of the opening
+// brace of a block and its first statement or comment.
+func blockStartEdits(fset *token.FileSet, file *ast.File, src []byte) []goEdit {
+	var edits []goEdit
+	ast.Inspect(file, func(n ast.Node) bool {
+		block, ok := n.(*ast.BlockStmt)
+		if !ok || !block.Lbrace.IsValid() {
+			return true
+		}
+		open := fset.Position(block.Lbrace)
+		first := block.Rbrace
+		if len(block.List) > 0 {
+			first = block.List[0].Pos()
+		}
+		for _, c := range file.Comments {
+			if c.Pos() > block.Lbrace && c.Pos() < first && fset.Position(c.Pos()).Line > open.Line {
+				first = c.Pos()
+				break
+			}
+		}
+		next := fset.Position(first)
+		if next.Line <= open.Line+1 {
+			return true
+		}
+		start := open.Offset + bytes.IndexByte(src[open.Offset:], '\n') + 1
+		end := next.Offset - (next.Column - 1)
+		if len(bytes.TrimSpace(src[start:end])) == 0 {
+			edits = append(edits, goEdit{span{start, end}, ""})
+		}
+		return true
+	})
+	return edits
+}
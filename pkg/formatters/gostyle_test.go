@@ -0,0 +1,86 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatGoContext_Applies_Style_Rules verifies every opt-in style rule
+// on its own, and that the defaults leave the source as gofmt prints it.
+func TestFormatGoContext_Applies_Style_Rules(t *testing.T) {
+	src := "package p\n\nimport (\n\t\"example.com/x\"\n\t\"os\"\n)\n\n" +
+		"func f(v int) {\n\n\t// Mode.\n\tmode := 0755\n\tswitch v {\n\tcase 1,\n\t\t2:\n\t\tos.Exit(mode)\n\t}\n\tx.Run(0o644)\n}\n"
+	tests := []struct {
+		name string
+		cfg  func(*GoConfig)
+		want string
+	}{
+		{
+			name: "defaults",
+			cfg:  func(*GoConfig) {},
+			want: src,
+		},
+		{
+			name: "no empty line at block start",
+			cfg:  func(c *GoConfig) { c.NoEmptyLineAtBlockStart = true },
+			want: "package p\n\nimport (\n\t\"example.com/x\"\n\t\"os\"\n)\n\n" +
+				"func f(v int) {\n\t// Mode.\n\tmode := 0755\n\tswitch v {\n\tcase 1,\n\t\t2:\n\t\tos.Exit(mode)\n\t}\n\tx.Run(0o644)\n}\n",
+		},
+		{
+			name: "group std imports",
+			cfg:  func(c *GoConfig) { c.GroupStdImports = true },
+			want: "package p\n\nimport (\n\t\"os\"\n\n\t\"example.com/x\"\n)\n\n" +
+				"func f(v int) {\n\n\t// Mode.\n\tmode := 0755\n\tswitch v {\n\tcase 1,\n\t\t2:\n\t\tos.Exit(mode)\n\t}\n\tx.Run(0o644)\n}\n",
+		},
+		{
+			name: "short case clauses",
+			cfg:  func(c *GoConfig) { c.ShortCaseClauses = true },
+			want: "package p\n\nimport (\n\t\"example.com/x\"\n\t\"os\"\n)\n\n" +
+				"func f(v int) {\n\n\t// Mode.\n\tmode := 0755\n\tswitch v {\n\tcase 1, 2:\n\t\tos.Exit(mode)\n\t}\n\tx.Run(0o644)\n}\n",
+		},
+		{
+			name: "modern octal literals",
+			cfg:  func(c *GoConfig) { c.OctalLiteralStyle = OctalLiteralsModern },
+			want: "package p\n\nimport (\n\t\"example.com/x\"\n\t\"os\"\n)\n\n" +
+				"func f(v int) {\n\n\t// Mode.\n\tmode := 0o755\n\tswitch v {\n\tcase 1,\n\t\t2:\n\t\tos.Exit(mode)\n\t}\n\tx.Run(0o644)\n}\n",
+		},
+		{
+			name: "legacy octal literals",
+			cfg:  func(c *GoConfig) { c.OctalLiteralStyle = OctalLiteralsLegacy },
+			want: "package p\n\nimport (\n\t\"example.com/x\"\n\t\"os\"\n)\n\n" +
+				"func f(v int) {\n\n\t// Mode.\n\tmode := 0755\n\tswitch v {\n\tcase 1,\n\t\t2:\n\t\tos.Exit(mode)\n\t}\n\tx.Run(0644)\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultGoConfig()
+			tt.cfg(&cfg)
+			got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatGoContext: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatGoContext = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatGoContext_Keeps_Long_Case_Clauses verifies that case clauses
+// that would not fit in the line width, or hold comments, keep their lines.
+func TestFormatGoContext_Keeps_Long_Case_Clauses(t *testing.T) {
+	src := "package p\n\nfunc f(v string) {\n\tswitch v {\n" +
+		"\tcase \"alpha\",\n\t\t\"beta\":\n" +
+		"\tcase \"gamma\", // g\n\t\t\"delta\":\n\t}\n}\n"
+	cfg := DefaultGoConfig()
+	cfg.ShortCaseClauses, cfg.LineWidth = true, 20
+
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	if string(got) != src {
+		t.Fatalf("FormatGoContext = %q; want it unchanged", got)
+	}
+}
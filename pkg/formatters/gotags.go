@@ -0,0 +1,215 @@
+package formatters
+
+import (
+	"cmp"
+	"fmt"
+	"go/ast"
+	gofmt "go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// structTagPair is one key:"value" pair of a struct tag, with the value
+// quoted as written.
+type structTagPair struct {
+	key, value string
+}
+
+// String returns the pair as it appears in a tag.
+func (p structTagPair) String() string {
+	return p.key + ":" + p.value
+}
+
+// structTagEdit replaces the tag literal of a field, which is a raw
+// string literal if raw is set, with text.
+type structTagEdit struct {
+	start, end int
+	raw        bool
+	pairs      []structTagPair
+	text       string
+}
+
+// validStructTagKey reports whether key may be used as a struct tag key:
+// it is not empty and holds no spaces, quotes, colons or control characters.
+func validStructTagKey(key string) bool {
+	return key != "" && !strings.ContainsFunc(key, func(r rune) bool {
+		return r <= ' ' || r == ':' || r == '"' || r == 0x7f
+	})
+}
+
+// validateStructTagOrder checks the keys of GoConfig.StructTagOrder.
+func validateStructTagOrder(keys []string) error {
+	for i, key := range keys {
+		switch {
+		case !validStructTagKey(key):
+			return fmt.Errorf("%q is not a struct tag key", key)
+		case slices.Contains(keys[:i], key):
+			return fmt.Errorf("%q is listed twice", key)
+		}
+	}
+	return nil
+}
+
+// parseStructTag splits a tag in the conventional format, key:"value"
+// pairs separated by spaces, into its pairs. It reports false for tags in
+// any other format, which are left as written.
+func parseStructTag(tag string) ([]structTagPair, bool) {
+	var pairs []structTagPair
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return pairs, len(pairs) > 0
+		}
+		key, rest, ok := strings.Cut(tag, ":")
+		if !ok || !validStructTagKey(key) || !strings.HasPrefix(rest, `"`) {
+			return nil, false
+		}
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return nil, false
+		}
+		value := rest[:end+1]
+		if _, err := strconv.Unquote(value); err != nil {
+			return nil, false
+		}
+		pairs = append(pairs, structTagPair{key: key, value: value})
+		tag = rest[end+1:]
+		if tag != "" && tag[0] != ' ' {
+			return nil, false
+		}
+	}
+}
+
+// orderStructTag sorts the pairs with the listed keys first, in the listed
+// order, and keeps the order of the other keys after them.
+func orderStructTag(pairs []structTagPair, order []string) {
+	rank := func(p structTagPair) int {
+		if i := slices.Index(order, p.key); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	slices.SortStableFunc(pairs, func(a, b structTagPair) int { return cmp.Compare(rank(a), rank(b)) })
+}
+
+// normalizeStructTags rewrites the struct tags of formatted src: the keys
+// are ordered by order and separated by single spaces and, with align, the
+// pairs of fields on adjacent lines are padded into columns. Tags that do
+// not follow the conventional format are left as written.
+func normalizeStructTags(path string, src []byte, order []string, align bool) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+
+	var blocks [][]structTagEdit
+	ast.Inspect(file, func(n ast.Node) bool {
+		st, ok := n.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		var block []structTagEdit
+		lastLine := -1
+		for _, field := range st.Fields.List {
+			if start := fset.Position(field.Pos()).Line; start != lastLine+1 && len(block) > 0 {
+				blocks, block = append(blocks, block), nil
+			}
+			lastLine = fset.Position(field.End()).Line
+			if field.Tag == nil {
+				continue
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+			pairs, ok := parseStructTag(tag)
+			if !ok {
+				continue
+			}
+			orderStructTag(pairs, order)
+			block = append(block, structTagEdit{
+				start: fset.Position(field.Tag.Pos()).Offset,
+				end:   fset.Position(field.Tag.End()).Offset,
+				raw:   strings.HasPrefix(field.Tag.Value, "`"),
+				pairs: pairs,
+			})
+		}
+		if len(block) > 0 {
+			blocks = append(blocks, block)
+		}
+		return true
+	})
+
+	var edits []structTagEdit
+	for _, block := range blocks {
+		var widths []int
+		if align {
+			widths = structTagWidths(block)
+		}
+		for _, edit := range block {
+			edit.text = structTagLiteral(edit, widths)
+			edits = append(edits, edit)
+		}
+	}
+	slices.SortFunc(edits, func(a, b structTagEdit) int { return cmp.Compare(a.start, b.start) })
+
+	out := make([]byte, 0, len(src))
+	next := 0
+	for _, edit := range edits {
+		out = append(out, src[next:edit.start]...)
+		out = append(out, edit.text...)
+		next = edit.end
+	}
+	out = append(out, src[next:]...)
+	formatted, err := gofmt.Source(out)
+	if err != nil {
+		return nil, goSyntaxErrors(path, err)
+	}
+	return formatted, nil
+}
+
+// structTagWidths returns the width of the widest pair in each column of
+// a block of tags.
+func structTagWidths(block []structTagEdit) []int {
+	var widths []int
+	for _, edit := range block {
+		for i, p := range edit.pairs {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(p.String()))
+		}
+	}
+	return widths
+}
+
+// structTagLiteral renders the pairs of an edit as a literal quoted like
+// the original tag, padding each pair but the last to its column width.
+func structTagLiteral(edit structTagEdit, widths []int) string {
+	var b strings.Builder
+	for i, p := range edit.pairs {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(p.String())
+		if i < len(edit.pairs)-1 && i < len(widths) {
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(p.String())))
+		}
+	}
+	tag := b.String()
+	if edit.raw && !strings.Contains(tag, "`") {
+		return "`" + tag + "`"
+	}
+	return strconv.Quote(tag)
+}
@@ -0,0 +1,80 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatGoContext_Normalizes_Struct_Tags verifies that struct tag keys
+// are ordered and single-spaced, that alignment pads them into columns per
+// block of adjacent fields, and that unconventional tags are left alone.
+func TestFormatGoContext_Normalizes_Struct_Tags(t *testing.T) {
+	src := "package p\n\ntype T struct {\n" +
+		"\tName string `db:\"name\"   yaml:\"name\" json:\"name\"`\n" +
+		"\tID   int    \"json:\\\"id\\\"  db:\\\"id\\\"\"\n" +
+		"\tOdd  int    `not a tag`\n\n" +
+		"\tMore bool `yaml:\"more\"  json:\"more,omitempty\"`\n}\n"
+	tests := []struct {
+		name string
+		cfg  func(*GoConfig)
+		want string
+	}{
+		{
+			name: "normalize",
+			cfg:  func(c *GoConfig) { c.NormalizeStructTags = true },
+			want: "package p\n\ntype T struct {\n" +
+				"\tName string `json:\"name\" yaml:\"name\" db:\"name\"`\n" +
+				"\tID   int    \"json:\\\"id\\\" db:\\\"id\\\"\"\n" +
+				"\tOdd  int    `not a tag`\n\n" +
+				"\tMore bool `json:\"more,omitempty\" yaml:\"more\"`\n}\n",
+		},
+		{
+			name: "custom order",
+			cfg: func(c *GoConfig) {
+				c.NormalizeStructTags, c.StructTagOrder = true, []string{"db"}
+			},
+			want: "package p\n\ntype T struct {\n" +
+				"\tName string `db:\"name\" yaml:\"name\" json:\"name\"`\n" +
+				"\tID   int    \"db:\\\"id\\\" json:\\\"id\\\"\"\n" +
+				"\tOdd  int    `not a tag`\n\n" +
+				"\tMore bool `yaml:\"more\" json:\"more,omitempty\"`\n}\n",
+		},
+		{
+			name: "align",
+			cfg:  func(c *GoConfig) { c.AlignStructTags = true },
+			want: "package p\n\ntype T struct {\n" +
+				"\tName string `json:\"name\" yaml:\"name\" db:\"name\"`\n" +
+				"\tID   int    \"json:\\\"id\\\"   db:\\\"id\\\"\"\n" +
+				"\tOdd  int    `not a tag`\n\n" +
+				"\tMore bool `json:\"more,omitempty\" yaml:\"more\"`\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultGoConfig()
+			tt.cfg(&cfg)
+			got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatGoContext: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatGoContext = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseStructTag_Rejects_Unconventional_Tags verifies that only tags of
+// key:"value" pairs are parsed.
+func TestParseStructTag_Rejects_Unconventional_Tags(t *testing.T) {
+	for _, tag := range []string{"", "plain", `json:name`, `json:"a"yaml:"b"`, `json:"a`, `:"a"`} {
+		if pairs, ok := parseStructTag(tag); ok {
+			t.Fatalf("parseStructTag(%q) = %v; want no pairs", tag, pairs)
+		}
+	}
+	pairs, ok := parseStructTag(`json:"a,omitempty"  x:"\"q\""`)
+	if !ok || len(pairs) != 2 || pairs[1].value != `"\"q\""` {
+		t.Fatalf("parseStructTag = %v, %v; want two pairs", pairs, ok)
+	}
+}
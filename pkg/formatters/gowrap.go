@@ -0,0 +1,154 @@
+package formatters
+
+import (
+	"bytes"
+	"cmp"
+	"go/ast"
+	gofmt "go/format"
+	"go/parser"
+	"go/token"
+	"slices"
+)
+
+// goWrapPasses bounds the passes of wrapGoLines. Every pass splits at
+// least one list, so well-formed input finishes long before the bound.
+const goWrapPasses = 100
+
+// goList is a delimited, comma-separated list of a call, signature or
+// composite literal: the offsets of its delimiters and of every item.
+type goList struct {
+	open, close int
+	items       []span
+}
+
+// wrapGoLines splits the lists on lines longer than width so that every
+// item goes on a line of its own, like golines does. On each long line the
+// outermost call arguments, parameters, results or composite literal
+// elements that open and close on it are split; what remains too long is
+// split further in the next pass. Lists holding comments are left alone,
+// as are lines with nothing to split, such as long string literals. src
+// must be gofmt output.
+func wrapGoLines(path string, src []byte, width int) ([]byte, error) {
+	for range goWrapPasses {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+		if err != nil {
+			return nil, goSyntaxErrors(path, err)
+		}
+		lists := goLongLineLists(fset, file, src, width)
+		if len(lists) == 0 {
+			return src, nil
+		}
+		if src, err = gofmt.Source(splitGoLists(src, lists)); err != nil {
+			return nil, goSyntaxErrors(path, err)
+		}
+	}
+	return src, nil
+}
+
+// goLongLineLists returns the outermost list of every line longer than
+// width that opens and closes on that line, in source order.
+func goLongLineLists(fset *token.FileSet, file *ast.File, src []byte, width int) []goList {
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+	lines := bytes.Split(src, []byte("\n"))
+	long := func(l int) bool { return columns(string(lines[l-1])) > width }
+
+	outermost := map[int]goList{}
+	signatures := map[*ast.FieldList]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if fn, isFunc := n.(*ast.FuncType); isFunc {
+			signatures[fn.TypeParams], signatures[fn.Params], signatures[fn.Results] = true, true, true
+		}
+		if fields, isFields := n.(*ast.FieldList); isFields && !signatures[fields] {
+			return true
+		}
+		open, closing, items, ok := goListOf(n)
+		if !ok || len(items) == 0 || line(open) != line(closing) || !long(line(open)) {
+			return true
+		}
+		if existing, seen := outermost[line(open)]; seen && existing.open < offset(open) {
+			return true
+		}
+		if hasCommentBetween(file.Comments, open, closing) {
+			return true
+		}
+		list := goList{open: offset(open), close: offset(closing)}
+		for _, item := range items {
+			list.items = append(list.items, span{start: offset(item.start), end: offset(item.end)})
+		}
+		outermost[line(open)] = list
+		return true
+	})
+
+	lists := make([]goList, 0, len(outermost))
+	for _, list := range outermost {
+		lists = append(lists, list)
+	}
+	slices.SortFunc(lists, func(a, b goList) int { return cmp.Compare(a.open, b.open) })
+	return lists
+}
+
+// goItem is a list item as token positions.
+type goItem struct {
+	start, end token.Pos
+}
+
+// goListOf returns the delimiters and items of the list n holds, if any.
+// Field lists are taken to be parameters or results.
+func goListOf(n ast.Node) (token.Pos, token.Pos, []goItem, bool) {
+	switch n := n.(type) {
+	case *ast.CallExpr:
+		items := make([]goItem, len(n.Args))
+		for i, arg := range n.Args {
+			items[i] = goItem{arg.Pos(), arg.End()}
+		}
+		if n.Ellipsis.IsValid() && len(items) > 0 {
+			items[len(items)-1].end = n.Ellipsis + token.Pos(len("..."))
+		}
+		return n.Lparen, n.Rparen, items, true
+	case *ast.FieldList:
+		if !n.Opening.IsValid() || !n.Closing.IsValid() {
+			return token.NoPos, token.NoPos, nil, false
+		}
+		items := make([]goItem, len(n.List))
+		for i, field := range n.List {
+			items[i] = goItem{field.Pos(), field.End()}
+		}
+		return n.Opening, n.Closing, items, true
+	case *ast.CompositeLit:
+		items := make([]goItem, len(n.Elts))
+		for i, elt := range n.Elts {
+			items[i] = goItem{elt.Pos(), elt.End()}
+		}
+		return n.Lbrace, n.Rbrace, items, true
+	default:
+		return token.NoPos, token.NoPos, nil, false
+	}
+}
+
+// hasCommentBetween reports whether a comment lies between two positions.
+func hasCommentBetween(comments []*ast.CommentGroup, from, to token.Pos) bool {
+	return slices.ContainsFunc(comments, func(c *ast.CommentGroup) bool {
+		return c.Pos() > from && c.End() < to
+	})
+}
+
+// splitGoLists puts every item of the lists on a line of its own, each
+// followed by a comma, and the closing delimiter on the line after them.
+// The lists must not overlap.
+func splitGoLists(src []byte, lists []goList) []byte {
+	var out bytes.Buffer
+	next := 0
+	for _, list := range lists {
+		out.Write(src[next : list.open+1])
+		out.WriteString("\n")
+		for _, item := range list.items {
+			out.Write(src[item.start:item.end])
+			out.WriteString(",\n")
+		}
+		next = list.close
+	}
+	out.Write(src[next:])
+	return out.Bytes()
+}
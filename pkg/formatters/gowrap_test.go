@@ -0,0 +1,41 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatGoContext_Wraps_Long_Lines verifies that signatures, calls and
+// composite literals on lines over the line width are split one item per
+// line, outermost first, and that lists with comments or nothing left to
+// split are kept.
+func TestFormatGoContext_Wraps_Long_Lines(t *testing.T) {
+	src := "package p\n\n" +
+		"func Load(ctx context.Context, path string, opts map[string]any) (string, error) {\n" +
+		"\treturn fmt.Sprintf(\"%s %v\", path, strings.Join([]string{\"alpha\", \"beta\"}, \", \")), nil\n" +
+		"}\n\n" +
+		"func f(args ...any) {\n\tg(\"a string literal that is too long to fit on its own\", args...)\n}\n\n" +
+		"var short = []int{1, 2, 3}\n\n" +
+		"var kept = h(\"first argument of a call\" /* why */, \"second argument\")\n"
+	want := "package p\n\n" +
+		"func Load(\n\tctx context.Context,\n\tpath string,\n\topts map[string]any,\n) (string, error) {\n" +
+		"\treturn fmt.Sprintf(\n\t\t\"%s %v\",\n\t\tpath,\n\t\tstrings.Join([]string{\"alpha\", \"beta\"}, \", \"),\n\t), nil\n" +
+		"}\n\n" +
+		"func f(args ...any) {\n\tg(\n\t\t\"a string literal that is too long to fit on its own\",\n\t\targs...,\n\t)\n}\n\n" +
+		"var short = []int{1, 2, 3}\n\n" +
+		"var kept = h(\"first argument of a call\" /* why */, \"second argument\")\n"
+	cfg := DefaultGoConfig()
+	cfg.WrapLongLines, cfg.LineWidth = true, 60
+
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	if string(got) != want {
+		t.Fatalf("FormatGoContext = %q; want %q", got, want)
+	}
+	again, err := FormatGoContext(context.Background(), "p.go", got, cfg)
+	if err != nil || string(again) != want {
+		t.Fatalf("second FormatGoContext = %q, %v; want it unchanged", again, err)
+	}
+}
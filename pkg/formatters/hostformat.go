@@ -0,0 +1,19 @@
+package formatters
+
+import "context"
+
+// hostFormatKey is the context key of the host formatter.
+type hostFormatKey struct{}
+
+// WithHostFormat returns a context that lets the formatters hand code
+// embedded in a file, such as JSON in an HCL heredoc, to format, which
+// formats src as if it were the file at path and returns the result.
+func WithHostFormat(ctx context.Context, format func(path string, src []byte) ([]byte, error)) context.Context {
+	return context.WithValue(ctx, hostFormatKey{}, format)
+}
+
+// hostFormatter returns the host formatter ctx carries, if any.
+func hostFormatter(ctx context.Context) (func(string, []byte) ([]byte, error), bool) {
+	format, ok := ctx.Value(hostFormatKey{}).(func(string, []byte) ([]byte, error))
+	return format, ok
+}
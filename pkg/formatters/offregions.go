@@ -0,0 +1,88 @@
+package formatters
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Comments that enclose lines of code the formatters keep as written, such
+// as hand-aligned tables.
+const (
+	goFormatOff    = "//gofmt:off"
+	goFormatOn     = "//gofmt:on"
+	shellFormatOff = "# shfmt:off"
+	shellFormatOn  = "# shfmt:on"
+)
+
+// offRegion is the lines between an off comment, such as //gofmt:off, and
+// the matching on comment, and the indentation of the off comment.
+type offRegion struct {
+	span
+	indent string
+}
+
+// offRegions returns the regions of src between off and on comments on
+// lines of their own. A region that is not closed runs to the end of the
+// file, and an on comment outside a region is ignored.
+func offRegions(src []byte, off, on string) []offRegion {
+	var regions []offRegion
+	var open *offRegion
+	for offset := 0; offset < len(src); {
+		end := len(src)
+		if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+			end = offset + i + 1
+		}
+		line := src[offset:end]
+		switch text := string(bytes.TrimSpace(line)); {
+		case text == off && open == nil:
+			indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+			open = &offRegion{span: span{start: end}, indent: string(indent)}
+		case text == on && open != nil:
+			open.end = offset
+			regions = append(regions, *open)
+			open = nil
+		}
+		offset = end
+	}
+	if open != nil {
+		open.end = len(src)
+		regions = append(regions, *open)
+	}
+	return regions
+}
+
+// restoreOffRegions copies the lines of every off region of src over the
+// same region of formatted. When the formatter changed the indentation of
+// the off comment, the lines of the region are moved by the same amount so
+// that they stay in place relative to the code around them.
+func restoreOffRegions(src, formatted []byte, off, on string) ([]byte, error) {
+	original := offRegions(src, off, on)
+	if len(original) == 0 {
+		return formatted, nil
+	}
+	regions := offRegions(formatted, off, on)
+	if len(regions) != len(original) {
+		return nil, fmt.Errorf("formatting moved the %s and %s comments", off, on)
+	}
+	edits := make([]goEdit, len(regions))
+	for i, region := range regions {
+		text := reindent(src[original[i].start:original[i].end], original[i].indent, region.indent)
+		edits[i] = goEdit{region.span, string(text)}
+	}
+	return applyGoEdits(formatted, edits), nil
+}
+
+// reindent replaces the leading from of every line of text that starts
+// with it by to.
+func reindent(text []byte, from, to string) []byte {
+	if from == to {
+		return text
+	}
+	lines := bytes.SplitAfter(text, []byte("\n"))
+	for i, line := range lines {
+		if rest, ok := bytes.CutPrefix(line, []byte(from)); ok && len(bytes.TrimSpace(line)) > 0 {
+			lines[i] = append([]byte(to), rest...)
+		}
+	}
+	return bytes.Join(lines, nil)
+}
@@ -0,0 +1,64 @@
+package formatters
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// TestFormatGoContext_Keeps_Gofmt_Off_Regions verifies that lines between
+// //gofmt:off and //gofmt:on are kept as written, following the indentation
+// of the code around them, while the rest of the file is formatted.
+func TestFormatGoContext_Keeps_Gofmt_Off_Regions(t *testing.T) {
+	src := "package p\n\nfunc f() [][]int {\n    x  :=  1\n    //gofmt:off\n    m := [][]int{\n" +
+		"        {1,  0,   0},\n        {0, 10,   0},\n        {0,  0, 100},\n    }\n    //gofmt:on\n" +
+		"    _  =  x\n    return m\n}\n"
+	got, err := FormatGoContext(context.Background(), "p.go", []byte(src), DefaultGoConfig())
+	if err != nil {
+		t.Fatalf("FormatGoContext: %v", err)
+	}
+	want := "package p\n\nfunc f() [][]int {\n\tx := 1\n\t//gofmt:off\n\tm := [][]int{\n" +
+		"\t    {1,  0,   0},\n\t    {0, 10,   0},\n\t    {0,  0, 100},\n\t}\n\t//gofmt:on\n" +
+		"\t_ = x\n\treturn m\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatGoContext = %q; want %q", got, want)
+	}
+}
+
+// TestOffRegions_Finds_Regions verifies how regions are delimited.
+func TestOffRegions_Finds_Regions(t *testing.T) {
+	src := []byte("a\n//gofmt:on\n\t//gofmt:off\nb\n//gofmt:on\nc\n// gofmt:off\n//gofmt:off\nd\n")
+	got := offRegions(src, goFormatOff, goFormatOn)
+	want := []offRegion{
+		{span: span{start: 26, end: 28}, indent: "\t"},
+		{span: span{start: 66, end: 68}, indent: ""},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("offRegions = %v; want %v", got, want)
+	}
+}
+
+// TestFormatShell_Keeps_Shfmt_Off_Regions verifies that lines between
+// # shfmt:off and # shfmt:on are kept as written, in whole scripts and in
+// formatted ranges.
+func TestFormatShell_Keeps_Shfmt_Off_Regions(t *testing.T) {
+	src := "if true;  then\n  # shfmt:off\n  case $1 in\n    a)    run  ;;\n    bb)   stop ;;\n  esac\n" +
+		"  # shfmt:on\n  echo   done\nfi\n"
+	got, err := FormatShell("run.sh", []byte(src), DefaultShellConfig())
+	if err != nil {
+		t.Fatalf("FormatShell: %v", err)
+	}
+	want := "if true; then\n\t# shfmt:off\n\tcase $1 in\n\t  a)    run  ;;\n\t  bb)   stop ;;\n\tesac\n" +
+		"\t# shfmt:on\n\techo done\nfi\n"
+	if string(got) != want {
+		t.Fatalf("FormatShell = %q; want %q", got, want)
+	}
+
+	got, err = FormatShellRange(context.Background(), "run.sh", []byte("echo  a\n"+src), 10, 11, DefaultShellConfig())
+	if err != nil {
+		t.Fatalf("FormatShellRange: %v", err)
+	}
+	if want = "echo  a\n" + want; string(got) != want {
+		t.Fatalf("FormatShellRange = %q; want %q", got, want)
+	}
+}
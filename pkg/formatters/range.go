@@ -0,0 +1,48 @@
+package formatters
+
+import "errors"
+
+// ErrRangeUnsupported is returned by the range formatters when the range
+// does not cover anything they can format on its own. Callers are expected
+// to format the whole file instead.
+var ErrRangeUnsupported = errors.New("range cannot be formatted on its own")
+
+// span is a half-open byte range of a source file.
+type span struct {
+	start, end int
+}
+
+// overlaps reports whether s shares at least one byte with [start, end).
+// An empty range overlaps the span that contains its position.
+func (s span) overlaps(start, end int) bool {
+	if start == end {
+		return s.start <= start && start < s.end
+	}
+	return s.start < end && start < s.end
+}
+
+// covering returns the smallest span holding every span in spans that
+// overlaps [start, end), and false if there is none.
+func covering(spans []span, start, end int) (span, bool) {
+	result, found := span{}, false
+	for _, s := range spans {
+		if !s.overlaps(start, end) {
+			continue
+		}
+		if !found {
+			result, found = s, true
+			continue
+		}
+		result.start = min(result.start, s.start)
+		result.end = max(result.end, s.end)
+	}
+	return result, found
+}
+
+// splice replaces the bytes of src covered by s with replacement.
+func splice(src []byte, s span, replacement []byte) []byte {
+	out := make([]byte, 0, len(src)-(s.end-s.start)+len(replacement))
+	out = append(out, src[:s.start]...)
+	out = append(out, replacement...)
+	return append(out, src[s.end:]...)
+}
@@ -0,0 +1,181 @@
+package formatters
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// shellRow is a command on a line of its own, a candidate for alignment.
+type shellRow struct {
+	line   uint
+	indent string
+	name   string
+	words  []*syntax.Word
+}
+
+// alignShell pads the columns of printed shell code as cfg asks, taking
+// over the alignment of the deprecated keepPadding option: the words of
+// consecutive commands running the same program, and the commands of
+// consecutive one-line case arms. Lines with comments after the code are
+// left alone, since the printer has already aligned those comments.
+func alignShell(out []byte, path string, opts []syntax.ParserOption, cfg ShellConfig) ([]byte, error) {
+	if !cfg.AlignColumns && !cfg.AlignCaseArms {
+		return out, nil
+	}
+	opts = append(slices.Clip(opts), syntax.KeepComments(true))
+	file, err := syntax.NewParser(opts...).Parse(bytes.NewReader(out), path)
+	if err != nil {
+		return nil, shellSyntaxError(err)
+	}
+	lines := bytes.SplitAfter(out, []byte("\n"))
+	lineStart := make([]int, len(lines))
+	for i := 1; i < len(lines); i++ {
+		lineStart[i] = lineStart[i-1] + len(lines[i-1])
+	}
+	// before and after return the text of the line of pos before and after
+	// it, without the newline.
+	before := func(pos syntax.Pos) string {
+		return string(out[lineStart[pos.Line()-1]:pos.Offset()])
+	}
+	after := func(pos syntax.Pos) string {
+		line := lines[pos.Line()-1]
+		return strings.TrimSuffix(string(line[int(pos.Offset())-lineStart[pos.Line()-1]:]), "\n")
+	}
+
+	var edits []goEdit
+	var rows []shellRow
+	syntax.Walk(file, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.Stmt:
+			if row, ok := commandRow(n, before, after); ok && cfg.AlignColumns {
+				rows = append(rows, row)
+			}
+		case *syntax.CaseClause:
+			if cfg.AlignCaseArms {
+				edits = append(edits, caseArmEdits(n, out, before, after)...)
+			}
+		}
+		return true
+	})
+	edits = append(edits, columnEdits(out, rows)...)
+	if len(edits) == 0 {
+		return out, nil
+	}
+	return applyGoEdits(out, edits), nil
+}
+
+// commandRow returns the row of a statement that is a plain command with
+// arguments alone on its line, without redirections or operators.
+func commandRow(stmt *syntax.Stmt, before, after func(syntax.Pos) string) (shellRow, bool) {
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Assigns) > 0 || len(call.Args) < 2 || len(stmt.Redirs) > 0 {
+		return shellRow{}, false
+	}
+	if stmt.Negated || stmt.Background || stmt.Coprocess || stmt.Semicolon.IsValid() {
+		return shellRow{}, false
+	}
+	name, indent := call.Args[0].Lit(), before(stmt.Pos())
+	if name == "" || stmt.Pos().Line() != stmt.End().Line() {
+		return shellRow{}, false
+	}
+	if strings.TrimSpace(indent) != "" || after(stmt.End()) != "" {
+		return shellRow{}, false
+	}
+	return shellRow{line: stmt.Pos().Line(), indent: indent, name: name, words: call.Args}, true
+}
+
+// columnEdits pads the words of runs of rows on consecutive lines with the
+// same indentation, program and number of words into columns.
+func columnEdits(out []byte, rows []shellRow) []goEdit {
+	var edits []goEdit
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && sameColumns(rows[end-1], rows[end]) {
+			end++
+		}
+		if end-start > 1 {
+			edits = append(edits, padColumns(out, rows[start:end])...)
+		}
+		start = end
+	}
+	return edits
+}
+
+// sameColumns reports whether row b continues the columns of row a.
+func sameColumns(a, b shellRow) bool {
+	return b.line == a.line+1 && a.indent == b.indent && a.name == b.name && len(a.words) == len(b.words)
+}
+
+// padColumns pads every word but the last of rows to the widest word of
+// its column and a space.
+func padColumns(out []byte, rows []shellRow) []goEdit {
+	width := func(w *syntax.Word) int { return utf8.RuneCount(out[w.Pos().Offset():w.End().Offset()]) }
+	widths := make([]int, len(rows[0].words)-1)
+	for _, row := range rows {
+		for i := range widths {
+			widths[i] = max(widths[i], width(row.words[i]))
+		}
+	}
+	var edits []goEdit
+	for _, row := range rows {
+		for i, w := range widths {
+			word, next := row.words[i], row.words[i+1]
+			pad := strings.Repeat(" ", w-width(word)+1)
+			edits = append(edits, goEdit{span{int(word.End().Offset()), int(next.Pos().Offset())}, pad})
+		}
+	}
+	return edits
+}
+
+// caseArmEdits pads the patterns of runs of case arms on consecutive lines
+// so that their commands start in the same column. Only arms written on one
+// line, with a single command and nothing after their operator, take part.
+func caseArmEdits(clause *syntax.CaseClause, out []byte, before, after func(syntax.Pos) string) []goEdit {
+	type arm struct {
+		line       uint
+		paren, cmd int
+		width      int
+	}
+	var arms []arm
+	for _, item := range clause.Items {
+		if len(item.Stmts) != 1 || !item.OpPos.IsValid() {
+			continue
+		}
+		line, cmd := item.Pos().Line(), item.Stmts[0].Pos()
+		lead := strings.TrimSpace(before(item.Pos()))
+		if cmd.Line() != line || item.OpPos.Line() != line || lead != "" && lead != "(" || after(item.End()) != "" {
+			continue
+		}
+		last := item.Patterns[len(item.Patterns)-1].End()
+		paren := bytes.IndexByte(out[last.Offset():cmd.Offset()], ')')
+		if paren < 0 {
+			continue
+		}
+		paren += int(last.Offset()) + 1
+		width := utf8.RuneCountInString(before(cmd)) - utf8.RuneCount(out[paren:cmd.Offset()])
+		arms = append(arms, arm{line: line, paren: paren, cmd: int(cmd.Offset()), width: width})
+	}
+
+	var edits []goEdit
+	for start := 0; start < len(arms); {
+		end := start + 1
+		for end < len(arms) && arms[end].line == arms[end-1].line+1 {
+			end++
+		}
+		if end-start > 1 {
+			width := 0
+			for _, a := range arms[start:end] {
+				width = max(width, a.width)
+			}
+			for _, a := range arms[start:end] {
+				edits = append(edits, goEdit{span{a.paren, a.cmd}, strings.Repeat(" ", width-a.width+1)})
+			}
+		}
+		start = end
+	}
+	return edits
+}
@@ -0,0 +1,40 @@
+package formatters
+
+import "testing"
+
+// TestFormatShell_Aligns_Columns verifies that alignColumns pads the words
+// of consecutive commands running the same program, and leaves commands
+// with trailing comments, redirections or another shape alone.
+func TestFormatShell_Aligns_Columns(t *testing.T) {
+	src := "add foo 1 x\nadd barbaz 22 y\nadd é 3 z\nrm a b\n\n" +
+		"add q 1 x\nadd qq 2 x # note\n\nif true; then\n\tset -o errexit\n\tset -o pipefail\nfi\n"
+	cfg := DefaultShellConfig()
+	cfg.AlignColumns = true
+
+	got, err := FormatShell("run.sh", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatShell: %v", err)
+	}
+	want := "add foo    1  x\nadd barbaz 22 y\nadd é      3  z\nrm a b\n\n" +
+		"add q 1 x\nadd qq 2 x # note\n\nif true; then\n\tset -o errexit\n\tset -o pipefail\nfi\n"
+	if string(got) != want {
+		t.Fatalf("FormatShell = %q; want %q", got, want)
+	}
+}
+
+// TestFormatShell_Aligns_Case_Arms verifies that alignCaseArms lines up the
+// commands of consecutive one-line case arms.
+func TestFormatShell_Aligns_Case_Arms(t *testing.T) {
+	src := "case $1 in\na) run ;;\nlong | l) stop ;;\n*)\n\thelp\n\t;;\nesac\n"
+	cfg := DefaultShellConfig()
+	cfg.AlignCaseArms = true
+
+	got, err := FormatShell("run.sh", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatShell: %v", err)
+	}
+	want := "case $1 in\na)        run ;;\nlong | l) stop ;;\n*)\n\thelp\n\t;;\nesac\n"
+	if string(got) != want {
+		t.Fatalf("FormatShell = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package formatters
+
+import (
+	"bytes"
+	"errors"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// keepBackticks copies the backtick command substitutions of src over the
+// $( ) ones the printer writes for them when cfg.ConvertBackticks is not
+// set. Substitutions nested in a kept one are kept with it.
+func keepBackticks(src, formatted []byte, path string, opts []syntax.ParserOption, cfg ShellConfig) ([]byte, error) {
+	if cfg.ConvertBackticks || !bytes.ContainsRune(src, '`') {
+		return formatted, nil
+	}
+	original, err := commandSubstitutions(src, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	printed, err := commandSubstitutions(formatted, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(printed) != len(original) {
+		return nil, errors.New("formatting changed the number of command substitutions")
+	}
+	var edits []goEdit
+	kept := -1
+	for i, cs := range original {
+		if !cs.Backquotes || int(cs.Pos().Offset()) < kept {
+			continue
+		}
+		kept = int(cs.End().Offset())
+		edits = append(edits, goEdit{
+			span{int(printed[i].Pos().Offset()), int(printed[i].End().Offset())},
+			string(src[cs.Pos().Offset():cs.End().Offset()]),
+		})
+	}
+	return applyGoEdits(formatted, edits), nil
+}
+
+// commandSubstitutions returns the command substitutions of src in the
+// order they start.
+func commandSubstitutions(src []byte, path string, opts []syntax.ParserOption) ([]*syntax.CmdSubst, error) {
+	file, err := syntax.NewParser(opts...).Parse(bytes.NewReader(src), path)
+	if err != nil {
+		return nil, shellSyntaxError(err)
+	}
+	var substs []*syntax.CmdSubst
+	syntax.Walk(file, func(n syntax.Node) bool {
+		if cs, ok := n.(*syntax.CmdSubst); ok {
+			substs = append(substs, cs)
+		}
+		return true
+	})
+	return substs, nil
+}
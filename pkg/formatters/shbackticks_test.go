@@ -0,0 +1,39 @@
+package formatters
+
+import "testing"
+
+// TestFormatShell_Converts_Backticks verifies that backtick command
+// substitutions, nested ones included, become $( ) unless convertBackticks
+// is turned off, which keeps them as written.
+func TestFormatShell_Converts_Backticks(t *testing.T) {
+	src := "a=`echo \\`date\\``\nif true;  then b=\"x `pwd`  y\"; fi\nc=$(echo   c)\n"
+	tests := []struct {
+		name    string
+		convert bool
+		want    string
+	}{
+		{
+			name:    "convert",
+			convert: true,
+			want:    "a=$(echo $(date))\nif true; then b=\"x $(pwd)  y\"; fi\nc=$(echo c)\n",
+		},
+		{
+			name:    "keep",
+			convert: false,
+			want:    "a=`echo \\`date\\``\nif true; then b=\"x `pwd`  y\"; fi\nc=$(echo c)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultShellConfig()
+			cfg.ConvertBackticks = tt.convert
+			got, err := FormatShell("run.sh", []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatShell: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatShell = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
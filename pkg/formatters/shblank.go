@@ -0,0 +1,119 @@
+package formatters
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// shellBlankLines applies the blank line policy of cfg to printed shell
+// code: runs of blank lines longer than cfg.MaxBlankLines are shortened,
+// and consecutive function definitions are separated by exactly
+// cfg.FunctionBlankLines blank lines when it is set. Blank lines inside
+// heredocs and multi-line strings are part of the code and left alone.
+func shellBlankLines(out []byte, path string, opts []syntax.ParserOption, cfg ShellConfig) ([]byte, error) {
+	// The printer keeps at most one blank line in a row.
+	if cfg.MaxBlankLines >= 1 && cfg.FunctionBlankLines == 0 {
+		return out, nil
+	}
+	opts = append(slices.Clip(opts), syntax.KeepComments(true))
+	file, err := syntax.NewParser(opts...).Parse(bytes.NewReader(out), path)
+	if err != nil {
+		return nil, shellSyntaxError(err)
+	}
+	var verbatim []span
+	var funcs []*syntax.Stmt
+	syntax.Walk(file, func(n syntax.Node) bool {
+		if s, ok := verbatimShellSpan(n, out); ok {
+			verbatim = append(verbatim, s)
+		}
+		if stmt, ok := n.(*syntax.Stmt); ok {
+			if _, ok := stmt.Cmd.(*syntax.FuncDecl); ok {
+				funcs = append(funcs, stmt)
+			}
+		}
+		return true
+	})
+
+	var edits []goEdit
+	gaps := map[int]bool{}
+	if cfg.FunctionBlankLines > 0 {
+		for i := 1; i < len(funcs); i++ {
+			if gap, ok := functionGap(out, funcs[i-1], funcs[i]); ok {
+				edits = append(edits, goEdit{gap, strings.Repeat("\n", cfg.FunctionBlankLines)})
+				gaps[gap.start] = true
+			}
+		}
+	}
+	inside := func(offset int) bool {
+		return slices.ContainsFunc(verbatim, func(s span) bool { return s.start < offset && offset < s.end })
+	}
+	// blank is the span and length of the current run of blank lines.
+	var blank span
+	count := 0
+	flush := func() {
+		if count > cfg.MaxBlankLines && !gaps[blank.start] {
+			edits = append(edits, goEdit{blank, strings.Repeat("\n", cfg.MaxBlankLines)})
+		}
+		count = 0
+	}
+	offset := 0
+	for _, line := range bytes.SplitAfter(out, []byte("\n")) {
+		start := offset
+		offset += len(line)
+		if len(bytes.TrimSpace(line)) > 0 || inside(start) {
+			flush()
+			continue
+		}
+		if count == 0 {
+			blank.start = start
+		}
+		blank.end = offset
+		count++
+	}
+	flush()
+	if len(edits) == 0 {
+		return out, nil
+	}
+	return applyGoEdits(out, edits), nil
+}
+
+// functionGap returns the span of the blank lines between the function
+// definitions a and b, up to the comments before b, when only blank lines
+// separate them.
+func functionGap(out []byte, a, b *syntax.Stmt) (span, bool) {
+	end := int(a.End().Offset())
+	newline := bytes.IndexByte(out[end:], '\n')
+	if newline < 0 {
+		return span{}, false
+	}
+	next := int(b.Pos().Offset())
+	for _, c := range b.Comments {
+		next = min(next, int(c.Pos().Offset()))
+	}
+	gap := span{end + newline + 1, bytes.LastIndexByte(out[:next], '\n') + 1}
+	if gap.start > gap.end || len(bytes.TrimSpace(out[gap.start:gap.end])) > 0 {
+		return span{}, false
+	}
+	return gap, true
+}
+
+// verbatimShellSpan returns the span of a node whose line breaks are part
+// of the code: a quoted string, or a heredoc from its operator to the end
+// of its closing delimiter line.
+func verbatimShellSpan(n syntax.Node, src []byte) (span, bool) {
+	switch n := n.(type) {
+	case *syntax.SglQuoted, *syntax.DblQuoted:
+		return span{int(n.Pos().Offset()), int(n.End().Offset())}, true
+	case *syntax.Redirect:
+		if n.Hdoc != nil {
+			// The body ends before the line of the closing delimiter.
+			end := int(n.Hdoc.End().Offset())
+			end += bytes.IndexByte(src[end:], '\n') + 1
+			return span{int(n.OpPos.Offset()), end}, true
+		}
+	}
+	return span{}, false
+}
@@ -0,0 +1,49 @@
+package formatters
+
+import "testing"
+
+// TestFormatShell_Applies_Blank_Line_Policy verifies that maxBlankLines
+// removes blank lines outside heredocs and strings, and that
+// functionBlankLines separates functions, above their comments.
+func TestFormatShell_Applies_Blank_Line_Policy(t *testing.T) {
+	src := "f() {\n\techo f\n}\n# g prints g\ng() {\n\n\techo \"g\n\n\"\n}\n\n" +
+		"h() { :; }\ncat <<EOF\n\nEOF\n\nmain\n"
+	tests := []struct {
+		name      string
+		maxBlank  int
+		functions int
+		want      string
+	}{
+		{
+			name:     "defaults",
+			maxBlank: 1,
+			want:     src,
+		},
+		{
+			name:     "no blank lines",
+			maxBlank: 0,
+			want: "f() {\n\techo f\n}\n# g prints g\ng() {\n\techo \"g\n\n\"\n}\n" +
+				"h() { :; }\ncat <<EOF\n\nEOF\nmain\n",
+		},
+		{
+			name:      "functions apart",
+			maxBlank:  0,
+			functions: 2,
+			want: "f() {\n\techo f\n}\n\n\n# g prints g\ng() {\n\techo \"g\n\n\"\n}\n\n\n" +
+				"h() { :; }\ncat <<EOF\n\nEOF\nmain\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultShellConfig()
+			cfg.MaxBlankLines, cfg.FunctionBlankLines = tt.maxBlank, tt.functions
+			got, err := FormatShell("run.sh", []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatShell: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatShell = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,479 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/fileutil"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// ShellConfig maps a subset of shfmt options. Defaults aim to match shfmt
+// defaults. Extend as needed.
+type ShellConfig struct {
+	Indent               int               `json:"indent"               description:"Number of spaces per indent level; 0 indents with tabs." minimum:"0"`
+	BinaryNextLine       bool              `json:"binaryNextLine"       description:"Place binary operators such as && at the start of a line."`
+	SpaceRedirects       bool              `json:"spaceRedirects"       description:"Put a space after redirect operators."`
+	KeepPadding          bool              `json:"keepPadding"          description:"Keep column alignment padding." deprecated:"shfmt is dropping column alignment, use alignColumns and alignCaseArms instead"`
+	FunctionNextLine     bool              `json:"functionNextLine"     description:"Place the opening brace of a function on the next line."`
+	SwitchCaseIndent     bool              `json:"switchCaseIndent"     description:"Indent case clauses inside case statements."`
+	KeepComments         bool              `json:"keepComments"         description:"Preserve comments."`
+	Language             string            `json:"language"             description:"Shell dialect; auto picks it from the file extension or shebang." enum:"auto,posix,bash,mksh"`
+	Dialects             map[string]string `json:"dialects"             description:"Dialects by file extension, such as .sh, or file name, such as PKGBUILD, used before the extension and shebang when language is auto."`
+	FormatZsh            bool              `json:"formatZsh"            description:"Format zsh scripts as bash instead of leaving them unchanged; zsh-only syntax fails to parse."`
+	AlignColumns         bool              `json:"alignColumns"         description:"Pad the words of consecutive commands running the same program with the same number of words into columns."`
+	AlignCaseArms        bool              `json:"alignCaseArms"        description:"Pad the patterns of consecutive one-line case arms so that their commands line up."`
+	BashismWarnings      bool              `json:"bashismWarnings"      description:"Log a warning for each command or variable only bash provides, such as source, echo -e or $RANDOM, in scripts parsed as POSIX, and format them all the same."`
+	PosixCheck           bool              `json:"posixCheck"           description:"Report every bash-only construct in scripts meant to be POSIX, by the posix language, an sh shebang or an .sh extension, instead of formatting them."`
+	ReindentHeredocs     bool              `json:"reindentHeredocs"     description:"Re-indent the bodies of <<- heredocs with tabs to match the code around them; other heredoc bodies are always kept as written."`
+	TemplatePlaceholders []string          `json:"templatePlaceholders" description:"Regular expressions matching template placeholders, such as {{ .Var }} or @@VERSION@@, that are kept as written."`
+	Fragment             bool              `json:"fragment"             description:"Format files as a command list embedded in another file, such as a Dockerfile RUN instruction, joining its lines with backslash continuations."`
+	MaxBlankLines        int               `json:"maxBlankLines"        description:"Maximum number of consecutive blank lines; the printer never keeps more than one." minimum:"0"`
+	FunctionBlankLines   int               `json:"functionBlankLines"   description:"Number of blank lines between consecutive function definitions; 0 keeps them as they are." minimum:"0"`
+	AllowPartial         bool              `json:"allowPartial"         description:"Format the lines before the first syntax error and keep the rest as written instead of failing."`
+	ConvertBackticks     bool              `json:"convertBackticks"     description:"Rewrite backtick command substitutions as $( ); false keeps them as written."`
+	ZshFallback          string            `json:"zshFallback"          description:"What to do with zsh scripts formatted by formatZsh that fail to parse as bash: passthrough leaves them unchanged, error fails." enum:"passthrough,error"`
+}
+
+// DefaultShellConfig returns the configuration used when no options are set.
+func DefaultShellConfig() ShellConfig {
+	return ShellConfig{
+		Indent:               0,
+		BinaryNextLine:       false,
+		SpaceRedirects:       false,
+		KeepPadding:          false,
+		FunctionNextLine:     false,
+		SwitchCaseIndent:     false,
+		KeepComments:         true,
+		Language:             "auto",
+		Dialects:             nil,
+		FormatZsh:            false,
+		AlignColumns:         false,
+		AlignCaseArms:        false,
+		BashismWarnings:      false,
+		PosixCheck:           false,
+		ReindentHeredocs:     true,
+		TemplatePlaceholders: nil,
+		Fragment:             false,
+		MaxBlankLines:        1,
+		FunctionBlankLines:   0,
+		AllowPartial:         false,
+		ConvertBackticks:     true,
+		ZshFallback:          "passthrough",
+	}
+}
+
+// zshFileNames lists the startup files of zsh.
+var zshFileNames = []string{ //nolint:gochecknoglobals // read-only lookup
+	".zshrc", ".zshenv", ".zprofile", ".zlogin", ".zlogout",
+}
+
+// kshFileNames lists the startup files of the Korn shells.
+var kshFileNames = []string{".kshrc", ".mkshrc"} //nolint:gochecknoglobals // read-only lookup
+
+// shellLanguages lists the accepted values of ShellConfig.Language.
+var shellLanguages = []string{"auto", "posix", "bash", "mksh"} //nolint:gochecknoglobals // read-only lookup
+
+// shellDialects lists the accepted values of ShellConfig.Dialects.
+var shellDialects = []string{"posix", "bash", "mksh"} //nolint:gochecknoglobals // read-only lookup
+
+// zshFallbacks lists the accepted values of ShellConfig.ZshFallback.
+var zshFallbacks = []string{"passthrough", "error"} //nolint:gochecknoglobals // read-only lookup
+
+// Validate reports options that are out of range or not recognised.
+func (c ShellConfig) Validate() error {
+	var errs []error
+	if c.Indent < 0 {
+		errs = append(errs, &ConfigError{Property: "indent", Message: "must not be negative"})
+	}
+	if c.MaxBlankLines < 0 {
+		errs = append(errs, &ConfigError{Property: "maxBlankLines", Message: "must not be negative"})
+	}
+	if c.FunctionBlankLines < 0 {
+		errs = append(errs, &ConfigError{Property: "functionBlankLines", Message: "must not be negative"})
+	}
+	language := strings.ToLower(strings.TrimSpace(c.Language))
+	if !slices.Contains(shellLanguages, language) {
+		errs = append(errs, &ConfigError{
+			Property: "language",
+			Message:  "must be one of " + strings.Join(shellLanguages, ", "),
+		})
+	}
+	if len(c.Dialects) > 0 && language != "auto" {
+		errs = append(errs, &ConfigError{Property: "dialects", Message: "has no effect unless language is auto"})
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.Dialects)) {
+		if strings.TrimSpace(key) == "" {
+			errs = append(errs, &ConfigError{Property: "dialects", Message: "must not map an empty extension"})
+		}
+		if dialect := c.Dialects[key]; !slices.Contains(shellDialects, dialect) {
+			errs = append(errs, &ConfigError{
+				Property: "dialects",
+				Message:  fmt.Sprintf("%q for %q must be one of %s", dialect, key, strings.Join(shellDialects, ", ")),
+			})
+		}
+	}
+	if !slices.Contains(zshFallbacks, c.ZshFallback) {
+		errs = append(errs, &ConfigError{
+			Property: "zshFallback",
+			Message:  "must be one of " + strings.Join(zshFallbacks, ", "),
+		})
+	}
+	if c.PosixCheck && language != "auto" && language != "posix" {
+		errs = append(errs, &ConfigError{
+			Property: "posixCheck",
+			Message:  "has no effect unless language is auto or posix",
+		})
+	}
+	if c.BashismWarnings && language != "auto" && language != "posix" {
+		errs = append(errs, &ConfigError{
+			Property: "bashismWarnings",
+			Message:  "has no effect unless language is auto or posix",
+		})
+	}
+	for _, pattern := range c.TemplatePlaceholders {
+		re, err := regexp.Compile(pattern)
+		switch {
+		case err != nil:
+			errs = append(errs, &ConfigError{Property: "templatePlaceholders", Message: err.Error()})
+		case re.MatchString(""):
+			errs = append(errs, &ConfigError{
+				Property: "templatePlaceholders",
+				Message:  fmt.Sprintf("pattern %q matches an empty string", pattern),
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FormatShell formats a shell script using mvdan.cc/sh, applying the same
+// rules as the standalone shfmt tool for the given configuration. The path
+// is used in parse errors and, when the language is "auto", to pick the
+// dialect from the file extension; it may be empty.
+func FormatShell(path string, src []byte, cfg ShellConfig) ([]byte, error) {
+	return FormatShellContext(context.Background(), path, src, cfg)
+}
+
+// FormatShellContext is like FormatShell but stops early, returning
+// ctx.Err(), once ctx is cancelled. The context is checked between parsing
+// and printing. Zsh scripts are returned unchanged unless cfg.FormatZsh is
+// set, and then also when they fail to parse and cfg.ZshFallback is
+// passthrough. With cfg.AllowPartial, a script with syntax errors has the
+// lines before the first one formatted instead of failing. The
+// placeholders matching cfg.TemplatePlaceholders are replaced by plain
+// words while the script is formatted and put back afterwards. With
+// cfg.Fragment, src is formatted as a fragment that stays one logical line.
+func FormatShellContext(ctx context.Context, path string, src []byte, cfg ShellConfig) ([]byte, error) {
+	if skipZsh(ctx, path, src, cfg) {
+		return src, nil
+	}
+	if zshScript(path, src) && cfg.ZshFallback == "passthrough" {
+		cfg.ZshFallback = "error"
+		out, err := FormatShellContext(ctx, path, src, cfg)
+		return zshFallback(ctx, path, src, out, err)
+	}
+	if len(cfg.TemplatePlaceholders) > 0 {
+		masked, mask, err := maskTemplates(src, cfg.TemplatePlaceholders)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TemplatePlaceholders = nil
+		out, err := FormatShellContext(ctx, path, masked, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return mask.restore(out), nil
+	}
+	if cfg.Fragment {
+		return formatShellFragment(ctx, path, src, cfg)
+	}
+	opts := shellParserOptions(ctx, path, src, cfg)
+	if err := posixCheckError(opts, path, src, cfg); err != nil {
+		return nil, err
+	}
+	file, err := parseShell(opts, src, path)
+	if err != nil && cfg.AllowPartial {
+		return formatShellPartial(ctx, path, src, cfg, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	warnBashisms(ctx, path, src, file, cfg)
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	var out strings.Builder
+	printer := syntax.NewPrinter(shellPrinterOptions(cfg)...)
+	if err = printer.Print(&out, file); err != nil {
+		return nil, err
+	}
+	spaced, err := shellBlankLines([]byte(out.String()), path, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	spaced, err = keepBackticks(src, spaced, path, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	aligned, err := alignShell(spaced, path, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := keepDashHeredocs(src, aligned, path, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return restoreShellOffRegions(src, formatted, cfg)
+}
+
+// FormatShellRange formats the top-level statements of src that overlap the
+// byte range [start, end), leaving the rest of the script untouched. The
+// dialect is picked from the whole script. ErrRangeUnsupported is returned
+// when the range covers no statement, and for fragments, which are one
+// logical line.
+func FormatShellRange(ctx context.Context, path string, src []byte, start, end int, cfg ShellConfig) ([]byte, error) {
+	if skipZsh(ctx, path, src, cfg) {
+		return src, nil
+	}
+	if zshScript(path, src) && cfg.ZshFallback == "passthrough" {
+		cfg.ZshFallback = "error"
+		out, err := FormatShellRange(ctx, path, src, start, end, cfg)
+		return zshFallback(ctx, path, src, out, err)
+	}
+	if cfg.Fragment {
+		return nil, ErrRangeUnsupported
+	}
+	if len(cfg.TemplatePlaceholders) > 0 {
+		masked, mask, err := maskTemplates(src, cfg.TemplatePlaceholders)
+		if err != nil {
+			return nil, err
+		}
+		cfg.TemplatePlaceholders = nil
+		out, err := FormatShellRange(ctx, path, masked, mask.offset(start), mask.offset(end), cfg)
+		if err != nil {
+			return nil, err
+		}
+		return mask.restore(out), nil
+	}
+	opts := shellParserOptions(ctx, path, src, cfg)
+	if err := posixCheckError(opts, path, src, cfg); err != nil {
+		return nil, err
+	}
+	file, err := parseShell(opts, src, path)
+	if err != nil {
+		return nil, err
+	}
+	warnBashisms(ctx, path, src, file, cfg)
+
+	spans := make([]span, 0, len(file.Stmts))
+	for _, stmt := range file.Stmts {
+		spans = append(spans, span{start: int(stmt.Pos().Offset()), end: int(stmt.End().Offset())})
+	}
+	target, ok := covering(spans, start, end)
+	if !ok {
+		return nil, ErrRangeUnsupported
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	fragment, err := parseShell(opts, src[target.start:target.end], path)
+	if err != nil {
+		return nil, shiftSyntaxErrors(err, bytes.Count(src[:target.start], []byte("\n")))
+	}
+	var out strings.Builder
+	printer := syntax.NewPrinter(shellPrinterOptions(cfg)...)
+	if err = printer.Print(&out, fragment); err != nil {
+		return nil, err
+	}
+	spaced, err := shellBlankLines([]byte(out.String()), path, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	spaced, err = keepBackticks(src[target.start:target.end], spaced, path, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	aligned, err := alignShell(spaced, path, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err := keepDashHeredocs(src[target.start:target.end], aligned, path, opts, cfg)
+	if err != nil {
+		return nil, err
+	}
+	formatted, err = restoreShellOffRegions(src[target.start:target.end], formatted, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return splice(src, target, bytes.TrimSuffix(formatted, []byte("\n"))), nil
+}
+
+// restoreShellOffRegions keeps the lines between # shfmt:off and
+// # shfmt:on comments as written. The comments only survive printing when
+// cfg.KeepComments is set, so the regions are formatted like the rest of
+// the script otherwise.
+func restoreShellOffRegions(src, formatted []byte, cfg ShellConfig) ([]byte, error) {
+	if !cfg.KeepComments {
+		return formatted, nil
+	}
+	return restoreOffRegions(src, formatted, shellFormatOff, shellFormatOn)
+}
+
+// maxShellSyntaxErrors caps the number of syntax errors reported for a
+// script.
+const maxShellSyntaxErrors = 10
+
+// parseShell parses src, reporting syntax errors as SyntaxError values.
+// The parser stops at the first error, but it can assume a missing token
+// such as fi and go on, so the script is parsed again, recovering from one
+// more error each time, to report the errors after the first as well. The
+// errors are sorted by position.
+func parseShell(opts []syntax.ParserOption, src []byte, path string) (*syntax.File, error) {
+	file, err := syntax.NewParser(opts...).Parse(bytes.NewReader(src), path)
+	if err == nil {
+		return file, nil
+	}
+	errs := []error{shellSyntaxError(err)}
+	for recovered := 1; recovered < maxShellSyntaxErrors; recovered++ {
+		opts := append(slices.Clip(opts), syntax.RecoverErrors(recovered))
+		_, err = syntax.NewParser(opts...).Parse(bytes.NewReader(src), path)
+		if err == nil || err.Error() == errs[len(errs)-1].Error() {
+			break
+		}
+		errs = append(errs, shellSyntaxError(err))
+	}
+	sortSyntaxErrors(errs)
+	return nil, errors.Join(errs...)
+}
+
+func shellParserOptions(ctx context.Context, path string, src []byte, cfg ShellConfig) []syntax.ParserOption {
+	variant := shellVariant(path, src, cfg)
+	debugf(ctx, "parsing %s as %s (language %q)", path, variant, cfg.Language)
+	opts := []syntax.ParserOption{syntax.Variant(variant)}
+	if cfg.KeepComments {
+		opts = append(opts, syntax.KeepComments(true))
+	}
+	return opts
+}
+
+// skipZsh reports whether a script is written for zsh and is to be left
+// unchanged. The parser has no zsh dialect, so zsh scripts are only
+// formatted, as bash, when cfg.FormatZsh is set.
+func skipZsh(ctx context.Context, path string, src []byte, cfg ShellConfig) bool {
+	if cfg.FormatZsh || !zshScript(path, src) {
+		return false
+	}
+	warnf(ctx, "leaving zsh script %s unchanged", path)
+	return true
+}
+
+// zshScript reports whether a script is written for zsh, judging by its
+// extension, its name or its shebang.
+func zshScript(path string, src []byte) bool {
+	zsh := strings.EqualFold(filepath.Ext(path), ".zsh") || slices.Contains(zshFileNames, filepath.Base(path))
+	return zsh || fileutil.Shebang(src) == "zsh"
+}
+
+// zshFallback returns src in place of the result of formatting a zsh
+// script that failed with syntax errors, which are zsh-only syntax the
+// bash parser does not know rather than mistakes, reporting them as a
+// warning.
+func zshFallback(ctx context.Context, path string, src, formatted []byte, err error) ([]byte, error) {
+	var syntaxErr *SyntaxError
+	if err == nil || !errors.As(err, &syntaxErr) {
+		return formatted, err
+	}
+	warnf(ctx, "leaving zsh script %s unchanged, it does not parse as bash: %v", path, err)
+	return src, nil
+}
+
+// shellVariant picks the parser dialect for a script. An explicit language
+// wins. With "auto", the dialect cfg.Dialects maps the file to decides
+// first, then a dialect-specific file extension and then the shebang,
+// falling back to bash like shfmt does. Korn shell scripts and startup
+// files are parsed as mksh and zsh scripts as bash, the closest dialects.
+func shellVariant(path string, src []byte, cfg ShellConfig) syntax.LangVariant {
+	language := strings.ToLower(strings.TrimSpace(cfg.Language))
+	if dialect, ok := configuredDialect(path, cfg.Dialects); ok && language == "auto" {
+		language = dialect
+	}
+	switch language {
+	case "posix":
+		return syntax.LangPOSIX
+	case "bash":
+		return syntax.LangBash
+	case "mksh":
+		return syntax.LangMirBSDKorn
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".bash":
+		return syntax.LangBash
+	case ".mksh", ".ksh":
+		return syntax.LangMirBSDKorn
+	case ".bats":
+		return syntax.LangBats
+	}
+
+	if slices.Contains(kshFileNames, filepath.Base(path)) {
+		return syntax.LangMirBSDKorn
+	}
+
+	switch fileutil.Shebang(src) {
+	case "sh":
+		return syntax.LangPOSIX
+	case "mksh":
+		return syntax.LangMirBSDKorn
+	case "bats":
+		return syntax.LangBats
+	default:
+		return syntax.LangBash
+	}
+}
+
+// configuredDialect returns the dialect dialects maps a file to, by its
+// name or else by its extension, which is matched regardless of case.
+func configuredDialect(path string, dialects map[string]string) (string, bool) {
+	if dialect, ok := dialects[filepath.Base(path)]; ok {
+		return dialect, true
+	}
+	ext := filepath.Ext(path)
+	for key, dialect := range dialects {
+		if strings.HasPrefix(key, ".") && strings.EqualFold(key, ext) {
+			return dialect, true
+		}
+	}
+	return "", false
+}
+
+//goland:noinspection GoDeprecation
+func shellPrinterOptions(cfg ShellConfig) []syntax.PrinterOption {
+	var opts []syntax.PrinterOption
+	if cfg.Indent > 0 {
+		opts = append(opts, syntax.Indent(uint(cfg.Indent)))
+	}
+	if cfg.BinaryNextLine {
+		opts = append(opts, syntax.BinaryNextLine(true))
+	}
+	if cfg.SpaceRedirects {
+		opts = append(opts, syntax.SpaceRedirects(true))
+	}
+	if cfg.KeepPadding {
+		opts = append(opts, syntax.KeepPadding(true)) //nolint:staticcheck // since it is used
+	}
+	if cfg.FunctionNextLine {
+		opts = append(opts, syntax.FunctionNextLine(true))
+	}
+	if cfg.SwitchCaseIndent {
+		opts = append(opts, syntax.SwitchCaseIndent(true))
+	}
+	return opts
+}
@@ -0,0 +1,223 @@
+package formatters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestFormatShell_Applies_Config verifies that FormatShell honours the
+// printer options carried by ShellConfig.
+func TestFormatShell_Applies_Config(t *testing.T) {
+	src := []byte("if true; then\necho ok\nfi\n")
+
+	tests := []struct {
+		name string
+		cfg  func(*ShellConfig)
+		want string
+	}{
+		{
+			name: "defaults use tabs",
+			cfg:  func(*ShellConfig) {},
+			want: "if true; then\n\techo ok\nfi\n",
+		},
+		{
+			name: "indent uses spaces",
+			cfg:  func(c *ShellConfig) { c.Indent = 2 },
+			want: "if true; then\n  echo ok\nfi\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultShellConfig()
+			tt.cfg(&cfg)
+			got, err := FormatShell("", src, cfg)
+			if err != nil {
+				t.Fatalf("FormatShell: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatShell mismatch\n--- got ---\n%s\n--- want ---\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatShell_Reports_Syntax_Errors verifies that parse failures are
+// surfaced as errors.
+func TestFormatShell_Reports_Syntax_Errors(t *testing.T) {
+	if _, err := FormatShell("", []byte("if true; then\n"), DefaultShellConfig()); err == nil {
+		t.Fatalf("FormatShell accepted invalid script")
+	}
+}
+
+// TestShellConfig_Validate_Rejects_Bad_Values verifies the range checks
+// reported through configuration diagnostics.
+func TestShellConfig_Validate_Rejects_Bad_Values(t *testing.T) {
+	cfg := DefaultShellConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config invalid: %v", err)
+	}
+
+	cfg.Indent = -2
+	cfg.MaxBlankLines = -1
+	cfg.Language = "fish"
+	cfg.Dialects = map[string]string{".sh": "fish"}
+	cfg.PosixCheck = true
+	cfg.BashismWarnings = true
+	cfg.ZshFallback = "warn"
+	cfg.TemplatePlaceholders = []string{"@@[A-Z]+@@", "x*"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("Validate accepted negative indent and unknown language")
+	}
+	want := "indent: must not be negative\nmaxBlankLines: must not be negative\n" +
+		"language: must be one of auto, posix, bash, mksh\n" +
+		"dialects: has no effect unless language is auto\n" +
+		`dialects: "fish" for ".sh" must be one of posix, bash, mksh` + "\n" +
+		"zshFallback: must be one of passthrough, error\n" +
+		"posixCheck: has no effect unless language is auto or posix\n" +
+		"bashismWarnings: has no effect unless language is auto or posix\n" +
+		`templatePlaceholders: pattern "x*" matches an empty string`
+	if err.Error() != want {
+		t.Fatalf("Validate = %q; want %q", err.Error(), want)
+	}
+}
+
+// TestFormatShell_Infers_Dialect_From_Path verifies that the "auto"
+// language picks the dialect from the extension, the file name and then
+// the shebang, and that parse errors name the file.
+func TestFormatShell_Infers_Dialect_From_Path(t *testing.T) {
+	arrays := []byte("a=(1 2)\n")
+
+	if _, err := FormatShell("run.bash", arrays, DefaultShellConfig()); err != nil {
+		t.Fatalf("bash extension rejected arrays: %v", err)
+	}
+
+	_, err := FormatShell("bin/run", append([]byte("#!/bin/sh\n"), arrays...), DefaultShellConfig())
+	if err == nil {
+		t.Fatalf("sh shebang accepted bash arrays")
+	}
+	if !strings.HasPrefix(err.Error(), "bin/run:2:") {
+		t.Fatalf("error %q does not name the file", err)
+	}
+
+	for _, path := range []string{"run.ksh", "home/.kshrc"} {
+		if _, err = FormatShell(path, []byte("[[ a =~ b ]]\n"), DefaultShellConfig()); err == nil {
+			t.Fatalf("%s was not parsed as mksh", path)
+		}
+	}
+
+	cfg := DefaultShellConfig()
+	cfg.Language = "bash"
+	if _, err = FormatShell("bin/run", append([]byte("#!/bin/sh\n"), arrays...), cfg); err != nil {
+		t.Fatalf("explicit language did not win over shebang: %v", err)
+	}
+}
+
+// TestFormatShell_Uses_Configured_Dialects verifies that dialects map
+// files to a dialect by name or extension ahead of the shebang.
+func TestFormatShell_Uses_Configured_Dialects(t *testing.T) {
+	arrays := []byte("a=(1 2)\n")
+	cfg := DefaultShellConfig()
+	cfg.Dialects = map[string]string{".sh": "posix", "PKGBUILD": "bash"}
+
+	if _, err := FormatShell("bin/run.SH", append([]byte("#!/bin/bash\n"), arrays...), cfg); err == nil {
+		t.Fatalf(".sh files accepted bash arrays")
+	}
+	if _, err := FormatShell("pkg/PKGBUILD", append([]byte("#!/bin/sh\n"), arrays...), cfg); err != nil {
+		t.Fatalf("PKGBUILD was not parsed as bash: %v", err)
+	}
+	if _, err := FormatShell("run.bash", arrays, cfg); err != nil {
+		t.Fatalf("unmapped extension lost its dialect: %v", err)
+	}
+}
+
+// TestFormatShell_Leaves_Zsh_Scripts_Unless_Enabled verifies that zsh
+// scripts, recognised by extension or shebang, are returned unchanged
+// unless formatZsh is set, and are then formatted as bash.
+func TestFormatShell_Leaves_Zsh_Scripts_Unless_Enabled(t *testing.T) {
+	src := []byte("#!/usr/bin/env zsh\nif true;  then echo hi; fi\n")
+	for _, path := range []string{"rc.zsh", "home/.zshrc", "bin/run"} {
+		got, err := FormatShell(path, src, DefaultShellConfig())
+		if err != nil || string(got) != string(src) {
+			t.Fatalf("FormatShell(%s) = %q, %v; want the script unchanged", path, got, err)
+		}
+	}
+
+	cfg := DefaultShellConfig()
+	cfg.FormatZsh = true
+	got, err := FormatShell("rc.zsh", src, cfg)
+	if err != nil {
+		t.Fatalf("FormatShell: %v", err)
+	}
+	if want := "#!/usr/bin/env zsh\nif true; then echo hi; fi\n"; string(got) != want {
+		t.Fatalf("FormatShell = %q; want %q", got, want)
+	}
+}
+
+// TestFormatShell_Falls_Back_On_Zsh_Syntax verifies that zsh scripts with
+// zsh-only syntax are left unchanged with a warning under the passthrough
+// fallback, without debugging enabled, and fail under the error one.
+func TestFormatShell_Falls_Back_On_Zsh_Syntax(t *testing.T) {
+	src := []byte("typeset -A m\nprint -l ${(k)m}\n")
+	cfg := DefaultShellConfig()
+	cfg.FormatZsh = true
+
+	var warnings []string
+	ctx := WithWarningLog(context.Background(), func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	})
+	got, err := FormatShellContext(ctx, "rc.zsh", src, cfg)
+	if err != nil || string(got) != string(src) {
+		t.Fatalf("FormatShellContext = %q, %v; want the script unchanged", got, err)
+	}
+	if !slices.ContainsFunc(warnings, func(m string) bool { return strings.Contains(m, "does not parse as bash") }) {
+		t.Fatalf("warnings = %q; want the parse failure", warnings)
+	}
+
+	cfg.ZshFallback = "error"
+	var syntaxErr *SyntaxError
+	if _, err = FormatShell("rc.zsh", src, cfg); !errors.As(err, &syntaxErr) {
+		t.Fatalf("FormatShell error = %v; want a SyntaxError", err)
+	}
+}
+
+// TestFormatShellContext_Stops_When_Cancelled verifies that a cancelled
+// context aborts formatting with the context's error.
+func TestFormatShellContext_Stops_When_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := FormatShellContext(ctx, "test.sh", []byte("echo hi\n"), DefaultShellConfig())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("FormatShellContext error = %v; want %v", err, context.Canceled)
+	}
+}
+
+// TestFormatShellRange_Formats_Overlapping_Statements verifies that only
+// the statements touched by the range are formatted.
+func TestFormatShellRange_Formats_Overlapping_Statements(t *testing.T) {
+	src := "echo   a\nif true;then echo b;fi\necho   c\n"
+	start := strings.Index(src, "if")
+
+	got, err := FormatShellRange(context.Background(), "test.sh", []byte(src), start, start+2, DefaultShellConfig())
+	if err != nil {
+		t.Fatalf("FormatShellRange: %v", err)
+	}
+	want := "echo   a\nif true; then echo b; fi\necho   c\n"
+	if string(got) != want {
+		t.Fatalf("FormatShellRange = %q; want %q", got, want)
+	}
+}
+
+// TestFormatShellRange_Rejects_Ranges_Without_Statements verifies that a
+// range covering only blank lines asks for a whole-file format.
+func TestFormatShellRange_Rejects_Ranges_Without_Statements(t *testing.T) {
+	_, err := FormatShellRange(context.Background(), "test.sh", []byte("echo a\n\n\necho b\n"), 8, 9, DefaultShellConfig())
+	if !errors.Is(err, ErrRangeUnsupported) {
+		t.Fatalf("FormatShellRange error = %v; want %v", err, ErrRangeUnsupported)
+	}
+}
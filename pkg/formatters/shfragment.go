@@ -0,0 +1,116 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// formatShellFragment formats src as a command list embedded in another
+// file, such as the commands of a Dockerfile RUN instruction, which other
+// plugins hand over through the host. The fragment stays one logical line:
+// its line breaks are written as backslash continuations, with a semicolon
+// where a statement ends, and blank lines are dropped. When the fragment
+// holds more than one statement, the lines after the first are indented
+// one more level so that they stand out from the line they continue.
+func formatShellFragment(ctx context.Context, path string, src []byte, cfg ShellConfig) ([]byte, error) {
+	cfg.Fragment = false
+	body := bytes.TrimSpace(src)
+	if len(body) == 0 {
+		return src, nil
+	}
+	formatted, err := FormatShellContext(ctx, path, append(slices.Clip(body), '\n'), cfg)
+	if err != nil {
+		return nil, err
+	}
+	out, err := joinShellLines(formatted, path, shellParserOptions(ctx, path, formatted, cfg), cfg)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasSuffix(src, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	return out, nil
+}
+
+// joinShellLines writes the line breaks of formatted shell code as
+// backslash continuations. Lines inside heredocs and multi-line strings,
+// and lines ending in a comment, cannot be continued and are kept as they
+// are.
+func joinShellLines(formatted []byte, path string, opts []syntax.ParserOption, cfg ShellConfig) ([]byte, error) {
+	opts = append(slices.Clip(opts), syntax.KeepComments(true))
+	file, err := syntax.NewParser(opts...).Parse(bytes.NewReader(formatted), path)
+	if err != nil {
+		return nil, shellSyntaxError(err)
+	}
+	// verbatim holds the spans whose line breaks are part of the code, and
+	// ends and joined the offsets where statements end and where the next
+	// line continues the statement.
+	var verbatim []span
+	ends, joined := map[int]bool{}, map[int]bool{}
+	syntax.Walk(file, func(n syntax.Node) bool {
+		if s, ok := verbatimShellSpan(n, formatted); ok {
+			verbatim = append(verbatim, s)
+		}
+		switch n := n.(type) {
+		case *syntax.Stmt:
+			ends[int(n.End().Offset())] = true
+			if n.Background || n.Coprocess {
+				joined[int(n.End().Offset())] = true
+			}
+		case *syntax.BinaryCmd:
+			joined[int(n.X.End().Offset())] = true
+		case *syntax.Comment:
+			verbatim = append(verbatim, span{int(n.Pos().Offset()), int(n.End().Offset()) + 1})
+		}
+		return true
+	})
+	inside := func(offset int) bool {
+		return slices.ContainsFunc(verbatim, func(s span) bool { return s.start < offset && offset < s.end })
+	}
+	breaks := func(offset int) bool {
+		return slices.ContainsFunc(verbatim, func(s span) bool { return s.start <= offset && offset < s.end })
+	}
+
+	indent := ""
+	if len(file.Stmts) > 1 {
+		indent = "\t"
+		if cfg.Indent > 0 {
+			indent = strings.Repeat(" ", cfg.Indent)
+		}
+	}
+	var out bytes.Buffer
+	lines := bytes.SplitAfter(bytes.TrimSuffix(formatted, []byte("\n")), []byte("\n"))
+	offset := 0
+	for i, line := range lines {
+		start := offset
+		offset += len(line)
+		text := bytes.TrimSuffix(line, []byte("\n"))
+		if len(bytes.TrimSpace(text)) == 0 && !inside(start) {
+			continue
+		}
+		if i > 0 && !inside(start) {
+			out.WriteString(indent)
+		}
+		out.Write(text)
+		if i == len(lines)-1 {
+			break
+		}
+		end := start + len(text)
+		switch {
+		case breaks(end):
+			out.WriteByte('\n')
+			continue
+		case ends[end] && !joined[end]:
+			out.WriteString("; \\\n")
+		case bytes.HasSuffix(text, []byte("\\")):
+			out.WriteByte('\n')
+		default:
+			out.WriteString(" \\\n")
+		}
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,58 @@
+package formatters
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestFormatShell_Formats_Fragments verifies that fragments stay one
+// logical line of backslash continuations, with semicolons where
+// statements end, and keep the trailing newline only when they had one.
+func TestFormatShell_Formats_Fragments(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "continued command",
+			src:  "apt-get update && \\\n  apt-get install -y \\\n      curl \\\n   git && \\\n rm -rf /var/lib/apt/lists/*",
+			want: "apt-get update && \\\n\tapt-get install -y \\\n\t\tcurl \\\n\t\tgit && \\\n\trm -rf /var/lib/apt/lists/*",
+		},
+		{
+			name: "statement list",
+			src:  "set -eux\n\nif [ -f x ];  then echo a; fi\nmake &\nwait\n",
+			want: "set -eux; \\\n\tif [ -f x ]; then echo a; fi; \\\n\tmake & \\\n\twait\n",
+		},
+		{
+			name: "heredoc",
+			src:  "cat <<EOF >out\n  body\nEOF\n",
+			want: "cat <<EOF >out\n  body\nEOF\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultShellConfig()
+			cfg.Fragment = true
+			got, err := FormatShell("", []byte(tt.src), cfg)
+			if err != nil {
+				t.Fatalf("FormatShell: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatShell = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatShellRange_Rejects_Fragments verifies that fragments are only
+// formatted whole.
+func TestFormatShellRange_Rejects_Fragments(t *testing.T) {
+	cfg := DefaultShellConfig()
+	cfg.Fragment = true
+	_, err := FormatShellRange(context.Background(), "", []byte("a\nb\n"), 2, 3, cfg)
+	if !errors.Is(err, ErrRangeUnsupported) {
+		t.Fatalf("FormatShellRange error = %v; want ErrRangeUnsupported", err)
+	}
+}
@@ -0,0 +1,53 @@
+package formatters
+
+import (
+	"bytes"
+	"errors"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// keepDashHeredocs copies the bodies of the <<- heredocs of src, with their
+// closing delimiter lines, over the re-indented bodies of formatted when
+// cfg.ReindentHeredocs is not set. The printer keeps the bodies of other
+// heredocs as written, but re-indents <<- bodies with tabs to match the
+// code around them; the shell strips leading tabs from those, so both
+// forms run the same.
+func keepDashHeredocs(src, formatted []byte, path string, opts []syntax.ParserOption, cfg ShellConfig) ([]byte, error) {
+	if cfg.ReindentHeredocs {
+		return formatted, nil
+	}
+	original, err := dashHeredocs(src, path, opts)
+	if err != nil || len(original) == 0 {
+		return formatted, err
+	}
+	printed, err := dashHeredocs(formatted, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(printed) != len(original) {
+		return nil, errors.New("formatting changed the number of <<- heredocs")
+	}
+	edits := make([]goEdit, len(printed))
+	for i, body := range printed {
+		edits[i] = goEdit{body, string(src[original[i].start:original[i].end])}
+	}
+	return applyGoEdits(formatted, edits), nil
+}
+
+// dashHeredocs returns the spans of the bodies of the <<- heredocs of src,
+// with their closing delimiter lines, in order.
+func dashHeredocs(src []byte, path string, opts []syntax.ParserOption) ([]span, error) {
+	file, err := syntax.NewParser(opts...).Parse(bytes.NewReader(src), path)
+	if err != nil {
+		return nil, shellSyntaxError(err)
+	}
+	var bodies []span
+	syntax.Walk(file, func(n syntax.Node) bool {
+		if r, ok := n.(*syntax.Redirect); ok && r.Op == syntax.DashHdoc && r.Hdoc != nil {
+			bodies = append(bodies, span{int(r.Hdoc.Pos().Offset()), int(r.Hdoc.End().Offset())})
+		}
+		return true
+	})
+	return bodies, nil
+}
@@ -0,0 +1,42 @@
+package formatters
+
+import "testing"
+
+// TestFormatShell_Keeps_Heredoc_Bodies verifies that heredoc bodies are
+// kept byte for byte, and that <<- bodies are re-indented only when
+// reindentHeredocs is set.
+func TestFormatShell_Keeps_Heredoc_Bodies(t *testing.T) {
+	src := "if true;  then\ncat <<EOF\n  SELECT  *\n\tFROM t\nEOF\n" +
+		"cat <<-'YAML'\n\tkey: value\n\t  nested:  1\n\tYAML\nfi\n"
+	tests := []struct {
+		name     string
+		reindent bool
+		want     string
+	}{
+		{
+			name:     "reindent",
+			reindent: true,
+			want: "if true; then\n\tcat <<EOF\n  SELECT  *\n\tFROM t\nEOF\n" +
+				"\tcat <<-'YAML'\n\t\tkey: value\n\t\t  nested:  1\n\tYAML\nfi\n",
+		},
+		{
+			name:     "keep",
+			reindent: false,
+			want: "if true; then\n\tcat <<EOF\n  SELECT  *\n\tFROM t\nEOF\n" +
+				"\tcat <<-'YAML'\n\tkey: value\n\t  nested:  1\n\tYAML\nfi\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultShellConfig()
+			cfg.ReindentHeredocs = tt.reindent
+			got, err := FormatShell("run.sh", []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatShell: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatShell = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
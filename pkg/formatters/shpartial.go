@@ -0,0 +1,64 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// formatShellPartial formats the lines of src that come before its first
+// syntax error and leaves the rest of the script as written, for scripts
+// that are being edited. The lines kept for formatting are cut back until
+// they parse on their own, so that a statement the error is in, such as an
+// if clause that is not closed yet, is left as written as a whole. It
+// returns err, the error formatting the whole script failed with, when no
+// line before the error can be formatted.
+func formatShellPartial(ctx context.Context, path string, src []byte, cfg ShellConfig, err error) ([]byte, error) {
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return nil, err
+	}
+	cfg.AllowPartial = false
+	opts := shellParserOptions(ctx, path, src, cfg)
+	line := syntaxErr.Line
+	for line > 1 {
+		end := partialEnd(src, line)
+		if end == 0 {
+			break
+		}
+		_, prefixErr := parseShell(opts, src[:end], path)
+		if prefixErr == nil {
+			debugf(ctx, "formatting up to the syntax error at line %d", syntaxErr.Line)
+			formatted, formatErr := FormatShellContext(ctx, path, src[:end], cfg)
+			if formatErr != nil {
+				return nil, err
+			}
+			return append(formatted, src[end:]...), nil
+		}
+		if !errors.As(prefixErr, &syntaxErr) || syntaxErr.Line >= line {
+			break
+		}
+		line = syntaxErr.Line
+	}
+	return nil, err
+}
+
+// partialEnd returns the offset just after the last line before line that
+// is not blank and does not continue on the next line with a backslash, or
+// zero if there is none.
+func partialEnd(src []byte, line int) int {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	end := 0
+	for i := 0; i < line-1 && i < len(lines); i++ {
+		end += len(lines[i])
+	}
+	for end > 0 {
+		start := bytes.LastIndexByte(src[:end-1], '\n') + 1
+		text := bytes.TrimRight(src[start:end], " \t\r\n")
+		if len(text) > 0 && !bytes.HasSuffix(text, []byte("\\")) {
+			return end
+		}
+		end = start
+	}
+	return 0
+}
@@ -0,0 +1,42 @@
+package formatters
+
+import "testing"
+
+// TestFormatShell_Formats_Partial_Scripts verifies that allowPartial
+// formats the lines before the first syntax error, leaving a statement the
+// error is in as written, and still fails when there is nothing before it.
+func TestFormatShell_Formats_Partial_Scripts(t *testing.T) {
+	cfg := DefaultShellConfig()
+	cfg.AllowPartial = true
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "broken command",
+			src:  "echo   a\nif true;  then echo b; fi\n\nfoo(  \necho   c\n",
+			want: "echo a\nif true; then echo b; fi\n\nfoo(  \necho   c\n",
+		},
+		{
+			name: "open clause",
+			src:  "echo   a \\\n  b\nif true;  then\n  echo   c\n",
+			want: "echo a \\\n\tb\nif true;  then\n  echo   c\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatShell("run.sh", []byte(tt.src), cfg)
+			if err != nil {
+				t.Fatalf("FormatShell: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatShell = %q; want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FormatShell("run.sh", []byte("if true;  then\n  echo   c\n"), cfg); err == nil {
+		t.Fatalf("FormatShell accepted a script that is broken from its first line")
+	}
+}
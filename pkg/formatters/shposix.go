@@ -0,0 +1,222 @@
+package formatters
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/fileutil"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// targetsPOSIX reports whether a script is meant to be POSIX: the language
+// is posix, or it is auto and the dialects of cfg map the script to posix,
+// or they do not map it and it has an sh shebang, or no shebang and an .sh
+// extension.
+func targetsPOSIX(path string, src []byte, cfg ShellConfig) bool {
+	language := strings.ToLower(strings.TrimSpace(cfg.Language))
+	if dialect, ok := configuredDialect(path, cfg.Dialects); ok && language == "auto" {
+		language = dialect
+	}
+	switch language {
+	case "posix":
+		return true
+	case "auto":
+		shebang := fileutil.Shebang(src)
+		return shebang == "sh" || shebang == "" && strings.EqualFold(filepath.Ext(path), ".sh")
+	default:
+		return false
+	}
+}
+
+// checkPOSIX parses a script meant to be POSIX as bash and reports every
+// bash-only construct in it as a SyntaxError, where the POSIX parser would
+// stop at the first one.
+func checkPOSIX(opts []syntax.ParserOption, src []byte, path string) error {
+	opts = append(slices.Clip(opts), syntax.Variant(syntax.LangBash))
+	file, err := parseShell(opts, src, path)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	report := func(pos syntax.Pos, feature string) {
+		errs = append(errs, &SyntaxError{
+			Path:    path,
+			Line:    int(pos.Line()),
+			Column:  int(pos.Col()),
+			Message: feature + " are a bash feature, not POSIX",
+		})
+	}
+	syntax.Walk(file, func(n syntax.Node) bool {
+		if pos, feature, ok := bashOnly(n); ok {
+			report(pos, feature)
+		}
+		if stmt, ok := n.(*syntax.Stmt); ok {
+			for _, r := range stmt.Redirs {
+				if r.Op == syntax.WordHdoc || r.Op == syntax.RdrAll || r.Op == syntax.AppAll {
+					report(r.OpPos, "redirects such as "+r.Op.String())
+				}
+			}
+		}
+		return true
+	})
+	sortSyntaxErrors(errs)
+	return errors.Join(errs...)
+}
+
+// bashOnly returns the position and name of the bash-only construct a node
+// is, if it is one.
+func bashOnly(n syntax.Node) (syntax.Pos, string, bool) {
+	switch n := n.(type) {
+	case *syntax.ArrayExpr:
+		return n.Pos(), "arrays", true
+	case *syntax.Assign:
+		if n.Index != nil {
+			return n.Pos(), "array elements", true
+		}
+	case *syntax.TestClause:
+		return n.Pos(), "[[ ]] tests", true
+	case *syntax.ProcSubst:
+		return n.Pos(), "process substitutions", true
+	case *syntax.ArithmCmd:
+		return n.Pos(), "(( )) commands", true
+	case *syntax.LetClause:
+		return n.Pos(), "let commands", true
+	case *syntax.CoprocClause:
+		return n.Pos(), "coprocesses", true
+	case *syntax.ExtGlob:
+		return n.Pos(), "extended globs", true
+	case *syntax.CStyleLoop:
+		return n.Pos(), "C-style for loops", true
+	case *syntax.ForClause:
+		if n.Select {
+			return n.Pos(), "select loops", true
+		}
+	case *syntax.FuncDecl:
+		if n.RsrvWord {
+			return n.Pos(), "function keywords", true
+		}
+	case *syntax.DeclClause:
+		if v := n.Variant.Value; v == "declare" || v == "typeset" || v == "nameref" {
+			return n.Pos(), v + " commands", true
+		}
+	case *syntax.BinaryCmd:
+		if n.Op == syntax.PipeAll {
+			return n.OpPos, "|& pipes", true
+		}
+	case *syntax.SglQuoted:
+		if n.Dollar {
+			return n.Pos(), "$'' strings", true
+		}
+	case *syntax.DblQuoted:
+		if n.Dollar {
+			return n.Pos(), `$"" strings`, true
+		}
+	case *syntax.ParamExp:
+		return bashOnlyParamExp(n)
+	}
+	return syntax.Pos{}, "", false
+}
+
+// bashOnlyParamExp returns the position and name of the bash-only form of
+// a parameter expansion, if it is one.
+func bashOnlyParamExp(n *syntax.ParamExp) (syntax.Pos, string, bool) {
+	switch {
+	case n.Index != nil:
+		return n.Pos(), "array expansions", true
+	case n.Excl || n.Names != 0:
+		return n.Pos(), "indirect expansions", true
+	case n.Slice != nil:
+		return n.Pos(), "substring expansions", true
+	case n.Repl != nil:
+		return n.Pos(), "replacement expansions", true
+	case n.Exp != nil && n.Exp.Op >= syntax.UpperFirst:
+		return n.Pos(), "case and transformation expansions", true
+	}
+	return syntax.Pos{}, "", false
+}
+
+// posixCheckError checks a script meant to be POSIX when cfg.PosixCheck is
+// set, so that its bash-only constructs are reported rather than failing
+// at the first one or being formatted as bash.
+func posixCheckError(opts []syntax.ParserOption, path string, src []byte, cfg ShellConfig) error {
+	if !cfg.PosixCheck || !targetsPOSIX(path, src, cfg) {
+		return nil
+	}
+	return checkPOSIX(opts, src, path)
+}
+
+// bashCommands lists the bash builtins that POSIX shells do not have.
+var bashCommands = []string{ //nolint:gochecknoglobals // read-only lookup
+	"[[", "bind", "builtin", "caller", "compgen", "complete", "declare", "dirs", "disown", "enable",
+	"help", "let", "logout", "mapfile", "popd", "pushd", "readarray", "shopt", "source", "suspend", "typeset",
+}
+
+// bashVariables lists the variables bash sets that POSIX shells do not.
+var bashVariables = []string{ //nolint:gochecknoglobals // read-only lookup
+	"BASH", "BASH_REMATCH", "BASH_SOURCE", "BASH_VERSION", "DIRSTACK", "EUID", "FUNCNAME", "GROUPS",
+	"HOSTNAME", "HOSTTYPE", "MACHTYPE", "OSTYPE", "PIPESTATUS", "RANDOM", "SECONDS", "SHLVL", "UID",
+}
+
+// warnBashisms reports, as warnings, the commands and variables of a
+// script parsed as POSIX that the POSIX parser accepts but only bash
+// provides, in the style of checkbashisms, when cfg.BashismWarnings is set.
+func warnBashisms(ctx context.Context, path string, src []byte, file *syntax.File, cfg ShellConfig) {
+	if !cfg.BashismWarnings || shellVariant(path, src, cfg) != syntax.LangPOSIX {
+		return
+	}
+	syntax.Walk(file, func(n syntax.Node) bool {
+		var feature string
+		switch n := n.(type) {
+		case *syntax.CallExpr:
+			feature = bashismCall(n)
+		case *syntax.ParamExp:
+			if n.Param != nil && slices.Contains(bashVariables, n.Param.Value) {
+				feature = "$" + n.Param.Value + " expansions"
+			}
+		}
+		if feature != "" {
+			warnf(ctx, "%d:%d: %s are a bash feature, not POSIX", n.Pos().Line(), n.Pos().Col(), feature)
+		}
+		return true
+	})
+}
+
+// bashismCall returns the name of the bash-only form of a command, if it
+// is one: a bash builtin, echo -e, read and printf options POSIX lacks,
+// local with options and == in tests.
+func bashismCall(call *syntax.CallExpr) string {
+	if len(call.Args) == 0 {
+		return ""
+	}
+	name, first := call.Args[0].Lit(), ""
+	if len(call.Args) > 1 {
+		first = call.Args[1].Lit()
+	}
+	// options reports whether the first argument holds options other than
+	// the accepted letters.
+	options := func(accepted string) bool {
+		return len(first) > 1 && first[0] == '-' && strings.Trim(first[1:], accepted) != ""
+	}
+	switch {
+	case slices.Contains(bashCommands, name):
+		return name + " commands"
+	case name == "echo" && (first == "-e" || first == "-E"):
+		return "echo " + first + " options"
+	case name == "read" && options("r"):
+		return "read options other than -r"
+	case name == "printf" && first == "-v":
+		return "printf -v options"
+	case name == "local" && options(""):
+		return "local options"
+	case name == "[" || name == "test":
+		for _, arg := range call.Args[1:] {
+			if arg.Lit() == "==" {
+				return "== comparisons in tests"
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package formatters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestFormatShell_Reports_Bash_Only_Constructs verifies that posixCheck
+// reports every bash-only construct of a script meant to be POSIX, and
+// leaves scripts meant for bash alone.
+func TestFormatShell_Reports_Bash_Only_Constructs(t *testing.T) {
+	src := []byte("a=(1 2)\nif [[ -n $a ]]; then\n\tdiff <(ls) x &>/dev/null\nfi\necho ${a/x/y} $'\\t'\n")
+	cfg := DefaultShellConfig()
+	cfg.PosixCheck = true
+
+	_, err := FormatShell("run.sh", src, cfg)
+	want := "run.sh:1:3: arrays are a bash feature, not POSIX\n" +
+		"run.sh:2:4: [[ ]] tests are a bash feature, not POSIX\n" +
+		"run.sh:3:7: process substitutions are a bash feature, not POSIX\n" +
+		"run.sh:3:15: redirects such as &> are a bash feature, not POSIX\n" +
+		"run.sh:5:6: replacement expansions are a bash feature, not POSIX\n" +
+		"run.sh:5:15: $'' strings are a bash feature, not POSIX"
+	if err == nil || err.Error() != want {
+		t.Fatalf("FormatShell error = %v; want %s", err, want)
+	}
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("error %v is not a SyntaxError", err)
+	}
+
+	for _, path := range []string{"run.bash", "bin/run"} {
+		if _, err = FormatShell(path, append([]byte("#!/bin/bash\n"), src...), cfg); err != nil {
+			t.Fatalf("FormatShell(%s) = %v; want bash scripts formatted", path, err)
+		}
+	}
+	if _, err = FormatShell("run.sh", []byte("if [ -n \"$a\" ]; then echo ok; fi\n"), cfg); err != nil {
+		t.Fatalf("FormatShell rejected a POSIX script: %v", err)
+	}
+}
+
+// TestFormatShell_Warns_About_Bashisms verifies that bashismWarnings logs
+// the bash-only commands and variables the POSIX parser accepts, and that
+// the script is formatted all the same.
+func TestFormatShell_Warns_About_Bashisms(t *testing.T) {
+	src := "#!/bin/sh\nsource ./env\necho -e \"$RANDOM\"\nread -p x y\n" +
+		"if [ \"$a\" == b ];  then local -r c; fi\nread -r z\necho -n ok\n"
+	cfg := DefaultShellConfig()
+	cfg.BashismWarnings = true
+
+	var warnings []string
+	ctx := WithWarningLog(context.Background(), func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	})
+	got, err := FormatShellContext(ctx, "run", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatShellContext: %v", err)
+	}
+	if want := strings.Replace(src, ";  then", "; then", 1); string(got) != want {
+		t.Fatalf("FormatShellContext = %q; want %q", got, want)
+	}
+	want := []string{
+		"2:1: source commands are a bash feature, not POSIX",
+		"3:1: echo -e options are a bash feature, not POSIX",
+		"3:10: $RANDOM expansions are a bash feature, not POSIX",
+		"4:1: read options other than -r are a bash feature, not POSIX",
+		"5:4: == comparisons in tests are a bash feature, not POSIX",
+		"5:25: local options are a bash feature, not POSIX",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("warnings = %q; want %q", warnings, want)
+	}
+
+	warnings = nil
+	if _, err = FormatShellContext(ctx, "run.bash", []byte(src), cfg); err != nil || len(warnings) > 0 {
+		t.Fatalf("FormatShellContext(run.bash) = %v with warnings %q; want no warnings", err, warnings)
+	}
+}
@@ -0,0 +1,84 @@
+package formatters
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// templateMask is a templated script whose placeholders, such as
+// {{ .Var }} or @@VERSION@@, were replaced by words the shell parser
+// accepts, so that the script can be formatted and the placeholders put
+// back afterwards.
+type templateMask struct {
+	// spans are the placeholders in the original script, in order.
+	spans []span
+	// tokens are the words that replaced them, and originals their text.
+	tokens, originals []string
+}
+
+// templatePattern compiles the placeholder patterns into one expression
+// that matches any of them.
+func templatePattern(patterns []string) (*regexp.Regexp, error) {
+	alternatives := make([]string, len(patterns))
+	for i, p := range patterns {
+		alternatives[i] = "(?:" + p + ")"
+	}
+	return regexp.Compile(strings.Join(alternatives, "|"))
+}
+
+// maskTemplates replaces every placeholder of src matching one of the
+// patterns by a word such as __tpl0__ that src does not contain yet.
+func maskTemplates(src []byte, patterns []string) ([]byte, templateMask, error) {
+	re, err := templatePattern(patterns)
+	if err != nil {
+		return nil, templateMask{}, err
+	}
+	prefix := "__tpl"
+	for bytes.Contains(src, []byte(prefix)) {
+		prefix = "_" + prefix
+	}
+	var m templateMask
+	var out bytes.Buffer
+	next := 0
+	for i, loc := range re.FindAllIndex(src, -1) {
+		token := prefix + strconv.Itoa(i) + "__"
+		out.Write(src[next:loc[0]])
+		out.WriteString(token)
+		m.spans = append(m.spans, span{loc[0], loc[1]})
+		m.tokens = append(m.tokens, token)
+		m.originals = append(m.originals, string(src[loc[0]:loc[1]]))
+		next = loc[1]
+	}
+	out.Write(src[next:])
+	return out.Bytes(), m, nil
+}
+
+// offset maps an offset of the original script to the masked one. Offsets
+// inside a placeholder map to the start of its word.
+func (m templateMask) offset(offset int) int {
+	shift := 0
+	for i, s := range m.spans {
+		if offset < s.end {
+			if offset > s.start {
+				offset = s.start
+			}
+			break
+		}
+		shift += len(m.tokens[i]) - (s.end - s.start)
+	}
+	return offset + shift
+}
+
+// restore puts the placeholders back into formatted.
+func (m templateMask) restore(formatted []byte) []byte {
+	if len(m.tokens) == 0 {
+		return formatted
+	}
+	pairs := make([]string, 0, 2*len(m.tokens))
+	for i, token := range m.tokens {
+		pairs = append(pairs, token, m.originals[i])
+	}
+	return []byte(strings.NewReplacer(pairs...).Replace(string(formatted)))
+}
@@ -0,0 +1,46 @@
+package formatters
+
+import (
+	"context"
+	"testing"
+)
+
+// TestFormatShell_Keeps_Template_Placeholders verifies that placeholders
+// matching templatePlaceholders are kept as written, in words, statements
+// and heredocs, while the script around them is formatted.
+func TestFormatShell_Keeps_Template_Placeholders(t *testing.T) {
+	src := "{{ if .Debug }}\nset  -x\n{{ end }}\nVERSION=@@VERSION@@\n" +
+		"if true;  then\necho {{ .Name | quote }}  \"${TEMPLATE}\"\nfi\n" +
+		"cat <<EOF\n  port: {{ .Port }}\nEOF\n"
+	cfg := DefaultShellConfig()
+	cfg.TemplatePlaceholders = []string{`\{\{.*?\}\}`, `@@[A-Z_]+@@`}
+
+	got, err := FormatShell("entrypoint.sh.tmpl", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatShell: %v", err)
+	}
+	want := "{{ if .Debug }}\nset -x\n{{ end }}\nVERSION=@@VERSION@@\n" +
+		"if true; then\n\techo {{ .Name | quote }} \"${TEMPLATE}\"\nfi\n" +
+		"cat <<EOF\n  port: {{ .Port }}\nEOF\n"
+	if string(got) != want {
+		t.Fatalf("FormatShell = %q; want %q", got, want)
+	}
+}
+
+// TestFormatShellRange_Keeps_Template_Placeholders verifies that range
+// offsets past placeholders still select the statements they cover.
+func TestFormatShellRange_Keeps_Template_Placeholders(t *testing.T) {
+	src := "echo  {{ .A }}\necho  {{ .B }}\n"
+	cfg := DefaultShellConfig()
+	cfg.TemplatePlaceholders = []string{`\{\{.*?\}\}`}
+
+	start := len("echo  {{ .A }}\n")
+	got, err := FormatShellRange(context.Background(), "run.sh", []byte(src), start, len(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatShellRange: %v", err)
+	}
+	want := "echo  {{ .A }}\necho {{ .B }}\n"
+	if string(got) != want {
+		t.Fatalf("FormatShellRange = %q; want %q", got, want)
+	}
+}
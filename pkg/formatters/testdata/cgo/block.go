@@ -0,0 +1,22 @@
+package cgo
+
+/*
+#cgo CFLAGS: -DPNG_DEBUG=1 -DSOMETHING_VERY_LONG_THAT_GOES_ON_AND_ON=1 -DANOTHER_DEFINE=2 -DYET_ANOTHER_DEFINE=3
+#include <stdlib.h>
+
+  static int add(int a, int b) {
+	return a + b;
+  }
+*/
+import "C"
+
+import (
+	"fmt"
+	"os"
+)
+
+// Add adds two numbers in C.
+func Add(a, b int) int {
+	fmt.Fprintln(os.Stderr, "adding")
+	return int(C.add(C.int(a), C.int(b)))
+}
@@ -0,0 +1,21 @@
+package cgo
+
+import (
+	"strings"
+
+	// #include <string.h>
+	// static int length(const char *s) { return strlen(s); } // the length of a C string, which is long
+	"C"
+	"errors"
+	"unsafe"
+)
+
+// Length returns the length of s as C sees it.
+func Length(s string) (int, error) {
+	if strings.ContainsRune(s, 0) {
+		return 0, errors.New("nul byte")
+	}
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+	return int(C.length(cs)), nil
+}
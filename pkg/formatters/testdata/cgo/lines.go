@@ -0,0 +1,15 @@
+package cgo
+
+// #cgo LDFLAGS: -lm
+// #include <math.h>
+//
+// - this line is C, not a list, and it is long enough to be rewrapped by a careless pass
+//   int indented;
+// # define SQUARE(x) ((x) * (x))
+// static double root(double x) { return sqrt(x); } // a trailing comment that runs past the width
+import "C"
+
+// Root returns the square root of x.
+func Root(x float64) float64 {
+	return float64(C.root(C.double(x)))
+}
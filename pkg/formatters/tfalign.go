@@ -0,0 +1,91 @@
+package formatters
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// unalignHCL undoes the column alignment of hclwrite in src: the padding
+// before the = of attributes and object items, and before the comments
+// after them, becomes a single space.
+func unalignHCL(src []byte, path string) []byte {
+	tokens, diags := hclsyntax.LexConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+	var edits []goEdit
+	for i := 1; i < len(tokens); i++ {
+		tok, prev := tokens[i], tokens[i-1]
+		if tok.Type != hclsyntax.TokenEqual && !isLineComment(tok) || prev.Type == hclsyntax.TokenNewline {
+			continue
+		}
+		gap := span{prev.Range.End.Byte, tok.Range.Start.Byte}
+		if gap.end-gap.start > 1 && len(bytes.Trim(src[gap.start:gap.end], " ")) == 0 {
+			edits = append(edits, goEdit{gap, " "})
+		}
+	}
+	if len(edits) == 0 {
+		return src
+	}
+	return applyGoEdits(src, edits)
+}
+
+// alignHCLComments lines up the comments at the ends of consecutive lines
+// of src that hold an attribute or object item, one space after the
+// longest of them, the way hclwrite does before passes such as unalignHCL
+// and reindentHCL change the widths of the lines.
+func alignHCLComments(src []byte, path string) []byte {
+	tokens, diags := hclsyntax.LexConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+	// A trailing comment is the gap before the comment of a line and the
+	// column that gap starts at.
+	type trailing struct {
+		gap span
+		col int
+	}
+	var group []trailing
+	var edits []goEdit
+	flush := func() {
+		col := 0
+		for _, comment := range group {
+			col = max(col, comment.col)
+		}
+		for _, comment := range group {
+			if padding := col - comment.col + 1; comment.gap.end-comment.gap.start != padding {
+				edits = append(edits, goEdit{comment.gap, strings.Repeat(" ", padding)})
+			}
+		}
+		group = nil
+	}
+	start := 0
+	for i, tok := range tokens {
+		if tok.Type != hclsyntax.TokenNewline && !isLineComment(tok) && tok.Type != hclsyntax.TokenEOF {
+			continue
+		}
+		line := tokens[start : i+1]
+		start = i + 1
+		if n := len(line); n >= 4 && line[0].Type == hclsyntax.TokenIdent && line[1].Type == hclsyntax.TokenEqual &&
+			isLineComment(line[n-1]) && line[n-2].Range.End.Line == line[n-1].Range.Start.Line {
+			prev := line[n-2]
+			group = append(group, trailing{span{prev.Range.End.Byte, tok.Range.Start.Byte}, prev.Range.End.Column})
+			continue
+		}
+		flush()
+	}
+	flush()
+	if len(edits) == 0 {
+		return src
+	}
+	return applyGoEdits(src, edits)
+}
+
+// isLineComment reports whether tok is a # or // comment, which runs to
+// the end of its line.
+func isLineComment(tok hclsyntax.Token) bool {
+	return tok.Type == hclsyntax.TokenComment && !bytes.HasPrefix(tok.Bytes, []byte("/*"))
+}
@@ -0,0 +1,43 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Keeps_Assignments_Unaligned verifies that alignAssignments
+// false puts a single space before the = of attributes and object items
+// and before the comments after them.
+func TestFormatHCL_Keeps_Assignments_Unaligned(t *testing.T) {
+	src := "a = 1 # one\nbbb = 2 # two\ntags = {\n  Name = \"n\"\n  Environment = \"e\"\n}\nok = a == 1\n"
+	cfg := DefaultHCLConfig()
+	cfg.AlignAssignments = false
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	if string(got) != src {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, src)
+	}
+}
+
+// TestFormatHCL_Preserves_Comment_Alignment verifies that
+// preserveCommentAlignment lines up the comments after consecutive
+// attributes that alignAssignments false leaves unaligned, in each group
+// of its own.
+func TestFormatHCL_Preserves_Comment_Alignment(t *testing.T) {
+	src := "a = 1 # one\nbbbbb = \"${var.x}\" # two\nc = [\n  1,\n] # three\n" +
+		"tags = {\n  Name = \"n\" # name\n  Environment = \"e\" # environment\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.AlignAssignments = false
+	cfg.IndentWidth = 4
+	cfg.PreserveCommentAlignment = true
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "a = 1         # one\nbbbbb = var.x # two\nc = [\n    1,\n] # three\n" +
+		"tags = {\n    Name = \"n\"        # name\n    Environment = \"e\" # environment\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
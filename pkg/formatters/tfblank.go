@@ -0,0 +1,93 @@
+package formatters
+
+import (
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// separateHCLBlocks puts exactly between blank lines between consecutive
+// top-level blocks of body, and cuts every other run of blank lines in
+// body and its nested blocks down to one. Blank lines inside expressions
+// are left alone.
+func separateHCLBlocks(body *hclwrite.Body, between int) {
+	items := bodyItems(body)
+	var out []hclItem
+	for i, item := range items {
+		if item.block != nil && i > 0 && items[i-1].block != nil && between > 0 {
+			out = append(out, hclItem{tokens: newlineTokens(between)})
+		}
+		if item.block != nil || item.attr != nil {
+			out = append(out, item)
+			continue
+		}
+		blank := !slices.ContainsFunc(item.tokens, func(tok *hclwrite.Token) bool {
+			return tok.Type != hclsyntax.TokenNewline
+		})
+		switch {
+		case blank && i > 0 && i < len(items)-1 && items[i-1].block != nil && items[i+1].block != nil:
+			item.tokens = newlineTokens(between)
+		default:
+			item.tokens = singleBlankLines(item.tokens)
+		}
+		if len(item.tokens) > 0 {
+			out = append(out, item)
+		}
+	}
+	if !sameItems(items, out) {
+		setBodyItems(body, out)
+	}
+	for _, block := range body.Blocks() {
+		limitHCLBlankLines(block.Body())
+	}
+}
+
+// limitHCLBlankLines cuts the runs of blank lines in body and its nested
+// blocks down to one.
+func limitHCLBlankLines(body *hclwrite.Body) {
+	items := bodyItems(body)
+	out := slices.Clone(items)
+	for i, item := range out {
+		if item.block == nil && item.attr == nil {
+			out[i].tokens = singleBlankLines(item.tokens)
+		}
+	}
+	if !sameItems(items, out) {
+		setBodyItems(body, out)
+	}
+	for _, block := range body.Blocks() {
+		limitHCLBlankLines(block.Body())
+	}
+}
+
+// singleBlankLines drops the newlines of tokens that follow another
+// newline, leaving at most one blank line in a row.
+func singleBlankLines(tokens hclwrite.Tokens) hclwrite.Tokens {
+	var kept hclwrite.Tokens
+	for i, tok := range tokens {
+		if tok.Type == hclsyntax.TokenNewline && i > 0 && tokens[i-1].Type == hclsyntax.TokenNewline {
+			continue
+		}
+		kept = append(kept, tok)
+	}
+	return kept
+}
+
+// newlineTokens returns n newline tokens, which make n blank lines after
+// a block.
+func newlineTokens(n int) hclwrite.Tokens {
+	tokens := make(hclwrite.Tokens, n)
+	for i := range tokens {
+		tokens[i] = &hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}
+	}
+	return tokens
+}
+
+// sameItems reports whether a and b hold the same items with the same
+// number of tokens, so that the body they come from needs no change.
+func sameItems(a, b []hclItem) bool {
+	return slices.EqualFunc(a, b, func(x, y hclItem) bool {
+		return x.attr == y.attr && x.block == y.block && len(x.tokens) == len(y.tokens)
+	})
+}
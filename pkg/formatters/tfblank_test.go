@@ -0,0 +1,43 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Separates_Blocks verifies that blankLinesBetweenBlocks
+// puts the given number of blank lines between top-level blocks and cuts
+// other runs of blank lines to one.
+func TestFormatHCL_Separates_Blocks(t *testing.T) {
+	src := "variable \"a\" {}\nvariable \"b\" {}\n\n\n\nresource \"r\" \"x\" {\n  a = 1\n\n\n  b = 2\n}\n" +
+		"\n\n\n# about c\n\n\nc = 1\n"
+	tests := []struct {
+		name    string
+		between int
+		want    string
+	}{
+		{
+			name:    "one",
+			between: 1,
+			want: "variable \"a\" {}\n\nvariable \"b\" {}\n\nresource \"r\" \"x\" {\n  a = 1\n\n  b = 2\n}\n" +
+				"\n# about c\n\nc = 1\n",
+		},
+		{
+			name:    "none",
+			between: 0,
+			want: "variable \"a\" {}\nvariable \"b\" {}\nresource \"r\" \"x\" {\n  a = 1\n\n  b = 2\n}\n" +
+				"\n# about c\n\nc = 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultHCLConfig()
+			cfg.BlankLinesBetweenBlocks = &tt.between
+
+			got, err := FormatHCL("main.tf", []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatHCL: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package formatters
+
+import (
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// normalizeCollections lays out the lists, objects and function calls of
+// an expression that span lines, the expression starting col columns into
+// a line indented depth levels. Those that fit within cfg.LineWidth once
+// joined are written on one line; the others get one element per line,
+// followed by a comma in lists and function calls, the way terraform fmt
+// writes them, and no comma in objects. Expressions with comments or
+// heredocs are kept as written.
+func (f *hclFormatter) normalizeCollections(tokens hclwrite.Tokens, depth, col int) hclwrite.Tokens {
+	if slices.ContainsFunc(tokens, func(tok *hclwrite.Token) bool {
+		return tok.Type == hclsyntax.TokenComment || tok.Type == hclsyntax.TokenOHeredoc
+	}) {
+		return tokens
+	}
+	return f.layoutExpression(tokens, depth, col, 0)
+}
+
+// layoutExpression lays out the collections of tokens, which start col
+// columns into a line indented depth levels and are followed on their last
+// line by tail columns of other tokens.
+func (f *hclFormatter) layoutExpression(tokens hclwrite.Tokens, depth, col, tail int) hclwrite.Tokens {
+	out := make(hclwrite.Tokens, 0, len(tokens))
+	lineStart, lineCol := 0, col
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.Type == hclsyntax.TokenNewline {
+			out = append(out, tok)
+			lineStart, lineCol = len(out), (depth+1)*max(f.cfg.IndentWidth, 1)
+			continue
+		}
+		open, end, ok := collectionAt(tokens, i)
+		if !ok {
+			if tok.Type == hclsyntax.TokenOQuote {
+				// Strings, with their interpolations, are kept as written.
+				end = matchingToken(tokens, i)
+				out = append(out, tokens[i:end+1]...)
+				i = end
+				continue
+			}
+			out = append(out, tok)
+			continue
+		}
+		after := tokens[end+1:]
+		rest := tail
+		if next := slices.IndexFunc(after, isNewline); next >= 0 {
+			after, rest = after[:next], 0
+		}
+		if len(after) > 0 {
+			rest += hclWidth(after)
+		}
+		start := lineCol
+		if len(out) > lineStart {
+			start += hclWidth(out[lineStart:]) + 1
+		}
+		out = append(out, f.layoutCollection(tokens[i:end+1], open-i+1, depth, start, rest)...)
+		i = end
+	}
+	return out
+}
+
+// layoutCollection lays out a collection whose elements start after the
+// first open tokens of span.
+func (f *hclFormatter) layoutCollection(span hclwrite.Tokens, open, depth, col, tail int) hclwrite.Tokens {
+	inner := span[open : len(span)-1]
+	if !hasNewline(inner) || isForExpression(inner) {
+		return span
+	}
+	if flat := flattenExpression(span); col+hclWidth(flat)+tail <= f.cfg.LineWidth {
+		return flat
+	}
+	object := span[open-1].Type == hclsyntax.TokenOBrace
+	elements := splitElements(inner, object)
+	out := slices.Concat(span[:open], newlineTokens(1))
+	indent := (depth + 1) * max(f.cfg.IndentWidth, 1)
+	for i, element := range elements {
+		// An expanded final argument can't be followed by a comma.
+		comma := !object && !(i == len(elements)-1 && element[len(element)-1].Type == hclsyntax.TokenEllipsis)
+		elementTail := 0
+		if comma {
+			elementTail = 1
+		}
+		out = append(out, f.layoutExpression(element, depth+1, indent, elementTail)...)
+		if comma {
+			out = append(out, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
+		}
+		out = append(out, newlineTokens(1)...)
+	}
+	return append(out, span[len(span)-1])
+}
+
+// flattenExpression joins tokens onto one line, dropping the trailing
+// commas of their collections and separating the items of their objects
+// with commas.
+func flattenExpression(tokens hclwrite.Tokens) hclwrite.Tokens {
+	out := make(hclwrite.Tokens, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch open, end, ok := collectionAt(tokens, i); {
+		case ok && !isForExpression(tokens[open+1:end]):
+			out = append(out, tokens[i:open+1]...)
+			for j, element := range splitElements(tokens[open+1:end], tokens[open].Type == hclsyntax.TokenOBrace) {
+				if j > 0 {
+					out = append(out, &hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")})
+				}
+				out = append(out, flattenExpression(element)...)
+			}
+			out = append(out, tokens[end])
+			i = end
+		case tok.Type == hclsyntax.TokenOQuote:
+			end = matchingToken(tokens, i)
+			out = append(out, tokens[i:end+1]...)
+			i = end
+		case tok.Type != hclsyntax.TokenNewline:
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// splitElements splits the tokens inside a collection at its commas, and
+// at its newlines too in objects, whose items they separate, dropping the
+// separators and the empty elements.
+func splitElements(inner hclwrite.Tokens, object bool) []hclwrite.Tokens {
+	var elements []hclwrite.Tokens
+	var element hclwrite.Tokens
+	depth := 0
+	for _, tok := range inner {
+		if depth == 0 && (tok.Type == hclsyntax.TokenComma || object && tok.Type == hclsyntax.TokenNewline) {
+			if len(element) > 0 {
+				elements = append(elements, element)
+			}
+			element = nil
+			continue
+		}
+		depth += nesting(tok.Type)
+		if tok.Type == hclsyntax.TokenNewline && depth == 0 {
+			continue
+		}
+		element = append(element, tok)
+	}
+	if len(element) > 0 {
+		elements = append(elements, element)
+	}
+	return elements
+}
+
+// collectionAt reports whether a list, an object or a function call starts
+// at tokens[i], returning the index of its opening bracket and of its
+// closing one.
+func collectionAt(tokens hclwrite.Tokens, i int) (int, int, bool) {
+	open := i
+	switch {
+	case tokens[i].Type == hclsyntax.TokenOBrack, tokens[i].Type == hclsyntax.TokenOBrace:
+	case tokens[i].Type == hclsyntax.TokenIdent && i+1 < len(tokens) && tokens[i+1].Type == hclsyntax.TokenOParen:
+		open = i + 1
+	default:
+		return 0, 0, false
+	}
+	end := matchingToken(tokens, open)
+	if end >= len(tokens) {
+		return 0, 0, false
+	}
+	return open, end, true
+}
+
+// matchingToken returns the index of the token that closes the one at
+// tokens[open], or len(tokens) when none does.
+func matchingToken(tokens hclwrite.Tokens, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if depth += nesting(tokens[i].Type); depth == 0 {
+			return i
+		}
+	}
+	return len(tokens)
+}
+
+// isForExpression reports whether the tokens inside brackets or braces are
+// a for expression, which is kept as written.
+func isForExpression(inner hclwrite.Tokens) bool {
+	first := slices.IndexFunc(inner, func(tok *hclwrite.Token) bool { return !isNewline(tok) })
+	return first >= 0 && inner[first].Type == hclsyntax.TokenIdent && string(inner[first].Bytes) == "for"
+}
+
+// isNewline reports whether tok is a newline.
+func isNewline(tok *hclwrite.Token) bool {
+	return tok.Type == hclsyntax.TokenNewline
+}
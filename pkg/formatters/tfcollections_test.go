@@ -0,0 +1,63 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Normalizes_Collections verifies that normalizeCollections
+// joins the collections that span lines onto one line when they fit and
+// puts one element per line in the others, with trailing commas in lists
+// and function calls, leaving for expressions and commented lists alone.
+func TestFormatHCL_Normalizes_Collections(t *testing.T) {
+	src := "locals {\n" +
+		"  a = [\n    1, 2,\n    3\n  ]\n" +
+		"  b = concat(\n    var.x,\n" +
+		"    [\"aaaaaaaaaaaaaaaaaaaa\", \"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\",\n      \"cccccccccccccccccccccccc\"])\n" +
+		"  c = {\n    x = 1\n    y = [\n      2,\n    ]\n  }\n" +
+		"  d = format(\"%s\",\n    var.args...)\n" +
+		"  e = [for x in var.y :\n    x]\n" +
+		"  f = [\n    # first\n    1,\n  ]\n" +
+		"}\n"
+	cfg := DefaultHCLConfig()
+	cfg.NormalizeCollections = true
+	cfg.LineWidth = 60
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "locals {\n" +
+		"  a = [1, 2, 3]\n" +
+		"  b = concat(\n    var.x,\n    [\n      \"aaaaaaaaaaaaaaaaaaaa\",\n" +
+		"      \"bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb\",\n      \"cccccccccccccccccccccccc\",\n    ],\n  )\n" +
+		"  c = { x = 1, y = [2] }\n" +
+		"  d = format(\"%s\", var.args...)\n" +
+		"  e = [for x in var.y :\n  x]\n" +
+		"  f = [\n    # first\n    1,\n  ]\n" +
+		"}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL = %q; want %q", got, want)
+	}
+	again, err := FormatHCL("main.tf", got, cfg)
+	if err != nil || string(again) != want {
+		t.Fatalf("FormatHCL twice = %q, %v; want %q", again, err, want)
+	}
+}
+
+// TestFormatHCL_Expands_Objects_Without_Commas verifies that the items of
+// an object spanning lines that is too long for one go one per line,
+// without the commas that separated them.
+func TestFormatHCL_Expands_Objects_Without_Commas(t *testing.T) {
+	src := "tags = merge(var.tags, { Name = \"web-server-primary\",\n  Environment = \"production\" }, var.extra)\n"
+	cfg := DefaultHCLConfig()
+	cfg.NormalizeCollections = true
+	cfg.LineWidth = 40
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "tags = merge(\n  var.tags,\n  {\n    Name        = \"web-server-primary\"\n" +
+		"    Environment = \"production\"\n  },\n  var.extra,\n)\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package formatters
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Values of HCLConfig.Dialect.
+const (
+	// HCLDialectAuto picks the dialect from the file name.
+	HCLDialectAuto = "auto"
+	// HCLDialectTerraform applies the rules of terraform fmt.
+	HCLDialectTerraform = "terraform"
+	// HCLDialectPacker formats Packer templates, which have no legacy
+	// variable types to rewrite.
+	HCLDialectPacker = "packer"
+	// HCLDialectNomad formats Nomad job specifications, keeping the bodies
+	// of template blocks as written.
+	HCLDialectNomad = "nomad"
+	// HCLDialectTerragrunt formats Terragrunt configurations, whose inputs
+	// can be sorted.
+	HCLDialectTerragrunt = "terragrunt"
+	// HCLDialectGeneric formats any HCL, such as Vault, Consul and Boundary
+	// policies, without the rewrites of terraform fmt.
+	HCLDialectGeneric = "hcl"
+)
+
+// hclDialects lists the accepted values of HCLConfig.Dialect.
+var hclDialects = []string{ //nolint:gochecknoglobals // read-only lookup
+	HCLDialectAuto,
+	HCLDialectTerraform,
+	HCLDialectPacker,
+	HCLDialectNomad,
+	HCLDialectTerragrunt,
+	HCLDialectGeneric,
+}
+
+// hclDialect returns the dialect to format path in: the one cfg names, or
+// for auto, the one cfg.Dialects maps the file to, packer for .pkr.hcl and
+// .pkrvars.hcl files, nomad for .nomad and .nomad.hcl files, terragrunt
+// for terragrunt.hcl files, hcl for dependency lock files, which are only
+// laid out, and terraform for the rest.
+func hclDialect(path string, cfg HCLConfig) string {
+	if cfg.Dialect != "" && cfg.Dialect != HCLDialectAuto {
+		return cfg.Dialect
+	}
+	if dialect, ok := configuredHCLDialect(path, cfg.Dialects); ok {
+		return dialect
+	}
+	name := strings.ToLower(filepath.Base(path))
+	if strings.HasSuffix(name, ".pkr.hcl") || strings.HasSuffix(name, ".pkrvars.hcl") {
+		return HCLDialectPacker
+	}
+	if strings.HasSuffix(name, ".nomad") || strings.HasSuffix(name, ".nomad.hcl") {
+		return HCLDialectNomad
+	}
+	if name == "terragrunt.hcl" {
+		return HCLDialectTerragrunt
+	}
+	if name == lockFileName {
+		return HCLDialectGeneric
+	}
+	return HCLDialectTerraform
+}
+
+// configuredHCLDialect returns the dialect dialects maps a file to, by its
+// name or else by the longest of its extensions, such as .policy.hcl,
+// which are matched regardless of case.
+func configuredHCLDialect(path string, dialects map[string]string) (string, bool) {
+	base := filepath.Base(path)
+	if dialect, ok := dialects[base]; ok {
+		return dialect, true
+	}
+	name := strings.ToLower(base)
+	dialect, longest := "", 0
+	for key, value := range dialects {
+		if strings.HasPrefix(key, ".") && strings.HasSuffix(name, strings.ToLower(key)) && len(key) > longest {
+			dialect, longest = value, len(key)
+		}
+	}
+	return dialect, longest > 0
+}
+
+// dialectConfig turns off the options of cfg that do not apply to dialect:
+// the Terraform-specific rewrites outside terraform, and every rewrite of
+// terraform fmt in generic HCL.
+func dialectConfig(dialect string, cfg HCLConfig) HCLConfig {
+	if dialect != HCLDialectTerraform {
+		cfg.NormalizeTypes = false
+		cfg.SortRequiredProviders = false
+		cfg.OrderMetaArguments = false
+	}
+	if dialect != HCLDialectTerragrunt {
+		cfg.SortInputs = false
+	}
+	if dialect == HCLDialectGeneric {
+		cfg.UnwrapInterpolations = false
+	}
+	return cfg
+}
+
+// keepTemplateBodies copies the bodies of the template blocks of src, from
+// after their opening brace to their closing brace, over those of
+// formatted, so that the templates of Nomad jobs are kept as written.
+func keepTemplateBodies(src, formatted []byte, path string) ([]byte, error) {
+	original := templateBodies(src, path)
+	if len(original) == 0 {
+		return formatted, nil
+	}
+	printed := templateBodies(formatted, path)
+	if len(printed) != len(original) {
+		return nil, errors.New("formatting changed the number of template blocks")
+	}
+	edits := make([]goEdit, len(printed))
+	for i, body := range printed {
+		edits[i] = goEdit{body, string(src[original[i].start:original[i].end])}
+	}
+	return applyGoEdits(formatted, edits), nil
+}
+
+// templateBodies returns the spans of the bodies of the template blocks
+// of src, at any depth, in order.
+func templateBodies(src []byte, path string) []span {
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	var spans []span
+	var walk func(body *hclsyntax.Body)
+	walk = func(body *hclsyntax.Body) {
+		for _, block := range body.Blocks {
+			if block.Type == "template" {
+				spans = append(spans, span{block.OpenBraceRange.End.Byte, block.CloseBraceRange.Start.Byte})
+				continue
+			}
+			walk(block.Body)
+		}
+	}
+	walk(body)
+	return spans
+}
@@ -0,0 +1,103 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Skips_Terraform_Rules_For_Packer verifies that Packer
+// templates keep their variable types while still being formatted, and
+// that the dialect option overrides the file name.
+func TestFormatHCL_Skips_Terraform_Rules_For_Packer(t *testing.T) {
+	src := "variable \"tags\" {\n  type = map\n}\nsource \"amazon-ebs\" \"x\" {\n  ami_name = \"${var.name}\"\n}\n"
+	packer := "variable \"tags\" {\n  type = map\n}\nsource \"amazon-ebs\" \"x\" {\n  ami_name = var.name\n}\n"
+	terraform := "variable \"tags\" {\n  type = map(any)\n}\nsource \"amazon-ebs\" \"x\" {\n  ami_name = var.name\n}\n"
+	tests := []struct {
+		path    string
+		dialect string
+		want    string
+	}{
+		{"build.pkr.hcl", HCLDialectAuto, packer},
+		{"vars.pkrvars.hcl", HCLDialectAuto, packer},
+		{"main.tf", HCLDialectAuto, terraform},
+		{"build.hcl", HCLDialectPacker, packer},
+		{"build.pkr.hcl", HCLDialectTerraform, terraform},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+"/"+tt.dialect, func(t *testing.T) {
+			cfg := DefaultHCLConfig()
+			cfg.Dialect = tt.dialect
+
+			got, err := FormatHCL(tt.path, []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatHCL: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatHCL_Keeps_Nomad_Templates verifies that the bodies of the
+// template blocks of Nomad jobs are kept as written while the rest of the
+// job is formatted.
+func TestFormatHCL_Keeps_Nomad_Templates(t *testing.T) {
+	src := "job \"web\" {\n    datacenters = [\"dc1\"]\n  group \"g\" {\n    task \"t\" {\n" +
+		"      template {\n        data = \"${NOMAD_ADDR}\"\n        destination   = \"local/env\"\n      }\n" +
+		"    }\n  }\n}\n"
+	want := "job \"web\" {\n  datacenters = [\"dc1\"]\n  group \"g\" {\n    task \"t\" {\n" +
+		"      template {\n        data = \"${NOMAD_ADDR}\"\n        destination   = \"local/env\"\n      }\n" +
+		"    }\n  }\n}\n"
+	for _, path := range []string{"web.nomad", "web.nomad.hcl"} {
+		got, err := FormatHCL(path, []byte(src), DefaultHCLConfig())
+		if err != nil {
+			t.Fatalf("FormatHCL(%s): %v", path, err)
+		}
+		if string(got) != want {
+			t.Fatalf("FormatHCL(%s) mismatch\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+		}
+	}
+}
+
+// TestFormatHCL_Uses_Configured_Dialects verifies that dialects maps files
+// by name or by their longest extension, and that the hcl dialect skips
+// every rewrite of terraform fmt while still formatting.
+func TestFormatHCL_Uses_Configured_Dialects(t *testing.T) {
+	src := "path \"secret/*\" {\n  capabilities = [\"read\"]\n  value = \"${x}\"\n}\n"
+	generic := "path \"secret/*\" {\n  capabilities = [\"read\"]\n  value        = \"${x}\"\n}\n"
+	terraform := "path \"secret/*\" {\n  capabilities = [\"read\"]\n  value        = x\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.Dialects = map[string]string{".hcl": "hcl", ".tf.hcl": "terraform", "main.hcl": "terraform"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"policies/admin.HCL", generic},
+		{"stack.tf.hcl", terraform},
+		{"main.hcl", terraform},
+		{"main.tf", terraform},
+	}
+	for _, tt := range tests {
+		got, err := FormatHCL(tt.path, []byte(src), cfg)
+		if err != nil {
+			t.Fatalf("FormatHCL(%s): %v", tt.path, err)
+		}
+		if string(got) != tt.want {
+			t.Fatalf("FormatHCL(%s) mismatch\n--- got ---\n%s\n--- want ---\n%s", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestHCLConfig_Validate_Rejects_Bad_Dialects verifies that unknown
+// dialects, empty extensions and dialects without the auto dialect are
+// reported.
+func TestHCLConfig_Validate_Rejects_Bad_Dialects(t *testing.T) {
+	cfg := DefaultHCLConfig()
+	cfg.Dialect = HCLDialectTerraform
+	cfg.Dialects = map[string]string{"": "hcl", ".x": "auto"}
+	err := cfg.Validate()
+	want := "dialects: has no effect unless dialect is auto\n" +
+		"dialects: must not map an empty extension\n" +
+		"dialects: \"auto\" for \".x\" must be one of terraform, packer, nomad, terragrunt, hcl"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Validate = %v; want %q", err, want)
+	}
+}
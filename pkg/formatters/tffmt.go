@@ -0,0 +1,506 @@
+package formatters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// HCLConfig holds the options of the HCL formatter.
+type HCLConfig struct {
+	Dialect                  string              `json:"dialect"                  description:"terraform applies every rule of terraform fmt, packer skips the Terraform-specific ones such as the rewrite of variable types, nomad also keeps the bodies of template blocks as written, terragrunt can sort inputs, hcl skips every rewrite, auto picks a dialect from dialects or the file name." enum:"auto,terraform,packer,nomad,terragrunt,hcl"`
+	Dialects                 map[string]string   `json:"dialects"                 description:"Dialects by file name or extension, such as {\".hcl\": \"hcl\"}, used when dialect is auto before the built-in file names."`
+	IndentWidth              int                 `json:"indentWidth"              description:"Number of spaces per indent level." minimum:"1"`
+	AlignAssignments         bool                `json:"alignAssignments"         description:"Pad the = of consecutive attributes and object items, and the comments after them, into columns, like terraform fmt."`
+	NormalizeTypes           bool                `json:"normalizeTypes"           description:"Rewrite legacy variable types such as \"list\" and bare list, map and set to their Terraform 0.12 forms, like terraform fmt."`
+	UnwrapInterpolations     bool                `json:"unwrapInterpolations"     description:"Rewrite strings that are a single interpolation, such as \"${var.x}\", to the bare expression, like terraform fmt."`
+	SortAttributes           bool                `json:"sortAttributes"           description:"Order each run of attributes in a body, those not separated by blank lines, comments or blocks, by name."`
+	LeadingAttributes        map[string][]string `json:"leadingAttributes"        description:"Attributes that sortAttributes puts first, in order, keyed by the type of their block, such as source and version in module blocks."`
+	OrderMetaArguments       bool                `json:"orderMetaArguments"       description:"Move count and for_each to the start of resource, data and module blocks, and lifecycle, depends_on and provider to the end, each set apart by a blank line."`
+	SortRequiredProviders    bool                `json:"sortRequiredProviders"    description:"Order the entries of required_providers blocks by name."`
+	SortBlocks               []string            `json:"sortBlocks"               description:"Types of top-level blocks, such as variable and output, whose blocks are ordered by label among the places blocks of that type hold."`
+	SortInputs               bool                `json:"sortInputs"               description:"Order the keys of the inputs object of Terragrunt configurations by name."`
+	BlankLinesBetweenBlocks  *int                `json:"blankLinesBetweenBlocks"  description:"Exact number of blank lines between consecutive top-level blocks; runs of blank lines elsewhere are cut to one. Unset keeps blank lines as written." minimum:"0"`
+	LabelStyle               string              `json:"labelStyle"               description:"quoted writes every block label as a quoted string, like terraform fmt; unquoted writes the labels that are identifiers, such as those of dynamic blocks, bare and quotes the others." enum:"quoted,unquoted"`
+	FormatValidations        bool                `json:"formatValidations"        description:"Split the condition of the validation blocks of variables and the assert blocks of checks after its top-level || or && operators when its line is longer than lineWidth, wrapping it in parentheses, and join an error_message spread over several lines onto one when it fits."`
+	NormalizeCollections     bool                `json:"normalizeCollections"     description:"Join the lists, objects and function calls that span lines onto one line when they fit within lineWidth, and put one element per line in the others, with a trailing comma after each element of lists and function calls and none in objects. Collections with comments or heredocs are kept as written."`
+	LineWidth                int                 `json:"lineWidth"                description:"Maximum line width that formatValidations and normalizeCollections work to." minimum:"1"`
+	HeredocStyle             string              `json:"heredocStyle"             description:"preserve keeps heredocs byte for byte as written; indented turns <<EOF heredocs into <<-EOF ones and indents their bodies one level below the line that opens them, with the closing marker in line with it. The text of the bodies is never reflowed, and heredocs whose value would change are kept." enum:"preserve,indented"`
+	FormatJSONHeredocs       bool                `json:"formatJSONHeredocs"       description:"Have dprint format the heredocs that hold a JSON object or array, such as IAM policies, with the plugin it formats .json files with, and splice the result back. Heredocs with interpolations are kept as written."`
+	PreserveCommentAlignment bool                `json:"preserveCommentAlignment" description:"Line up the comments at the ends of consecutive attributes and object items once every other option has run, so that alignAssignments false, a changed indentWidth or a rewritten value does not leave them ragged."`
+}
+
+// DefaultHCLIndentWidth is the default of HCLConfig.IndentWidth, the
+// indentation of terraform fmt.
+const DefaultHCLIndentWidth = 2
+
+// DefaultHCLConfig returns the configuration used when no options are set.
+func DefaultHCLConfig() HCLConfig {
+	return HCLConfig{
+		Dialect:              HCLDialectAuto,
+		IndentWidth:          DefaultHCLIndentWidth,
+		AlignAssignments:     true,
+		NormalizeTypes:       true,
+		UnwrapInterpolations: true,
+		LeadingAttributes: map[string][]string{
+			"module":  {"source", "version"},
+			"moved":   {"from", "to"},
+			"import":  {"to", "id"},
+			"removed": {"from"},
+		},
+		LabelStyle:   LabelStyleQuoted,
+		LineWidth:    DefaultHCLLineWidth,
+		HeredocStyle: HeredocStylePreserve,
+	}
+}
+
+// Validate reports options that are out of range.
+func (c HCLConfig) Validate() error {
+	var errs []error
+	if !slices.Contains(hclDialects, c.Dialect) {
+		errs = append(errs, &ConfigError{
+			Property: "dialect",
+			Message:  "must be one of " + strings.Join(hclDialects, ", "),
+		})
+	}
+	if len(c.Dialects) > 0 && c.Dialect != HCLDialectAuto {
+		errs = append(errs, &ConfigError{Property: "dialects", Message: "has no effect unless dialect is auto"})
+	}
+	named := hclDialects[1:] // every dialect but auto
+	for _, key := range slices.Sorted(maps.Keys(c.Dialects)) {
+		if strings.TrimSpace(key) == "" {
+			errs = append(errs, &ConfigError{Property: "dialects", Message: "must not map an empty extension"})
+		}
+		if dialect := c.Dialects[key]; !slices.Contains(named, dialect) {
+			errs = append(errs, &ConfigError{
+				Property: "dialects",
+				Message:  fmt.Sprintf("%q for %q must be one of %s", dialect, key, strings.Join(named, ", ")),
+			})
+		}
+	}
+	if c.IndentWidth < 1 {
+		errs = append(errs, &ConfigError{Property: "indentWidth", Message: "must be positive"})
+	}
+	if c.BlankLinesBetweenBlocks != nil && *c.BlankLinesBetweenBlocks < 0 {
+		errs = append(errs, &ConfigError{Property: "blankLinesBetweenBlocks", Message: "must not be negative"})
+	}
+	if !slices.Contains(labelStyles, c.LabelStyle) {
+		errs = append(errs, &ConfigError{
+			Property: "labelStyle",
+			Message:  "must be one of " + strings.Join(labelStyles, ", "),
+		})
+	}
+	if (c.FormatValidations || c.NormalizeCollections) && c.LineWidth < 1 {
+		errs = append(errs, &ConfigError{Property: "lineWidth", Message: "must be positive"})
+	}
+	if !slices.Contains(heredocStyles, c.HeredocStyle) {
+		errs = append(errs, &ConfigError{
+			Property: "heredocStyle",
+			Message:  "must be one of " + strings.Join(heredocStyles, ", "),
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// FormatHCL formats HCL source code using logic adapted from Terraform's
+// "terraform fmt" implementation, via the hclwrite and hclsyntax packages.
+// The path is used as the file name in diagnostics; it may be empty. Files
+// whose path ends in .json, such as main.tf.json, are in the JSON syntax of
+// HCL and are formatted as canonical JSON instead, and .sentinel policies
+// only have their whitespace laid out.
+func FormatHCL(path string, src []byte, cfg HCLConfig) ([]byte, error) {
+	return FormatHCLContext(context.Background(), path, src, cfg)
+}
+
+// FormatHCLContext is like FormatHCL but stops early, returning ctx.Err(),
+// once ctx is cancelled. The context is checked after the file is parsed
+// and before each block is formatted.
+func FormatHCLContext(ctx context.Context, path string, src []byte, cfg HCLConfig) ([]byte, error) {
+	if isHCLJSON(path) {
+		return formatHCLJSON(path, src, cfg)
+	}
+	if isSentinel(path) {
+		return formatSentinel(src), nil
+	}
+	// Parse with hclwrite so we can manipulate tokens and regenerate the
+	// formatted output. hclwrite parses the native syntax with hclsyntax
+	// first and returns its diagnostics, so syntax errors need no parse of
+	// their own. This is not the only pass over the file, though: the
+	// passes after f.Bytes() work on the printed text and lex it again,
+	// and unquoteHCLLabels, keepTemplateBodies and orderMetaArguments
+	// followed by sortHCLAttributes parse it again.
+	f, diags := hclwrite.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, hclSyntaxErrors(diags)
+	}
+	if f == nil {
+		return nil, errors.New("failed to parse HCL config")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	dialect := hclDialect(path, cfg)
+	cfg = dialectConfig(dialect, cfg)
+	formatter := &hclFormatter{ctx: ctx, cfg: cfg}
+	formatter.formatBody(f.Body(), nil)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if dialect == HCLDialectTerraform {
+		expandRefactoringBlocks(f.Body())
+	}
+	if isLockFile(path) {
+		normalizeLockFile(f.Body())
+	}
+	if cfg.SortRequiredProviders {
+		sortRequiredProviders(f.Body())
+	}
+	if cfg.SortInputs {
+		sortTerragruntInputs(f.Body())
+	}
+	if cfg.OrderMetaArguments && orderMetaArguments(f.Body()) && cfg.SortAttributes {
+		// The moved attributes are unstructured tokens now, which
+		// sortHCLAttributes would not see, so parse them back.
+		if f, diags = hclwrite.ParseConfig(f.Bytes(), path, hcl.InitialPos); diags.HasErrors() {
+			return nil, hclSyntaxErrors(diags)
+		}
+	}
+	if cfg.SortAttributes {
+		sortHCLAttributes(f.Body(), "", cfg.LeadingAttributes)
+	}
+	if len(cfg.SortBlocks) > 0 {
+		sortHCLBlocks(f.Body(), cfg.SortBlocks)
+	}
+	if cfg.BlankLinesBetweenBlocks != nil {
+		separateHCLBlocks(f.Body(), max(*cfg.BlankLinesBetweenBlocks, 0))
+	}
+
+	out := f.Bytes()
+	if cfg.LabelStyle == LabelStyleUnquoted {
+		out = unquoteHCLLabels(out, path)
+	}
+	if !cfg.AlignAssignments {
+		out = unalignHCL(out, path)
+	}
+	out = reindentHCL(out, path, cfg.IndentWidth)
+	if cfg.HeredocStyle == HeredocStyleIndented {
+		out = indentHeredocs(out, path, cfg.IndentWidth)
+	}
+	if cfg.FormatJSONHeredocs {
+		out = formatJSONHeredocs(ctx, out, path, cfg.IndentWidth)
+	}
+	if cfg.PreserveCommentAlignment {
+		out = alignHCLComments(out, path)
+	}
+	if dialect == HCLDialectNomad {
+		return keepTemplateBodies(src, out, path)
+	}
+	return out, nil
+}
+
+// hclFormatter adapts Terraform's fmt formatting logic for use in this plugin.
+// Formatting stops descending into blocks once ctx is cancelled.
+type hclFormatter struct {
+	ctx context.Context
+	cfg HCLConfig
+}
+
+const (
+	// minInterpolationTokens is the minimum number of tokens required for a "${ ... }" sequence.
+	minInterpolationTokens = 5
+	// parenPairTokens is the number of tokens needed for adding parentheses (open and close).
+	parenPairTokens = 2
+	// legacyTypeTokens is the number of tokens in a legacy quoted type expression like "string".
+	legacyTypeTokens = 3
+)
+
+func (f *hclFormatter) formatBody(body *hclwrite.Body, inBlocks []string) {
+	attrs := body.Attributes()
+	for name, attr := range attrs {
+		if len(inBlocks) == 1 && inBlocks[0] == "variable" && name == "type" && f.cfg.NormalizeTypes {
+			cleanedExprTokens := f.formatTypeExpr(attr.Expr().BuildTokens(nil))
+			body.SetAttributeRaw(name, cleanedExprTokens)
+			continue
+		}
+		exprTokens := attr.Expr().BuildTokens(nil)
+		if f.cfg.UnwrapInterpolations {
+			cleanedExprTokens := f.formatValueExpr(exprTokens)
+			if len(cleanedExprTokens) != len(exprTokens) {
+				debugf(f.ctx, "unwrapped interpolation in %s", strings.Join(slices.Concat(inBlocks, []string{name}), "."))
+			}
+			exprTokens = cleanedExprTokens
+		}
+		if f.cfg.NormalizeCollections {
+			exprTokens = f.normalizeCollections(exprTokens, len(inBlocks), f.assignmentWidth(attrs, name, len(inBlocks)))
+		}
+		body.SetAttributeRaw(name, exprTokens)
+	}
+	if f.cfg.FormatValidations && isValidationBlock(inBlocks) {
+		f.formatValidation(body, len(inBlocks))
+	}
+
+	blocks := body.Blocks()
+	for _, block := range blocks {
+		if f.ctx.Err() != nil {
+			return
+		}
+		// Normalize the label formatting, removing any weird stuff like
+		// interleaved inline comments and using the idiomatic quoted
+		// label syntax, which unquoteHCLLabels undoes for labelStyle
+		// unquoted.
+		block.SetLabels(block.Labels())
+
+		f.formatBody(block.Body(), append(inBlocks, block.Type()))
+	}
+}
+
+func (f *hclFormatter) formatValueExpr(tokens hclwrite.Tokens) hclwrite.Tokens {
+	if len(tokens) < minInterpolationTokens {
+		// Can't possibly be a "${ ... }" sequence without at least enough
+		// tokens for the delimiters and one token inside them.
+		return tokens
+	}
+
+	if !f.isInterpolationSequence(tokens) {
+		return tokens
+	}
+
+	inside := tokens[2 : len(tokens)-2]
+
+	if !f.isSingleInterpolation(inside) {
+		return tokens
+	}
+
+	// If we got down here without an early return then this looks like
+	// an unwrappable sequence, but we'll trim any leading and trailing
+	// newlines that might result in an invalid result if we were to
+	// naively trim something like this:
+	// "${
+	//    foo
+	// }"
+	trimmed := f.trimNewlines(inside)
+
+	// Finally, we check if the unwrapped expression is on multiple lines. If
+	// so, we ensure that it is surrounded by parenthesis to make sure that it
+	// parses correctly after unwrapping. This may be redundant in some cases,
+	// but is required for at least multi-line ternary expressions.
+	return f.wrapMultiLineIfNeeded(trimmed)
+}
+
+// isInterpolationSequence checks if tokens represent a "${ ... }" interpolation sequence.
+func (f *hclFormatter) isInterpolationSequence(tokens hclwrite.Tokens) bool {
+	oQuote := tokens[0]
+	oBrace := tokens[1]
+	cBrace := tokens[len(tokens)-2]
+	cQuote := tokens[len(tokens)-1]
+	return oQuote.Type == hclsyntax.TokenOQuote &&
+		oBrace.Type == hclsyntax.TokenTemplateInterp &&
+		cBrace.Type == hclsyntax.TokenTemplateSeqEnd &&
+		cQuote.Type == hclsyntax.TokenCQuote
+}
+
+// isSingleInterpolation checks if the interior tokens represent a single interpolation.
+func (f *hclFormatter) isSingleInterpolation(inside hclwrite.Tokens) bool {
+	// We're only interested in sequences that are provable to be single
+	// interpolation sequences, which we'll determine by hunting inside
+	// the interior tokens for any other interpolation sequences. This is
+	// likely to produce false negatives sometimes, but that's better than
+	// false positives and we're mainly interested in catching the easy cases
+	// here.
+	quotes := 0
+	for _, token := range inside {
+		if token.Type == hclsyntax.TokenOQuote {
+			quotes++
+			continue
+		}
+		if token.Type == hclsyntax.TokenCQuote {
+			quotes--
+			continue
+		}
+		if quotes > 0 {
+			// Interpolation sequences inside nested quotes are okay, because
+			// they are part of a nested expression.
+			// "${foo("${bar}")}"
+			continue
+		}
+		if token.Type == hclsyntax.TokenTemplateInterp || token.Type == hclsyntax.TokenTemplateSeqEnd {
+			// We've found another template delimiter within our interior
+			// tokens, which suggests that we've found something like this:
+			// "${foo}${bar}"
+			// That isn't unwrappable, so we'll leave the whole expression alone.
+			return false
+		}
+		if token.Type == hclsyntax.TokenQuotedLit {
+			// If there's any literal characters in the outermost
+			// quoted sequence then it is not unwrappable.
+			return false
+		}
+	}
+	return true
+}
+
+// wrapMultiLineIfNeeded wraps multi-line expressions in parentheses if not already wrapped.
+func (f *hclFormatter) wrapMultiLineIfNeeded(trimmed hclwrite.Tokens) hclwrite.Tokens {
+	isMultiLine := false
+	hasLeadingParen := false
+	hasTrailingParen := false
+	for i, token := range trimmed {
+		switch {
+		case i == 0 && token.Type == hclsyntax.TokenOParen:
+			hasLeadingParen = true
+		case token.Type == hclsyntax.TokenNewline:
+			isMultiLine = true
+		case i == len(trimmed)-1 && token.Type == hclsyntax.TokenCParen:
+			hasTrailingParen = true
+		}
+	}
+	if isMultiLine && (!hasLeadingParen || !hasTrailingParen) {
+		wrapped := make(hclwrite.Tokens, 0, len(trimmed)+parenPairTokens)
+		wrapped = append(wrapped, &hclwrite.Token{
+			Type:  hclsyntax.TokenOParen,
+			Bytes: []byte("("),
+		})
+		wrapped = append(wrapped, trimmed...)
+		wrapped = append(wrapped, &hclwrite.Token{
+			Type:  hclsyntax.TokenCParen,
+			Bytes: []byte(")"),
+		})
+
+		return wrapped
+	}
+
+	return trimmed
+}
+
+func (f *hclFormatter) formatTypeExpr(tokens hclwrite.Tokens) hclwrite.Tokens {
+	switch len(tokens) {
+	case 1:
+		kwTok := tokens[0]
+		if kwTok.Type != hclsyntax.TokenIdent {
+			// Not a single type keyword, then.
+			return tokens
+		}
+
+		// Collection types without an explicit element type mean
+		// the element type is "any", so we'll normalize that.
+		switch string(kwTok.Bytes) {
+		case "list", "map", "set":
+			return hclwrite.Tokens{
+				kwTok,
+				{
+					Type:  hclsyntax.TokenOParen,
+					Bytes: []byte("("),
+				},
+				{
+					Type:  hclsyntax.TokenIdent,
+					Bytes: []byte("any"),
+				},
+				{
+					Type:  hclsyntax.TokenCParen,
+					Bytes: []byte(")"),
+				},
+			}
+		default:
+			return tokens
+		}
+
+	case legacyTypeTokens:
+		// A pre-0.12 legacy quoted string type, like "string".
+		oQuote := tokens[0]
+		strTok := tokens[1]
+		cQuote := tokens[2]
+		if oQuote.Type != hclsyntax.TokenOQuote ||
+			strTok.Type != hclsyntax.TokenQuotedLit ||
+			cQuote.Type != hclsyntax.TokenCQuote {
+			// Not a quoted string sequence, then.
+			return tokens
+		}
+
+		// Because this quoted syntax is from Terraform 0.11 and
+		// earlier, which didn't have the idea of "any" as an,
+		// element type, we use string as the default element
+		// type. That will avoid oddities if somehow the configuration
+		// was relying on numeric values being auto-converted to
+		// string, as 0.11 would do. This mimicks what terraform
+		// 0.12upgrade used to do, because we'd found real-world
+		// modules that were depending on the auto-stringing.)
+		switch string(strTok.Bytes) {
+		case "string":
+			return hclwrite.Tokens{
+				{
+					Type:  hclsyntax.TokenIdent,
+					Bytes: []byte("string"),
+				},
+			}
+		case "list":
+			return hclwrite.Tokens{
+				{
+					Type:  hclsyntax.TokenIdent,
+					Bytes: []byte("list"),
+				},
+				{
+					Type:  hclsyntax.TokenOParen,
+					Bytes: []byte("("),
+				},
+				{
+					Type:  hclsyntax.TokenIdent,
+					Bytes: []byte("string"),
+				},
+				{
+					Type:  hclsyntax.TokenCParen,
+					Bytes: []byte(")"),
+				},
+			}
+		case "map":
+			return hclwrite.Tokens{
+				{
+					Type:  hclsyntax.TokenIdent,
+					Bytes: []byte("map"),
+				},
+				{
+					Type:  hclsyntax.TokenOParen,
+					Bytes: []byte("("),
+				},
+				{
+					Type:  hclsyntax.TokenIdent,
+					Bytes: []byte("string"),
+				},
+				{
+					Type:  hclsyntax.TokenCParen,
+					Bytes: []byte(")"),
+				},
+			}
+		default:
+			// Something else we're not expecting, then.
+			return tokens
+		}
+	default:
+		return tokens
+	}
+}
+
+func (f *hclFormatter) trimNewlines(tokens hclwrite.Tokens) hclwrite.Tokens {
+	if len(tokens) == 0 {
+		return nil
+	}
+	var start, end int
+	for start = range tokens {
+		if tokens[start].Type != hclsyntax.TokenNewline {
+			break
+		}
+	}
+	for end = len(tokens); end > 0; end-- {
+		if tokens[end-1].Type != hclsyntax.TokenNewline {
+			break
+		}
+	}
+	return tokens[start:end]
+}
@@ -0,0 +1,123 @@
+package formatters
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestFormatHCL_Matches_Terraform_Fmt verifies the terraform fmt rules
+// that FormatHCL applies on top of hclwrite.
+func TestFormatHCL_Matches_Terraform_Fmt(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "aligns attributes",
+			src:  "a = 1\nbbb = 2\n",
+			want: "a   = 1\nbbb = 2\n",
+		},
+		{
+			name: "unwraps single interpolation",
+			src:  "a = \"${var.x}\"\n",
+			want: "a = var.x\n",
+		},
+		{
+			name: "keeps mixed templates",
+			src:  "a = \"x-${var.x}\"\n",
+			want: "a = \"x-${var.x}\"\n",
+		},
+		{
+			name: "normalizes legacy variable types",
+			src:  "variable \"v\" {\n  type = \"list\"\n}\n",
+			want: "variable \"v\" {\n  type = list(string)\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatHCL("", []byte(tt.src), DefaultHCLConfig())
+			if err != nil {
+				t.Fatalf("FormatHCL: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatHCL_Reports_Syntax_Errors verifies that invalid HCL is
+// rejected with an error.
+func TestFormatHCL_Reports_Syntax_Errors(t *testing.T) {
+	_, err := FormatHCL("main.tf", []byte("a = {\n"), DefaultHCLConfig())
+	if err == nil {
+		t.Fatalf("FormatHCL accepted invalid HCL")
+	}
+	if !strings.HasPrefix(err.Error(), "main.tf:") {
+		t.Fatalf("error %q does not name the file", err)
+	}
+}
+
+// TestFormatHCL_Keeps_Legacy_Types verifies that normalizeTypes false leaves
+// quoted and bare collection variable types as written.
+func TestFormatHCL_Keeps_Legacy_Types(t *testing.T) {
+	src := "variable \"a\" {\n  type = \"list\"\n}\n\nvariable \"b\" {\n  type = map\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.NormalizeTypes = false
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	if string(got) != src {
+		t.Fatalf("FormatHCL = %q; want %q", got, src)
+	}
+}
+
+// TestFormatHCL_Keeps_Interpolations verifies that unwrapInterpolations
+// false leaves single interpolations as written while still aligning.
+func TestFormatHCL_Keeps_Interpolations(t *testing.T) {
+	src := "a = \"${var.x}\"\nbbb = \"${var.y}\"\n"
+	cfg := DefaultHCLConfig()
+	cfg.UnwrapInterpolations = false
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "a   = \"${var.x}\"\nbbb = \"${var.y}\"\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL = %q; want %q", got, want)
+	}
+}
+
+// TestFormatHCLContext_Stops_When_Cancelled verifies that a cancelled
+// context aborts formatting with the context's error.
+func TestFormatHCLContext_Stops_When_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := FormatHCLContext(ctx, "main.tf", []byte("a = 1\n"), DefaultHCLConfig())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("FormatHCLContext error = %v; want %v", err, context.Canceled)
+	}
+}
+
+// TestHCLConfig_Validate_Rejects_Bad_Values verifies that out-of-range
+// options are reported.
+func TestHCLConfig_Validate_Rejects_Bad_Values(t *testing.T) {
+	cfg := DefaultHCLConfig()
+	cfg.Dialect = "hcl1"
+	cfg.IndentWidth = 0
+	blank := -1
+	cfg.BlankLinesBetweenBlocks = &blank
+	err := cfg.Validate()
+	want := "dialect: must be one of auto, terraform, packer, nomad, terragrunt, hcl\n" +
+		"indentWidth: must be positive\nblankLinesBetweenBlocks: must not be negative"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Validate = %v; want %q", err, want)
+	}
+}
@@ -0,0 +1,137 @@
+package formatters
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Values of HCLConfig.HeredocStyle.
+const (
+	// HeredocStylePreserve keeps heredocs byte for byte as written.
+	HeredocStylePreserve = "preserve"
+	// HeredocStyleIndented turns <<EOF heredocs into <<-EOF ones and
+	// indents their bodies and closing markers with the code around them.
+	HeredocStyleIndented = "indented"
+)
+
+// heredocStyles lists the accepted values of HCLConfig.HeredocStyle.
+var heredocStyles = []string{HeredocStylePreserve, HeredocStyleIndented} //nolint:gochecknoglobals // read-only lookup
+
+// indentHeredocs turns the <<EOF heredocs of src into <<-EOF ones and lays
+// out every <<- heredoc with its body one level of width spaces below the
+// line that opens it and its closing marker in line with that line. Only
+// the leading whitespace of the lines changes, by the same amount on each,
+// so the value of the string, which <<- strips of the indentation its lines
+// share, stays the same. Heredocs that would change value are left alone:
+// <<EOF heredocs whose lines are all indented and heredocs with an
+// interpolation spanning lines.
+func indentHeredocs(src []byte, path string, width int) []byte {
+	if width < 1 {
+		width = DefaultHCLIndentWidth
+	}
+	tokens, diags := hclsyntax.LexConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+	var edits []goEdit
+	for i, tok := range tokens {
+		if tok.Type == hclsyntax.TokenOHeredoc {
+			edits = append(edits, heredocEdits(src, tokens[i:], width)...)
+		}
+	}
+	if len(edits) == 0 {
+		return src
+	}
+	return applyGoEdits(src, edits)
+}
+
+// heredocEdits returns the edits that indent the heredoc opened by the
+// first of tokens, or none when that would change its value.
+func heredocEdits(src []byte, tokens hclsyntax.Tokens, width int) []goEdit {
+	open := tokens[0]
+	bodyStart := open.Range.End.Byte
+	// starts holds the offsets at which the lexer starts a line of the
+	// body; an interpolation spanning lines hides the lines inside it.
+	starts := map[int]bool{bodyStart: true}
+	var closer hclsyntax.Token
+	for _, tok := range tokens[1:] {
+		if tok.Type == hclsyntax.TokenOHeredoc {
+			return nil
+		}
+		if tok.Type == hclsyntax.TokenCHeredoc {
+			closer = tok
+			break
+		}
+		if tok.Type == hclsyntax.TokenStringLit && bytes.HasSuffix(tok.Bytes, []byte("\n")) {
+			starts[tok.Range.End.Byte] = true
+		}
+	}
+	if closer.Type != hclsyntax.TokenCHeredoc {
+		return nil
+	}
+
+	type bodyLine struct {
+		start  int
+		spaces int
+	}
+	var lines []bodyLine
+	shared := -1
+	offset := bodyStart
+	for _, line := range bytes.SplitAfter(src[bodyStart:closer.Range.Start.Byte], []byte("\n")) {
+		if len(line) == 0 {
+			break
+		}
+		if !starts[offset] {
+			return nil
+		}
+		// Lines of whitespace only are left out of the shared indentation
+		// and kept as they are.
+		if text := bytes.TrimLeftFunc(line, unicode.IsSpace); len(text) > 0 {
+			spaces := utf8.RuneCount(line[:len(line)-len(text)])
+			lines = append(lines, bodyLine{start: offset, spaces: spaces})
+			if shared < 0 || spaces < shared {
+				shared = spaces
+			}
+		}
+		offset += len(line)
+	}
+
+	flush := bytes.HasPrefix(open.Bytes, []byte("<<-"))
+	if !flush && shared > 0 {
+		return nil
+	}
+	lineStart := bytes.LastIndexByte(src[:open.Range.Start.Byte], '\n') + 1
+	indent := len(src[lineStart:]) - len(bytes.TrimLeft(src[lineStart:], " "))
+	delta := indent + width - max(shared, 0)
+
+	var edits []goEdit
+	if !flush {
+		at := open.Range.Start.Byte + len("<<")
+		edits = append(edits, goEdit{span{at, at}, "-"})
+	}
+	for _, line := range lines {
+		if delta > 0 {
+			edits = append(edits, goEdit{span{line.start, line.start}, strings.Repeat(" ", delta)})
+			continue
+		}
+		// Drop the first -delta whitespace runes, which every line has.
+		end := line.start
+		for range -delta {
+			_, size := utf8.DecodeRune(src[end:])
+			end += size
+		}
+		if end > line.start {
+			edits = append(edits, goEdit{span{line.start, end}, ""})
+		}
+	}
+	marker := strings.Repeat(" ", indent) + strings.TrimSpace(string(closer.Bytes))
+	if marker != string(closer.Bytes) {
+		edits = append(edits, goEdit{span{closer.Range.Start.Byte, closer.Range.End.Byte}, marker})
+	}
+	return edits
+}
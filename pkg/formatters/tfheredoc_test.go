@@ -0,0 +1,103 @@
+package formatters
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// TestFormatHCL_Lays_Out_Heredocs verifies that heredocs are kept byte for
+// byte by default, even when their lines start with an interpolation, and
+// that the indented style indents them without changing their value.
+func TestFormatHCL_Lays_Out_Heredocs(t *testing.T) {
+	tests := []struct {
+		name  string
+		style string
+		width int
+		src   string
+		want  string
+	}{
+		{
+			name:  "preserved with another indent width",
+			style: HeredocStylePreserve,
+			width: 4,
+			src:   "a {\n  x = <<EOF\n  ${b}  =  1\nc\nEOF\n  y = <<-EOT\n      ${d}\n      e\n    EOT\n}\n",
+			want:  "a {\n    x = <<EOF\n  ${b}  =  1\nc\nEOF\n    y = <<-EOT\n      ${d}\n      e\n    EOT\n}\n",
+		},
+		{
+			name:  "indented",
+			style: HeredocStyleIndented,
+			width: 2,
+			src:   "a {\n  x = <<EOF\n{\n  \"b\": ${c}\n\n}\nEOF\n}\n",
+			want:  "a {\n  x = <<-EOF\n    {\n      \"b\": ${c}\n\n    }\n  EOF\n}\n",
+		},
+		{
+			name:  "flush heredoc reindented",
+			style: HeredocStyleIndented,
+			width: 2,
+			src:   "x = <<-EOF\n        a\n          b\n      EOF\n",
+			want:  "x = <<-EOF\n  a\n    b\nEOF\n",
+		},
+		{
+			name:  "indented lines kept",
+			style: HeredocStyleIndented,
+			width: 2,
+			src:   "x = <<EOF\n  a\n    b\nEOF\n",
+			want:  "x = <<EOF\n  a\n    b\nEOF\n",
+		},
+		{
+			name:  "multi-line interpolation kept",
+			style: HeredocStyleIndented,
+			width: 2,
+			src:   "x = <<EOF\na ${\nb\n}\nEOF\n",
+			want:  "x = <<EOF\na ${\nb\n}\nEOF\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultHCLConfig()
+			cfg.HeredocStyle = tt.style
+			cfg.IndentWidth = tt.width
+			got, err := FormatHCL("main.tf", []byte(tt.src), cfg)
+			if err != nil {
+				t.Fatalf("FormatHCL: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatHCL = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIndentHeredocs_Keeps_Values verifies that indenting heredocs leaves
+// the strings they hold unchanged.
+func TestIndentHeredocs_Keeps_Values(t *testing.T) {
+	src := "a {\n  x = <<EOF\n{\n  \"b\": 1\n   \n}\nEOF\n  y = <<-EOF\n\t\tc\n\t\t  d\n\t\tEOF\n}\n"
+	got := indentHeredocs([]byte(src), "main.tf", 4)
+	if string(got) == src {
+		t.Fatalf("indentHeredocs left %q unchanged", src)
+	}
+	// values returns the values of the attributes of the block of src.
+	values := func(src []byte) map[string]string {
+		file, diags := hclsyntax.ParseConfig(src, "main.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			t.Fatalf("ParseConfig(%q): %v", src, diags)
+		}
+		out := map[string]string{}
+		for name, attr := range file.Body.(*hclsyntax.Body).Blocks[0].Body.Attributes {
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				t.Fatalf("Value of %s: %v", name, diags)
+			}
+			out[name] = value.AsString()
+		}
+		return out
+	}
+	before, after := values([]byte(src)), values(got)
+	for name, want := range before {
+		if after[name] != want {
+			t.Fatalf("%s = %q after indentHeredocs; want %q", name, after[name], want)
+		}
+	}
+}
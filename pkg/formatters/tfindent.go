@@ -0,0 +1,60 @@
+package formatters
+
+import (
+	"bytes"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// hclwriteIndent is the number of spaces hclwrite indents each level by.
+const hclwriteIndent = 2
+
+// reindentHCL changes the two-space indentation of src, as printed by
+// hclwrite, to width spaces per level. Lines that do not start a token,
+// such as the lines of block comments, and heredoc bodies with their
+// closing markers are kept as written, since hclwrite keeps them too. A
+// width below one keeps the indentation of hclwrite.
+func reindentHCL(src []byte, path string, width int) []byte {
+	if width < 1 || width == hclwriteIndent {
+		return src
+	}
+	tokens, diags := hclsyntax.LexConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+	starts := make(map[int]bool, len(tokens))
+	heredocs := 0 // the depth of the heredoc bodies tok is in
+	for _, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenStringLit:
+		case hclsyntax.TokenCHeredoc:
+			heredocs--
+		default:
+			// An interpolation at the start of a line of a heredoc body is
+			// part of the body.
+			if heredocs == 0 {
+				starts[tok.Range.Start.Byte] = true
+			}
+			if tok.Type == hclsyntax.TokenOHeredoc {
+				heredocs++
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(src))
+	offset := 0
+	for _, line := range bytes.SplitAfter(src, []byte("\n")) {
+		text := bytes.TrimLeft(line, " ")
+		spaces := len(line) - len(text)
+		if spaces > 0 && starts[offset+spaces] {
+			out.Write(bytes.Repeat([]byte(" "), spaces/hclwriteIndent*width+spaces%hclwriteIndent))
+			out.Write(text)
+		} else {
+			out.Write(line)
+		}
+		offset += len(line)
+	}
+	return out.Bytes()
+}
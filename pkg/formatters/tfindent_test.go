@@ -0,0 +1,23 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Uses_Indent_Width verifies that indentWidth re-indents
+// nested blocks and expressions while heredoc bodies and the lines of block
+// comments keep their indentation.
+func TestFormatHCL_Uses_Indent_Width(t *testing.T) {
+	src := "resource \"a\" \"b\" {\n  x = <<-EOT\n    hi\n    EOT\n  /* a\n     b */\n" +
+		"  tags = {\n    Name = \"n\"\n  }\n  dynamic \"d\" {\n    for_each = [\n      1,\n    ]\n  }\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.IndentWidth = 4
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "resource \"a\" \"b\" {\n    x = <<-EOT\n    hi\n    EOT\n    /* a\n     b */\n" +
+		"    tags = {\n        Name = \"n\"\n    }\n    dynamic \"d\" {\n        for_each = [\n            1,\n        ]\n    }\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
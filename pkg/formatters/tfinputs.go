@@ -0,0 +1,110 @@
+package formatters
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// objectItem is an item of a multi-line object constructor: its line,
+// with the comment lines directly above it, and its key.
+type objectItem struct {
+	key    string
+	tokens hclwrite.Tokens
+}
+
+// sortTerragruntInputs orders the keys of the object assigned to the
+// top-level inputs attribute of a Terragrunt configuration.
+func sortTerragruntInputs(body *hclwrite.Body) {
+	attr := body.GetAttribute("inputs")
+	if attr == nil {
+		return
+	}
+	if sorted, ok := sortObjectItems(attr.Expr().BuildTokens(nil)); ok {
+		body.SetAttributeRaw("inputs", sorted)
+	}
+}
+
+// sortObjectItems orders the items of tokens, an object constructor with
+// each item on lines of its own, by key among the places they hold, so
+// that blank lines stay where they are. Comment lines directly above an
+// item move with it. It reports false when tokens are not such an object
+// or are sorted already.
+func sortObjectItems(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	n := len(tokens)
+	if n < 2 || tokens[0].Type != hclsyntax.TokenOBrace || tokens[n-1].Type != hclsyntax.TokenCBrace {
+		return nil, false
+	}
+	if tokens[1].Type != hclsyntax.TokenNewline {
+		return nil, false
+	}
+	var lines []hclwrite.Tokens
+	depth, start := 0, 2
+	for i := 2; i < n-1; i++ {
+		switch tokens[i].Type { //nolint:exhaustive // only nesting and line ends matter
+		case hclsyntax.TokenOBrace, hclsyntax.TokenOBrack, hclsyntax.TokenOParen,
+			hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+			depth++
+		case hclsyntax.TokenCBrace, hclsyntax.TokenCBrack, hclsyntax.TokenCParen, hclsyntax.TokenTemplateSeqEnd:
+			depth--
+		}
+		if depth == 0 && endsLine(tokens[i:i+1]) {
+			lines = append(lines, tokens[start:i+1])
+			start = i + 1
+		}
+	}
+	if start != n-1 {
+		return nil, false
+	}
+
+	var items []objectItem
+	var lead hclwrite.Tokens
+	for _, line := range lines {
+		switch {
+		case line[0].Type == hclsyntax.TokenComment:
+			lead = append(lead, line...)
+		case line[0].Type == hclsyntax.TokenNewline:
+			items = append(items, objectItem{tokens: append(lead, line...)})
+			lead = nil
+		default:
+			items = append(items, objectItem{key: objectKey(line), tokens: append(lead, line...)})
+			lead = nil
+		}
+	}
+	if len(lead) > 0 {
+		items = append(items, objectItem{tokens: lead})
+	}
+
+	var places []int
+	var keyed []objectItem
+	for i, item := range items {
+		if item.key != "" {
+			places, keyed = append(places, i), append(keyed, item)
+		}
+	}
+	order := func(a, b objectItem) int { return cmp.Compare(a.key, b.key) }
+	if slices.IsSortedFunc(keyed, order) {
+		return nil, false
+	}
+	slices.SortStableFunc(keyed, order)
+	for k, i := range places {
+		items[i] = keyed[k]
+	}
+	sorted := hclwrite.Tokens{tokens[0], tokens[1]}
+	for _, item := range items {
+		sorted = append(sorted, item.tokens...)
+	}
+	return append(sorted, tokens[n-1]), true
+}
+
+// objectKey returns the key of an object item line: a bare name, or the
+// text of a quoted one.
+func objectKey(line hclwrite.Tokens) string {
+	if line[0].Type == hclsyntax.TokenOQuote && len(line) > 1 {
+		return string(line[1].Bytes)
+	}
+	return string(bytes.TrimSpace(line[0].Bytes))
+}
@@ -0,0 +1,34 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Sorts_Terragrunt_Inputs verifies that sortInputs orders
+// the keys of the inputs of terragrunt.hcl files, moving comments and
+// nested objects with their keys and keeping blank lines in place.
+func TestFormatHCL_Sorts_Terragrunt_Inputs(t *testing.T) {
+	src := "include \"root\" {\n  path = find_in_parent_folders()\n}\n\ndependency \"vpc\" {\n" +
+		"  config_path = \"../vpc\"\n}\n\ninputs = {\n  zone = \"a\"\n  # the vpc\n" +
+		"  vpc_id = dependency.vpc.outputs.id\n  tags = {\n    b = 1\n    a = 2\n  }\n\n" +
+		"  \"name\" = \"n\"\n  count = 1\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.SortInputs = true
+
+	got, err := FormatHCL("live/terragrunt.hcl", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "include \"root\" {\n  path = find_in_parent_folders()\n}\n\ndependency \"vpc\" {\n" +
+		"  config_path = \"../vpc\"\n}\n\ninputs = {\n  count  = 1\n  \"name\" = \"n\"\n  tags = {\n" +
+		"    b = 1\n    a = 2\n  }\n\n  # the vpc\n  vpc_id = dependency.vpc.outputs.id\n  zone   = \"a\"\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+
+	got, err = FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	if string(got) == want {
+		t.Fatalf("FormatHCL sorted the inputs of a Terraform file")
+	}
+}
@@ -0,0 +1,36 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	hcljson "github.com/hashicorp/hcl/v2/json"
+)
+
+// isHCLJSON reports whether path names a file in the JSON syntax of HCL,
+// such as main.tf.json or terraform.tfvars.json.
+func isHCLJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
+
+// formatHCLJSON formats a file in the JSON syntax of HCL as canonical
+// JSON: every object member and array element on a line of its own,
+// indented by cfg.IndentWidth spaces, with the keys in the order written.
+// The other options only apply to the native syntax.
+func formatHCLJSON(path string, src []byte, cfg HCLConfig) ([]byte, error) {
+	if _, diags := hcljson.Parse(src, path); diags.HasErrors() {
+		return nil, hclSyntaxErrors(diags)
+	}
+	width := cfg.IndentWidth
+	if width < 1 {
+		width = DefaultHCLIndentWidth
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, bytes.TrimSpace(src), "", strings.Repeat(" ", width)); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
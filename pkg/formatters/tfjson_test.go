@@ -0,0 +1,34 @@
+package formatters
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestFormatHCL_Formats_JSON_Syntax verifies that .tf.json files are
+// formatted as JSON indented by indentWidth with their keys in the order
+// written.
+func TestFormatHCL_Formats_JSON_Syntax(t *testing.T) {
+	src := `{"variable": {"b": {}, "a": {"default": [1, 2]}}, "//": "kept"}`
+	cfg := DefaultHCLConfig()
+	cfg.IndentWidth = 4
+
+	got, err := FormatHCL("main.tf.json", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "{\n    \"variable\": {\n        \"b\": {},\n        \"a\": {\n            \"default\": [\n" +
+		"                1,\n                2\n            ]\n        }\n    },\n    \"//\": \"kept\"\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+// TestFormatHCL_Reports_JSON_Syntax_Errors verifies that invalid JSON is
+// reported at its position.
+func TestFormatHCL_Reports_JSON_Syntax_Errors(t *testing.T) {
+	_, err := FormatHCL("main.tf.json", []byte("{\n  \"a\": ,\n}\n"), DefaultHCLConfig())
+	if err == nil || !strings.HasPrefix(err.Error(), "main.tf.json:2:") {
+		t.Fatalf("FormatHCL error = %v; want one at main.tf.json:2", err)
+	}
+}
@@ -0,0 +1,87 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// jsonHeredocSuffix is appended to the path of an HCL file to name the
+// JSON of its heredocs for the host, which picks the plugin that formats
+// them by it.
+const jsonHeredocSuffix = ".heredoc.json"
+
+// formatJSONHeredocs has the host formatter of ctx format the heredocs of
+// src that hold a JSON object or array, such as IAM policies and container
+// definitions, and splices the results back. The bodies of <<- heredocs
+// are indented one level of width spaces below the line that opens them,
+// which <<- strips again; those of <<EOF heredocs start in the first
+// column. Heredocs with interpolations or directives, and those the host
+// fails to format, are kept as written.
+func formatJSONHeredocs(ctx context.Context, src []byte, path string, width int) []byte {
+	format, ok := hostFormatter(ctx)
+	if !ok {
+		debugf(ctx, "no host formatter for JSON heredocs")
+		return src
+	}
+	if width < 1 {
+		width = DefaultHCLIndentWidth
+	}
+	tokens, diags := hclsyntax.LexConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+	var edits []goEdit
+	for i, open := range tokens {
+		if open.Type != hclsyntax.TokenOHeredoc {
+			continue
+		}
+		end := i + 1
+		for end < len(tokens) && tokens[end].Type == hclsyntax.TokenStringLit {
+			end++
+		}
+		if end == len(tokens) || tokens[end].Type != hclsyntax.TokenCHeredoc {
+			continue
+		}
+		body := src[open.Range.End.Byte:tokens[end].Range.Start.Byte]
+		if trimmed := bytes.TrimSpace(body); len(trimmed) == 0 || trimmed[0] != '{' && trimmed[0] != '[' ||
+			!json.Valid(trimmed) {
+			continue
+		}
+		formatted, err := format(path+jsonHeredocSuffix, body)
+		if err != nil {
+			debugf(ctx, "kept the JSON heredoc on line %d: %v", open.Range.Start.Line, err)
+			continue
+		}
+		indent := ""
+		if bytes.HasPrefix(open.Bytes, []byte("<<-")) {
+			lineStart := bytes.LastIndexByte(src[:open.Range.Start.Byte], '\n') + 1
+			spaces := len(src[lineStart:]) - len(bytes.TrimLeft(src[lineStart:], " "))
+			indent = strings.Repeat(" ", spaces+width)
+		}
+		if text := indentLines(formatted, indent); text != string(body) {
+			edits = append(edits, goEdit{span{open.Range.End.Byte, tokens[end].Range.Start.Byte}, text})
+		}
+	}
+	if len(edits) == 0 {
+		return src
+	}
+	return applyGoEdits(src, edits)
+}
+
+// indentLines prefixes the lines of text that are not blank with indent
+// and ends it with a newline.
+func indentLines(text []byte, indent string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(strings.TrimRight(string(text), "\n")+"\n", "\n") {
+		if strings.TrimSpace(line) != "" {
+			b.WriteString(indent)
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package formatters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// TestFormatHCL_Delegates_JSON_Heredocs verifies that heredocs holding JSON
+// are formatted by the host formatter and spliced back, indented inside
+// <<- heredocs, and that other heredocs and failures keep the text as
+// written.
+func TestFormatHCL_Delegates_JSON_Heredocs(t *testing.T) {
+	src := "resource \"aws_iam_policy\" \"p\" {\n  policy = <<-EOT\n    {\"Version\": \"2012-10-17\", \"Statement\": []}\n  EOT\n" +
+		"  defs = <<EOF\n[{\"name\": \"web\"}]\nEOF\n  tpl = <<EOF\n{\"a\": \"${var.a}\"}\nEOF\n  bad = <<EOF\n{\"fail\": 1}\nEOF\n}\n"
+	var paths []string
+	host := func(path string, src []byte) ([]byte, error) {
+		paths = append(paths, path)
+		if bytes.Contains(src, []byte("fail")) {
+			return nil, errors.New("no JSON plugin")
+		}
+		var out bytes.Buffer
+		err := json.Indent(&out, bytes.TrimSpace(src), "", "  ")
+		return out.Bytes(), err
+	}
+	cfg := DefaultHCLConfig()
+	cfg.FormatJSONHeredocs = true
+
+	got, err := FormatHCLContext(WithHostFormat(context.Background(), host), "iam.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCLContext: %v", err)
+	}
+	want := "resource \"aws_iam_policy\" \"p\" {\n  policy = <<-EOT\n    {\n      \"Version\": \"2012-10-17\",\n" +
+		"      \"Statement\": []\n    }\n  EOT\n" +
+		"  defs   = <<EOF\n[\n  {\n    \"name\": \"web\"\n  }\n]\nEOF\n  tpl    = <<EOF\n{\"a\": \"${var.a}\"}\nEOF\n" +
+		"  bad    = <<EOF\n{\"fail\": 1}\nEOF\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCLContext = %q; want %q", got, want)
+	}
+	if len(paths) != 3 || paths[0] != "iam.tf"+jsonHeredocSuffix {
+		t.Fatalf("host formatter paths = %q; want three of %q", paths, "iam.tf"+jsonHeredocSuffix)
+	}
+}
@@ -0,0 +1,53 @@
+package formatters
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Values of HCLConfig.LabelStyle.
+const (
+	// LabelStyleQuoted writes every block label as a quoted string, such
+	// as resource "aws_instance" "web", like terraform fmt.
+	LabelStyleQuoted = "quoted"
+	// LabelStyleUnquoted writes the block labels that are identifiers
+	// bare, such as dynamic setting, and quotes the others.
+	LabelStyleUnquoted = "unquoted"
+)
+
+// labelStyles lists the accepted values of HCLConfig.LabelStyle.
+var labelStyles = []string{LabelStyleQuoted, LabelStyleUnquoted} //nolint:gochecknoglobals // read-only lookup
+
+// unquoteHCLLabels writes the quoted block labels of src, at any depth,
+// that are valid identifiers without their quotes. hclwrite has already
+// quoted every label, so this is what tells the two styles apart.
+func unquoteHCLLabels(src []byte, path string) []byte {
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return src
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return src
+	}
+	var edits []goEdit
+	var walk func(body *hclsyntax.Body)
+	walk = func(body *hclsyntax.Body) {
+		for _, block := range body.Blocks {
+			for i, label := range block.Labels {
+				rng := block.LabelRanges[i]
+				if hclsyntax.ValidIdentifier(label) && string(src[rng.Start.Byte:rng.End.Byte]) == strconv.Quote(label) {
+					edits = append(edits, goEdit{span{rng.Start.Byte, rng.End.Byte}, label})
+				}
+			}
+			walk(block.Body)
+		}
+	}
+	walk(body)
+	if len(edits) == 0 {
+		return src
+	}
+	return applyGoEdits(src, edits)
+}
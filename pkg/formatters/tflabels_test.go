@@ -0,0 +1,38 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Applies_Label_Style verifies that block labels are quoted
+// by default and that the unquoted style writes the labels that are
+// identifiers bare at every depth, quoting the others.
+func TestFormatHCL_Applies_Label_Style(t *testing.T) {
+	src := "resource aws_security_group \"web\" {\n  dynamic ingress {\n    content {}\n  }\n}\n" +
+		"module \"a.b\" {}\n"
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{
+			style: LabelStyleQuoted,
+			want: "resource \"aws_security_group\" \"web\" {\n  dynamic \"ingress\" {\n    content {}\n  }\n}\n" +
+				"module \"a.b\" {}\n",
+		},
+		{
+			style: LabelStyleUnquoted,
+			want:  "resource aws_security_group web {\n  dynamic ingress {\n    content {}\n  }\n}\nmodule \"a.b\" {}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			cfg := DefaultHCLConfig()
+			cfg.LabelStyle = tt.style
+			got, err := FormatHCL("main.tf", []byte(src), cfg)
+			if err != nil {
+				t.Fatalf("FormatHCL: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("FormatHCL = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package formatters
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// lockFileName is the name of the dependency lock file of Terraform.
+const lockFileName = ".terraform.lock.hcl"
+
+// isLockFile reports whether path names a dependency lock file.
+func isLockFile(path string) bool {
+	return filepath.Base(path) == lockFileName
+}
+
+// normalizeLockFile lays out a dependency lock file the way the newest
+// Terraform writes it, so that files written by different versions do not
+// differ: provider blocks ordered by address, and the hashes of each one
+// sorted, without duplicates, one per line.
+func normalizeLockFile(body *hclwrite.Body) {
+	for _, block := range body.Blocks() {
+		if block.Type() != "provider" {
+			continue
+		}
+		attr := block.Body().GetAttribute("hashes")
+		if attr == nil {
+			continue
+		}
+		if hashes, ok := stringList(attr.Expr().BuildTokens(nil)); ok {
+			slices.Sort(hashes)
+			block.Body().SetAttributeRaw("hashes", hashListTokens(slices.Compact(hashes)))
+		}
+	}
+	sortHCLBlocks(body, []string{"provider"})
+}
+
+// stringList returns the strings of tokens, a list of plain quoted strings,
+// and reports false for any other expression, such as a list with comments
+// or interpolations, which is kept as written.
+func stringList(tokens hclwrite.Tokens) ([]string, bool) {
+	n := len(tokens)
+	if n < 2 || tokens[0].Type != hclsyntax.TokenOBrack || tokens[n-1].Type != hclsyntax.TokenCBrack {
+		return nil, false
+	}
+	var list []string
+	for i := 1; i < n-1; i++ {
+		switch tokens[i].Type { //nolint:exhaustive // any other token ends the list
+		case hclsyntax.TokenNewline, hclsyntax.TokenComma:
+		case hclsyntax.TokenOQuote:
+			if i+2 >= n-1 || tokens[i+1].Type != hclsyntax.TokenQuotedLit || tokens[i+2].Type != hclsyntax.TokenCQuote {
+				return nil, false
+			}
+			list = append(list, string(tokens[i+1].Bytes))
+			i += 2
+		default:
+			return nil, false
+		}
+	}
+	return list, true
+}
+
+// hashListTokens returns the tokens of a list of hashes with each hash on
+// a line of its own, followed by a comma.
+func hashListTokens(hashes []string) hclwrite.Tokens {
+	tokens := hclwrite.Tokens{
+		{Type: hclsyntax.TokenOBrack, Bytes: []byte("[")},
+		{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+	}
+	for _, hash := range hashes {
+		tokens = append(tokens,
+			&hclwrite.Token{Type: hclsyntax.TokenOQuote, Bytes: []byte(`"`)},
+			&hclwrite.Token{Type: hclsyntax.TokenQuotedLit, Bytes: []byte(hash)},
+			&hclwrite.Token{Type: hclsyntax.TokenCQuote, Bytes: []byte(`"`)},
+			&hclwrite.Token{Type: hclsyntax.TokenComma, Bytes: []byte(",")},
+			&hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")},
+		)
+	}
+	return append(tokens, &hclwrite.Token{Type: hclsyntax.TokenCBrack, Bytes: []byte("]")})
+}
@@ -0,0 +1,26 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Normalizes_Lock_Files verifies that dependency lock files
+// get their provider blocks ordered by address and their hashes sorted,
+// deduplicated and one per line, while other lists are kept as written.
+func TestFormatHCL_Normalizes_Lock_Files(t *testing.T) {
+	src := "# This file is maintained automatically by \"terraform init\".\n\n" +
+		"provider \"registry.terraform.io/hashicorp/random\" {\n  version = \"3.5.1\"\n" +
+		"  hashes = [\"zh:b\", \"h1:a\", \"zh:b\"]\n}\n\n" +
+		"provider \"registry.terraform.io/hashicorp/aws\" {\n  version     = \"5.0.0\"\n" +
+		"  constraints = \"~> 5.0\"\n  hashes = [\n    \"zh:2\", # pinned\n    \"h1:1\",\n  ]\n}\n"
+	got, err := FormatHCL(".terraform.lock.hcl", []byte(src), DefaultHCLConfig())
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "# This file is maintained automatically by \"terraform init\".\n\n" +
+		"provider \"registry.terraform.io/hashicorp/aws\" {\n  version     = \"5.0.0\"\n" +
+		"  constraints = \"~> 5.0\"\n  hashes = [\n    \"zh:2\", # pinned\n    \"h1:1\",\n  ]\n}\n\n" +
+		"provider \"registry.terraform.io/hashicorp/random\" {\n  version = \"3.5.1\"\n" +
+		"  hashes = [\n    \"h1:a\",\n    \"zh:b\",\n  ]\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
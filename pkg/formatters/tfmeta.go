@@ -0,0 +1,120 @@
+package formatters
+
+import (
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// metaArgumentBlocks lists the types of the blocks whose meta-arguments
+// orderMetaArguments moves.
+var metaArgumentBlocks = []string{"resource", "data", "module", "import", "removed"} //nolint:gochecknoglobals // read-only lookup
+
+// leadingMetaArguments and trailingMetaArguments list the meta-arguments
+// that go before and after the other arguments of a block, in order.
+var (
+	leadingMetaArguments  = []string{"count", "for_each"}                                //nolint:gochecknoglobals // read-only lookup
+	trailingMetaArguments = []string{"lifecycle", "depends_on", "provider", "providers"} //nolint:gochecknoglobals // read-only lookup
+)
+
+// orderMetaArguments moves the count and for_each of the resource, data,
+// module, import and removed blocks of body to the start of the block, and
+// their lifecycle block, depends_on and provider to the end, each group
+// set apart from the other arguments by a blank line. The other arguments,
+// with the blank lines and comments between them, stay in the order
+// written. It reports whether it moved anything.
+func orderMetaArguments(body *hclwrite.Body) bool {
+	changed := false
+	for _, block := range body.Blocks() {
+		if !slices.Contains(metaArgumentBlocks, block.Type()) {
+			continue
+		}
+		items := bodyItems(block.Body())
+		if ordered := metaArgumentOrder(items); !sameItems(items, ordered) {
+			setBodyItems(block.Body(), ordered)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// metaArgumentOrder returns items with the meta-arguments among them moved
+// to the start and end, or items itself when there are none.
+func metaArgumentOrder(items []hclItem) []hclItem {
+	rank := func(list []string, item hclItem) int {
+		if item.attr == nil && item.block == nil || item.block != nil && item.name != "lifecycle" {
+			return -1
+		}
+		return slices.Index(list, item.name)
+	}
+	// The newline after the opening brace belongs to the body and stays
+	// first.
+	var opening []hclItem
+	body := items
+	if len(body) > 0 && body[0].attr == nil && body[0].block == nil &&
+		len(body[0].tokens) > 0 && body[0].tokens[0].Type == hclsyntax.TokenNewline {
+		opening = []hclItem{{tokens: body[0].tokens[:1]}}
+		body = slices.Clone(body)
+		if body[0].tokens = body[0].tokens[1:]; len(body[0].tokens) == 0 {
+			body = body[1:]
+		}
+	}
+	var leading, rest, trailing []hclItem
+	for _, item := range body {
+		switch {
+		case rank(leadingMetaArguments, item) >= 0:
+			leading = append(leading, lineItem(item))
+		case rank(trailingMetaArguments, item) >= 0:
+			trailing = append(trailing, lineItem(item))
+		default:
+			rest = append(rest, item)
+		}
+	}
+	if len(leading) == 0 && len(trailing) == 0 {
+		return items
+	}
+	byRank := func(list []string) func(a, b hclItem) int {
+		return func(a, b hclItem) int { return rank(list, a) - rank(list, b) }
+	}
+	slices.SortStableFunc(leading, byRank(leadingMetaArguments))
+	slices.SortStableFunc(trailing, byRank(trailingMetaArguments))
+
+	// The blank lines that set the moved arguments apart replace those at
+	// either end of the rest.
+	blank := func(item hclItem) bool {
+		return item.attr == nil && item.block == nil && !slices.ContainsFunc(item.tokens, func(tok *hclwrite.Token) bool {
+			return tok.Type != hclsyntax.TokenNewline
+		})
+	}
+	for len(rest) > 0 && blank(rest[0]) {
+		rest = rest[1:]
+	}
+	for len(rest) > 0 && blank(rest[len(rest)-1]) {
+		rest = rest[:len(rest)-1]
+	}
+	if n := len(rest); n > 0 && rest[n-1].attr != nil {
+		rest[n-1] = lineItem(rest[n-1])
+	}
+
+	out := opening
+	for _, group := range [][]hclItem{leading, rest, trailing} {
+		if len(group) == 0 {
+			continue
+		}
+		if len(out) > len(opening) {
+			out = append(out, hclItem{tokens: newlineTokens(1)})
+		}
+		out = append(out, group...)
+	}
+	return out
+}
+
+// lineItem returns an attribute that ends its line, as it must once it is
+// moved; other items are returned as they are.
+func lineItem(item hclItem) hclItem {
+	if item.attr != nil && !endsLine(item.tokens) {
+		item.tokens = append(slices.Clip(item.tokens), newlineTokens(1)...)
+	}
+	return item
+}
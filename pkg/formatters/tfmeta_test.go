@@ -0,0 +1,33 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Orders_Meta_Arguments verifies that orderMetaArguments
+// moves count and for_each to the start of resource and module blocks and
+// lifecycle, depends_on and provider to the end, set apart by blank lines,
+// and leaves other blocks alone.
+func TestFormatHCL_Orders_Meta_Arguments(t *testing.T) {
+	src := "resource \"aws_instance\" \"web\" {\n  depends_on = [aws_vpc.main]\n  ami = \"ami-1\"\n\n" +
+		"  lifecycle {\n    create_before_destroy = true\n  }\n  # One per zone.\n  count = 3\n" +
+		"  tags = {}\n  provider = aws.west\n}\n" +
+		"module \"vpc\" {\n  source = \"./vpc\"\n  for_each = var.regions\n}\n" +
+		"variable \"count\" {\n  default = 1\n  depends_on = []\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.OrderMetaArguments = true
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "resource \"aws_instance\" \"web\" {\n  # One per zone.\n  count = 3\n\n  ami = \"ami-1\"\n\n" +
+		"  tags = {}\n\n  lifecycle {\n    create_before_destroy = true\n  }\n" +
+		"  depends_on = [aws_vpc.main]\n  provider   = aws.west\n}\n" +
+		"module \"vpc\" {\n  for_each = var.regions\n\n  source = \"./vpc\"\n}\n" +
+		"variable \"count\" {\n  default    = 1\n  depends_on = []\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL = %q; want %q", got, want)
+	}
+	if again, err := FormatHCL("main.tf", got, cfg); err != nil || string(again) != want {
+		t.Fatalf("FormatHCL of its output = %q, %v; want it unchanged", again, err)
+	}
+}
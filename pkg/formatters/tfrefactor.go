@@ -0,0 +1,58 @@
+package formatters
+
+import (
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// refactoringBlocks lists the types of the top-level blocks that record
+// changes to the state, which are written with each argument on a line of
+// its own.
+var refactoringBlocks = []string{"moved", "import", "removed"} //nolint:gochecknoglobals // read-only lookup
+
+// expandRefactoringBlocks writes the moved, import and removed blocks of
+// body that are on one line, such as removed { from = aws_instance.a },
+// over several lines, the way Terraform's documentation writes them.
+// Blocks with comments are left alone.
+func expandRefactoringBlocks(body *hclwrite.Body) {
+	items := bodyItems(body)
+	changed := false
+	for i, item := range items {
+		if item.block == nil || !slices.Contains(refactoringBlocks, item.name) || !isSingleLineBlock(item.tokens) {
+			continue
+		}
+		attrs := item.block.Body().Attributes()
+		if len(attrs) != 1 || len(item.block.Body().Blocks()) > 0 {
+			continue
+		}
+		expanded := hclwrite.NewBlock(item.block.Type(), item.block.Labels())
+		for name, attr := range attrs {
+			expanded.Body().SetAttributeRaw(name, attr.Expr().BuildTokens(nil))
+		}
+		items[i] = hclItem{block: expanded, name: item.name, tokens: expanded.BuildTokens(nil)}
+		changed = true
+	}
+	if changed {
+		setBodyItems(body, items)
+	}
+}
+
+// isSingleLineBlock reports whether the tokens of a block, without
+// comments, have its body on the line of its opening brace.
+func isSingleLineBlock(tokens hclwrite.Tokens) bool {
+	open := slices.IndexFunc(tokens, func(tok *hclwrite.Token) bool { return tok.Type == hclsyntax.TokenOBrace })
+	if open < 0 || slices.ContainsFunc(tokens, func(tok *hclwrite.Token) bool { return tok.Type == hclsyntax.TokenComment }) {
+		return false
+	}
+	for _, tok := range tokens[open+1:] {
+		switch tok.Type {
+		case hclsyntax.TokenNewline:
+			return false
+		case hclsyntax.TokenCBrace:
+			return true
+		}
+	}
+	return false
+}
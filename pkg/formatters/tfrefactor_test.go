@@ -0,0 +1,48 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Lays_Out_Refactoring_Blocks verifies that moved, import
+// and removed blocks get one argument per line in the order of Terraform's
+// documentation, and that the assert blocks of checks are laid out like
+// validation blocks.
+func TestFormatHCL_Lays_Out_Refactoring_Blocks(t *testing.T) {
+	src := "moved {\n  to = aws_instance.b\n  from = aws_instance.a\n}\n" +
+		"removed { from = aws_instance.old }\n" +
+		"import {\n  provider = aws.west\n  id = \"i-123\"\n  to = aws_instance.web\n}\n" +
+		"check \"health\" {\n  assert {\n    condition = data.http.a.status_code == 200 && data.http.b.status_code == 200\n" +
+		"    error_message = (\n      \"Unhealthy.\"\n    )\n  }\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.SortAttributes = true
+	cfg.OrderMetaArguments = true
+	cfg.FormatValidations = true
+	cfg.LineWidth = 60
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "moved {\n  from = aws_instance.a\n  to   = aws_instance.b\n}\n" +
+		"removed {\n  from = aws_instance.old\n}\n" +
+		"import {\n  to = aws_instance.web\n  id = \"i-123\"\n\n  provider = aws.west\n}\n" +
+		"check \"health\" {\n  assert {\n    condition = (\n      data.http.a.status_code == 200 &&\n" +
+		"      data.http.b.status_code == 200\n    )\n    error_message = \"Unhealthy.\"\n  }\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL = %q; want %q", got, want)
+	}
+}
+
+// TestFormatHCL_Keeps_Single_Line_Blocks_Outside_Terraform verifies that
+// only the terraform dialect expands one-line removed blocks.
+func TestFormatHCL_Keeps_Single_Line_Blocks_Outside_Terraform(t *testing.T) {
+	src := "removed { from = a.b }\n"
+	cfg := DefaultHCLConfig()
+	cfg.Dialect = HCLDialectGeneric
+	got, err := FormatHCL("main.hcl", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	if string(got) != src {
+		t.Fatalf("FormatHCL = %q; want %q", got, src)
+	}
+}
@@ -0,0 +1,117 @@
+package formatters
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// isSentinel reports whether path names a Sentinel policy.
+func isSentinel(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".sentinel")
+}
+
+// sentinelFrame is an open bracket of a Sentinel policy. level is the
+// indentation of the line it opens on, which the lines inside it go one
+// level below however many brackets that line opens, like in gofmt.
+// caseBlock is set for the braces of a case statement, whose when and else
+// arms line up with the case keyword, like the cases of a switch in Go.
+type sentinelFrame struct {
+	level     int
+	caseBlock bool
+}
+
+// sentinelScanner tracks whether a position of a Sentinel policy is in
+// code, a string or a comment across lines.
+type sentinelScanner struct {
+	raw, block bool
+}
+
+// formatSentinel lays out the whitespace of a Sentinel policy in the style
+// of sentinel fmt: lines indented with tabs by the brackets open before them,
+// the arms of case statements lined up with the case keyword, no trailing
+// blanks, no leading or repeated blank lines and a final newline. Strings
+// and comments spanning lines are kept as written, and the code itself is
+// never rewritten.
+func formatSentinel(src []byte) []byte {
+	var out bytes.Buffer
+	var frames []sentinelFrame
+	var s sentinelScanner
+	blank, level := 0, 0
+	for _, line := range strings.SplitAfter(string(src), "\n") {
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if s.raw || s.block {
+			out.WriteString(s.scan(line, level, &frames, false) + "\n")
+			continue
+		}
+		text := strings.TrimLeft(line, " \t")
+		if strings.TrimSpace(text) == "" {
+			blank++
+			continue
+		}
+		if blank > 0 && out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		blank = 0
+
+		level = 0
+		if n := len(frames); n > 0 {
+			inner := frames[n-1]
+			level = inner.level + 1
+			if strings.IndexAny(text[:1], "}])") == 0 || inner.caseBlock && isSentinelArm(text) {
+				level = inner.level
+			}
+		}
+		out.WriteString(strings.Repeat("\t", level) + s.scan(text, level, &frames, true) + "\n")
+	}
+	return out.Bytes()
+}
+
+// isSentinelArm reports whether a line starts a when or else arm of a case
+// statement.
+func isSentinelArm(text string) bool {
+	if rest, ok := strings.CutPrefix(text, "else"); ok {
+		return strings.HasPrefix(strings.TrimLeft(rest, " \t"), ":")
+	}
+	return strings.HasPrefix(text, "when ") || strings.HasPrefix(text, "when\t")
+}
+
+// scan follows line, indented level levels, through strings and comments,
+// opening and closing frames for the brackets in code, and returns it,
+// without trailing blanks when code requests it and it does not end inside
+// a raw string.
+func (s *sentinelScanner) scan(line string, level int, frames *[]sentinelFrame, code bool) string {
+	for i := 0; i < len(line); i++ {
+		switch {
+		case s.raw:
+			s.raw = line[i] != '`'
+		case s.block:
+			if strings.HasPrefix(line[i:], "*/") {
+				s.block = false
+				i++
+			}
+		case line[i] == '"':
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case line[i] == '`':
+			s.raw = true
+		case strings.HasPrefix(line[i:], "/*"):
+			s.block = true
+			i++
+		case line[i] == '#' || strings.HasPrefix(line[i:], "//"):
+			i = len(line)
+		case strings.IndexByte("{[(", line[i]) >= 0:
+			caseBlock := line[i] == '{' && strings.HasPrefix(strings.TrimLeft(line, " \t"), "case")
+			*frames = append(*frames, sentinelFrame{level: level, caseBlock: caseBlock})
+		case strings.IndexByte("}])", line[i]) >= 0 && len(*frames) > 0:
+			*frames = (*frames)[:len(*frames)-1]
+		}
+	}
+	if s.raw || !code {
+		return line
+	}
+	return strings.TrimRight(line, " \t")
+}
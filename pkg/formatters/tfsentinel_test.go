@@ -0,0 +1,45 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Formats_Sentinel_Policies verifies that Sentinel policies
+// are indented with tabs by their brackets and case arms, that blank lines
+// and trailing blanks are tidied, and that strings and comments spanning
+// lines are kept as written.
+func TestFormatHCL_Formats_Sentinel_Policies(t *testing.T) {
+	src := "\n\nimport \"tfplan/v2\" as tfplan   \n\n\n" +
+		"allowed = [\n  \"t2.micro\", # small\n    \"t3.micro\",\n]\n" +
+		"doc = `first {\n   second`\n/* a {\n   b */\n" +
+		"kind = func(x) {\n  case x {\n  when \"a\", \"b\":\n  return \"{\"\n" +
+		"  else:\n  if x is \"c\" {\n  return \"c\"\n  }\n  return \"\"\n  }\n}\n" +
+		"main = rule {\n    all tfplan.resource_changes as _, rc {\n  rc.change.after.instance_type in allowed\n    }\n}\n\n"
+	got, err := FormatHCL("restrict.sentinel", []byte(src), DefaultHCLConfig())
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "import \"tfplan/v2\" as tfplan\n\n" +
+		"allowed = [\n\t\"t2.micro\", # small\n\t\"t3.micro\",\n]\n" +
+		"doc = `first {\n   second`\n/* a {\n   b */\n" +
+		"kind = func(x) {\n\tcase x {\n\twhen \"a\", \"b\":\n\t\treturn \"{\"\n" +
+		"\telse:\n\t\tif x is \"c\" {\n\t\t\treturn \"c\"\n\t\t}\n\t\treturn \"\"\n\t}\n}\n" +
+		"main = rule {\n\tall tfplan.resource_changes as _, rc {\n\t\trc.change.after.instance_type in allowed\n\t}\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+// TestFormatHCL_Indents_Sentinel_Brackets_Once_Per_Line verifies that a
+// line opening several brackets indents the lines after it by one level,
+// and that the line closing them goes back to its level.
+func TestFormatHCL_Indents_Sentinel_Brackets_Once_Per_Line(t *testing.T) {
+	src := "main = rule {\n  all rs as r {\n  r.tags contains({\n  \"a\": 1,\n  \"b\": [[\n  2,\n  ]],\n  })\n  }\n}\n"
+	got, err := FormatHCL("tags.sentinel", []byte(src), DefaultHCLConfig())
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "main = rule {\n\tall rs as r {\n\t\tr.tags contains({\n\t\t\t\"a\": 1,\n\t\t\t\"b\": [[\n" +
+		"\t\t\t\t2,\n\t\t\t]],\n\t\t})\n\t}\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
@@ -0,0 +1,189 @@
+package formatters
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// hclItem is an attribute, a block, or the newlines and comments between
+// them, in a body.
+type hclItem struct {
+	attr   *hclwrite.Attribute
+	name   string
+	block  *hclwrite.Block
+	tokens hclwrite.Tokens
+}
+
+// bodyItems returns the items of body in order. The tokens of attributes
+// and blocks include their leading and trailing comments.
+func bodyItems(body *hclwrite.Body) []hclItem {
+	starts := make(map[*hclwrite.Token]hclItem)
+	for name, attr := range body.Attributes() {
+		if tokens := attr.BuildTokens(nil); len(tokens) > 0 {
+			starts[tokens[0]] = hclItem{attr: attr, name: name, tokens: tokens}
+		}
+	}
+	for _, block := range body.Blocks() {
+		if tokens := block.BuildTokens(nil); len(tokens) > 0 {
+			starts[tokens[0]] = hclItem{block: block, name: block.Type(), tokens: tokens}
+		}
+	}
+	var items []hclItem
+	all := body.BuildTokens(nil)
+	for i := 0; i < len(all); {
+		if item, ok := starts[all[i]]; ok {
+			items = append(items, item)
+			i += len(item.tokens)
+			continue
+		}
+		if n := len(items); n > 0 && items[n-1].attr == nil && items[n-1].block == nil {
+			items[n-1].tokens = append(items[n-1].tokens, all[i])
+		} else {
+			items = append(items, hclItem{tokens: hclwrite.Tokens{all[i]}})
+		}
+		i++
+	}
+	return items
+}
+
+// setBodyItems replaces the contents of body with items. Blocks stay
+// blocks of the body, but attributes become unstructured tokens, since
+// hclwrite cannot attach an existing attribute to a body. A block that
+// ended the file without a newline gets one when it no longer ends it.
+func setBodyItems(body *hclwrite.Body, items []hclItem) {
+	for name := range body.Attributes() {
+		body.RemoveAttribute(name)
+	}
+	for _, block := range body.Blocks() {
+		body.RemoveBlock(block)
+	}
+	body.Clear()
+	for i, item := range items {
+		if item.block == nil {
+			body.AppendUnstructuredTokens(item.tokens)
+			continue
+		}
+		body.AppendBlock(item.block)
+		if i < len(items)-1 && !endsLine(item.tokens) {
+			body.AppendNewline()
+		}
+	}
+}
+
+// sortItems orders the items that match among the places they hold in
+// items, leaving the others where they are, and reports whether that
+// changed their order. Attributes that are moved end their line.
+func sortItems(items []hclItem, match func(hclItem) bool, order func(a, b hclItem) int) bool {
+	var places []int
+	var picked []hclItem
+	for i, item := range items {
+		if match(item) {
+			places, picked = append(places, i), append(picked, item)
+		}
+	}
+	if slices.IsSortedFunc(picked, order) {
+		return false
+	}
+	slices.SortStableFunc(picked, order)
+	for k, i := range places {
+		items[i] = picked[k]
+		if items[i].attr != nil && !endsLine(items[i].tokens) {
+			items[i].tokens = append(items[i].tokens, &hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")})
+		}
+	}
+	return true
+}
+
+// isAttribute reports whether item is an attribute.
+func isAttribute(item hclItem) bool {
+	return item.attr != nil
+}
+
+// byName orders attributes by name, putting those first lists first, in
+// the order listed.
+func byName(first []string) func(a, b hclItem) int {
+	rank := func(name string) int {
+		if i := slices.Index(first, name); i >= 0 {
+			return i
+		}
+		return len(first)
+	}
+	return func(a, b hclItem) int {
+		return cmp.Or(cmp.Compare(rank(a.name), rank(b.name)), cmp.Compare(a.name, b.name))
+	}
+}
+
+// sortHCLAttributes orders each run of attributes of body and its nested
+// blocks, those not separated by blank lines, comments or blocks, by name.
+// The attributes leading lists for the type of the enclosing block come
+// first, in the order listed. Comments attached to an attribute move with
+// it.
+func sortHCLAttributes(body *hclwrite.Body, blockType string, leading map[string][]string) {
+	for _, block := range body.Blocks() {
+		sortHCLAttributes(block.Body(), block.Type(), leading)
+	}
+	order := byName(leading[blockType])
+	items := bodyItems(body)
+	changed := false
+	for start := 0; start < len(items); {
+		end := start
+		for end < len(items) && items[end].attr != nil {
+			end++
+		}
+		if end == start {
+			start++
+			continue
+		}
+		changed = sortItems(items[start:end], isAttribute, order) || changed
+		start = end
+	}
+	if changed {
+		setBodyItems(body, items)
+	}
+}
+
+// sortRequiredProviders orders the entries of the required_providers
+// blocks of the terraform blocks of body by name, wherever blank lines or
+// comments put them.
+func sortRequiredProviders(body *hclwrite.Body) {
+	for _, terraform := range body.Blocks() {
+		if terraform.Type() != "terraform" {
+			continue
+		}
+		for _, providers := range terraform.Body().Blocks() {
+			if providers.Type() != "required_providers" {
+				continue
+			}
+			if items := bodyItems(providers.Body()); sortItems(items, isAttribute, byName(nil)) {
+				setBodyItems(providers.Body(), items)
+			}
+		}
+	}
+}
+
+// sortHCLBlocks orders the blocks of body of each of types by their
+// labels, among the places the blocks of that type hold, so that other
+// blocks and the blank lines and comments between blocks stay where they
+// are.
+func sortHCLBlocks(body *hclwrite.Body, types []string) {
+	items := bodyItems(body)
+	byLabels := func(a, b hclItem) int { return slices.Compare(a.block.Labels(), b.block.Labels()) }
+	changed := false
+	for _, typ := range types {
+		match := func(item hclItem) bool { return item.block != nil && item.name == typ }
+		changed = sortItems(items, match, byLabels) || changed
+	}
+	if changed {
+		setBodyItems(body, items)
+	}
+}
+
+// endsLine reports whether tokens end in a newline, which the last item of
+// a file may lack. A trailing line comment holds its newline.
+func endsLine(tokens hclwrite.Tokens) bool {
+	return len(tokens) > 0 && bytes.HasSuffix(tokens[len(tokens)-1].Bytes, []byte("\n"))
+}
@@ -0,0 +1,83 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Sorts_Attributes verifies that sortAttributes orders each
+// run of attributes by name, keeps the leading attributes of module blocks
+// first, moves comments with their attributes and leaves runs separated by
+// blank lines apart.
+func TestFormatHCL_Sorts_Attributes(t *testing.T) {
+	src := "zone = \"a\"\nname = \"n\"\n\nmodule \"m\" {\n  version = \"1.0\"\n  name = \"x\"\n" +
+		"  source = \"./m\"\n\n  # the count\n  count = 2\n  # the bucket\n  bucket = \"b\" # inline\n" +
+		"  nested {\n    y = 1\n    x = 2\n  }\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.SortAttributes = true
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "name = \"n\"\nzone = \"a\"\n\nmodule \"m\" {\n  source  = \"./m\"\n  version = \"1.0\"\n  name    = \"x\"\n" +
+		"\n  # the bucket\n  bucket = \"b\" # inline\n  # the count\n  count = 2\n" +
+		"  nested {\n    x = 2\n    y = 1\n  }\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+// TestFormatHCL_Sorts_Last_Attribute_Without_Newline verifies that an
+// attribute at the end of a file without a newline keeps its line when it
+// is moved.
+func TestFormatHCL_Sorts_Last_Attribute_Without_Newline(t *testing.T) {
+	cfg := DefaultHCLConfig()
+	cfg.SortAttributes = true
+
+	got, err := FormatHCL("main.tfvars", []byte("b = 1\na = 2"), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	if want := "a = 2\nb = 1\n"; string(got) != want {
+		t.Fatalf("FormatHCL = %q; want %q", got, want)
+	}
+}
+
+// TestFormatHCL_Sorts_Required_Providers verifies that
+// sortRequiredProviders orders the entries of required_providers by name
+// across blank lines.
+func TestFormatHCL_Sorts_Required_Providers(t *testing.T) {
+	src := "terraform {\n  required_providers {\n    google = { source = \"hashicorp/google\" }\n\n" +
+		"    aws = { source = \"hashicorp/aws\" }\n    azurerm = { source = \"hashicorp/azurerm\" }\n  }\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.SortRequiredProviders = true
+
+	got, err := FormatHCL("versions.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "terraform {\n  required_providers {\n    aws = { source = \"hashicorp/aws\" }\n\n" +
+		"    azurerm = { source = \"hashicorp/azurerm\" }\n    google  = { source = \"hashicorp/google\" }\n  }\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+// TestFormatHCL_Sorts_Blocks_By_Label verifies that sortBlocks orders the
+// top-level blocks of each listed type by label while other blocks and the
+// blank lines between blocks stay where they are, and that a block moved
+// from the end of a file without a newline still ends its line.
+func TestFormatHCL_Sorts_Blocks_By_Label(t *testing.T) {
+	src := "variable \"b\" {}\n\nlocals {\n  x = 1\n}\n\nvariable \"a\" {}\n\n" +
+		"output \"z\" {\n  value = 1\n}\n\noutput \"y\" {\n  value = 2\n}"
+	cfg := DefaultHCLConfig()
+	cfg.SortBlocks = []string{"variable", "output"}
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "variable \"a\" {}\n\nlocals {\n  x = 1\n}\n\nvariable \"b\" {}\n\n" +
+		"output \"y\" {\n  value = 2\n}\n\noutput \"z\" {\n  value = 1\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+	}
+}
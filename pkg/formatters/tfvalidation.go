@@ -0,0 +1,165 @@
+package formatters
+
+import (
+	"bytes"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// DefaultHCLLineWidth is the default of HCLConfig.LineWidth, dprint's own
+// default.
+const DefaultHCLLineWidth = 120
+
+// isValidationBlock reports whether the blocks a body is in, outermost
+// first, make it the body of a validation block of a variable or of an
+// assert block of a check.
+func isValidationBlock(inBlocks []string) bool {
+	return slices.Equal(inBlocks, []string{"variable", "validation"}) || slices.Equal(inBlocks, []string{"check", "assert"})
+}
+
+// formatValidation lays out the attributes of the body of a validation or
+// assert block: a condition on one line longer than cfg.LineWidth is split
+// after its top-level || or && operators and wrapped in parentheses, the
+// way unwrapped interpolations spanning lines are, and an error_message
+// spread over several lines is joined onto one when it fits.
+func (f *hclFormatter) formatValidation(body *hclwrite.Body, depth int) {
+	attrs := body.Attributes()
+	prefix := func(name string) int { return f.assignmentWidth(attrs, name, depth) }
+	if attr, ok := attrs["condition"]; ok {
+		tokens := attr.Expr().BuildTokens(nil)
+		if !hasNewline(tokens) && prefix("condition")+hclWidth(tokens) > f.cfg.LineWidth {
+			if split, ok := splitCondition(tokens); ok {
+				body.SetAttributeRaw("condition", f.wrapMultiLineIfNeeded(split))
+			}
+		}
+	}
+	if attr, ok := attrs["error_message"]; ok {
+		tokens := attr.Expr().BuildTokens(nil)
+		if joined, ok := joinExpression(tokens); ok && prefix("error_message")+hclWidth(joined) <= f.cfg.LineWidth {
+			body.SetAttributeRaw("error_message", joined)
+		}
+	}
+}
+
+// assignmentWidth returns the width of the line of the attribute name of
+// attrs, in a body indented depth levels, before its expression.
+func (f *hclFormatter) assignmentWidth(attrs map[string]*hclwrite.Attribute, name string, depth int) int {
+	width := len(name)
+	if f.cfg.AlignAssignments {
+		for other := range attrs {
+			width = max(width, len(other))
+		}
+	}
+	return depth*max(f.cfg.IndentWidth, 1) + width + len(" = ")
+}
+
+// splitCondition puts a newline after each top-level || operator of an
+// expression, or each && operator when it has no ||, and one before and
+// after the whole, leaving off parentheses around it. It does not split
+// conditional expressions, nor expressions without such operators.
+func splitCondition(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	if inner, ok := unparenthesize(tokens); ok {
+		tokens = inner
+	}
+	ops := map[hclsyntax.TokenType]int{}
+	depth := 0
+	for _, tok := range tokens {
+		depth += nesting(tok.Type)
+		if depth == 0 {
+			ops[tok.Type]++
+		}
+	}
+	split := hclsyntax.TokenOr
+	switch {
+	case ops[hclsyntax.TokenQuestion] > 0:
+		return nil, false
+	case ops[hclsyntax.TokenOr] == 0 && ops[hclsyntax.TokenAnd] == 0:
+		return nil, false
+	case ops[hclsyntax.TokenOr] == 0:
+		split = hclsyntax.TokenAnd
+	}
+	out := make(hclwrite.Tokens, 0, len(tokens)+ops[split]+parenPairTokens)
+	out = append(out, newlineTokens(1)...)
+	depth = 0
+	for _, tok := range tokens {
+		depth += nesting(tok.Type)
+		out = append(out, tok)
+		if depth == 0 && tok.Type == split {
+			out = append(out, newlineTokens(1)...)
+		}
+	}
+	return append(out, newlineTokens(1)...), true
+}
+
+// joinExpression joins an expression spread over several lines onto one,
+// dropping the trailing commas of its lists and parentheses around the
+// whole. Expressions with comments, heredocs or objects, whose items are
+// separated by newlines, are not joined.
+func joinExpression(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	if !hasNewline(tokens) {
+		return nil, false
+	}
+	joined := make(hclwrite.Tokens, 0, len(tokens))
+	for _, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenComment, hclsyntax.TokenOHeredoc, hclsyntax.TokenOBrace:
+			return nil, false
+		case hclsyntax.TokenNewline:
+			continue
+		case hclsyntax.TokenCBrack:
+			if n := len(joined); n > 0 && joined[n-1].Type == hclsyntax.TokenComma {
+				joined = joined[:n-1]
+			}
+		}
+		joined = append(joined, tok)
+	}
+	if inner, ok := unparenthesize(joined); ok {
+		joined = inner
+	}
+	return joined, true
+}
+
+// unparenthesize returns the tokens inside the parentheses that enclose a
+// whole expression, if they do.
+func unparenthesize(tokens hclwrite.Tokens) (hclwrite.Tokens, bool) {
+	if len(tokens) < parenPairTokens || tokens[0].Type != hclsyntax.TokenOParen ||
+		tokens[len(tokens)-1].Type != hclsyntax.TokenCParen {
+		return nil, false
+	}
+	depth := 0
+	for i, tok := range tokens {
+		depth += nesting(tok.Type)
+		if depth == 0 && i < len(tokens)-1 {
+			return nil, false
+		}
+	}
+	return tokens[1 : len(tokens)-1], true
+}
+
+// nesting returns how a token changes the depth of brackets, quotes and
+// template sequences.
+func nesting(typ hclsyntax.TokenType) int {
+	switch typ {
+	case hclsyntax.TokenOParen, hclsyntax.TokenOBrack, hclsyntax.TokenOBrace, hclsyntax.TokenOQuote,
+		hclsyntax.TokenOHeredoc, hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
+		return 1
+	case hclsyntax.TokenCParen, hclsyntax.TokenCBrack, hclsyntax.TokenCBrace, hclsyntax.TokenCQuote,
+		hclsyntax.TokenCHeredoc, hclsyntax.TokenTemplateSeqEnd:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// hasNewline reports whether tokens span lines.
+func hasNewline(tokens hclwrite.Tokens) bool {
+	return slices.ContainsFunc(tokens, func(tok *hclwrite.Token) bool { return tok.Type == hclsyntax.TokenNewline })
+}
+
+// hclWidth returns the width of tokens on one line as hclwrite prints them.
+func hclWidth(tokens hclwrite.Tokens) int {
+	return utf8.RuneCount(bytes.TrimSpace(hclwrite.Format(tokens.Bytes())))
+}
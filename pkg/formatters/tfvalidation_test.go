@@ -0,0 +1,46 @@
+package formatters
+
+import "testing"
+
+// TestFormatHCL_Lays_Out_Validation_Blocks verifies that formatValidations
+// splits conditions longer than lineWidth at their top-level operators and
+// joins error messages that fit onto one line, in every variable.
+func TestFormatHCL_Lays_Out_Validation_Blocks(t *testing.T) {
+	src := "variable \"a\" {\n  validation {\n" +
+		"    condition = length(var.a) > 4 && substr(var.a, 0, 4) == \"ami-\" && contains([\"x\"], var.a)\n" +
+		"    error_message = join(\" \", [\n      \"The image_id must\",\n      \"start with ami-.\",\n    ])\n" +
+		"  }\n}\n" +
+		"variable \"b\" {\n  validation {\n    condition = var.b == \"x\" ? true : var.b == \"yyyyyyyyyyyyyyyyyyyyyyyyyyyyyy\"\n" +
+		"    error_message = (\n      \"Bad.\"\n    )\n  }\n}\n"
+	cfg := DefaultHCLConfig()
+	cfg.FormatValidations = true
+	cfg.LineWidth = 72
+
+	got, err := FormatHCL("main.tf", []byte(src), cfg)
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "variable \"a\" {\n  validation {\n    condition = (\n      length(var.a) > 4 &&\n" +
+		"      substr(var.a, 0, 4) == \"ami-\" &&\n      contains([\"x\"], var.a)\n    )\n" +
+		"    error_message = join(\" \", [\"The image_id must\", \"start with ami-.\"])\n  }\n}\n" +
+		"variable \"b\" {\n  validation {\n" +
+		"    condition     = var.b == \"x\" ? true : var.b == \"yyyyyyyyyyyyyyyyyyyyyyyyyyyyyy\"\n" +
+		"    error_message = \"Bad.\"\n  }\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL = %q; want %q", got, want)
+	}
+}
+
+// TestFormatHCL_Normalizes_Every_Variable_Type verifies that the types of
+// all variables are rewritten, not only those of the first.
+func TestFormatHCL_Normalizes_Every_Variable_Type(t *testing.T) {
+	src := "variable \"a\" {\n  type = \"string\"\n}\nvariable \"b\" {\n  type = \"list\"\n}\n"
+	got, err := FormatHCL("main.tf", []byte(src), DefaultHCLConfig())
+	if err != nil {
+		t.Fatalf("FormatHCL: %v", err)
+	}
+	want := "variable \"a\" {\n  type = string\n}\nvariable \"b\" {\n  type = list(string)\n}\n"
+	if string(got) != want {
+		t.Fatalf("FormatHCL = %q; want %q", got, want)
+	}
+}
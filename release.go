@@ -0,0 +1,25 @@
+// Package goat holds the release metadata shared by every plugin built from
+// this repository, so that a version bump or a license change reaches all
+// plugin binaries from one place.
+package goat
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed VERSION
+var versionFile string
+
+//go:embed LICENSE
+var licenseText string
+
+// Version returns the version of the current release.
+func Version() string {
+	return strings.TrimSpace(versionFile)
+}
+
+// License returns the license text every plugin reports.
+func License() string {
+	return licenseText
+}
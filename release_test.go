@@ -0,0 +1,22 @@
+package goat
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestVersion_Is_Semantic verifies that the embedded version is a plain
+// semantic version, as written by the release tooling.
+func TestVersion_Is_Semantic(t *testing.T) {
+	if !regexp.MustCompile(`^\d+\.\d+\.\d+$`).MatchString(Version()) {
+		t.Fatalf("Version = %q; want MAJOR.MINOR.PATCH", Version())
+	}
+}
+
+// TestLicense_Is_Embedded verifies that the license text is embedded.
+func TestLicense_Is_Embedded(t *testing.T) {
+	if !strings.Contains(License(), "License") {
+		t.Fatalf("License = %q; want the license text", License())
+	}
+}